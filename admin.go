@@ -0,0 +1,53 @@
+package gobo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AdminPrefix is the path prefix under which Gobo serves its own endpoints.
+// Middleware answers these paths directly; with Stub/Intercept, mount
+// AdminHandler on the mux yourself.
+const AdminPrefix = "/_gobo/"
+
+// AdminHandler returns an http.Handler for Gobo's admin endpoints:
+//
+//	GET /_gobo/openapi.json   OpenAPI 3.1 document of the mocked routes
+//	GET /_gobo/drift          schema drift observed by Shadow handlers
+//	GET /_gobo/pools          prefetch pool stats, see WithPrefetch
+//	GET /_gobo/limits         queue depth of each LimitingGenerator
+//	POST /_gobo/cache/invalidate?method=GET&path=/users
+//	                          drop cached responses, see InvalidateCache
+func (g *Gobo) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+AdminPrefix+"openapi.json", g.serveOpenAPI)
+	mux.HandleFunc("GET "+AdminPrefix+"drift", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"routes": g.DriftReport()})
+	})
+	mux.HandleFunc("GET "+AdminPrefix+"pools", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"pools": g.PoolStats()})
+	})
+	mux.HandleFunc("GET "+AdminPrefix+"limits", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"limiters": g.LimitStats()})
+	})
+	mux.HandleFunc("POST "+AdminPrefix+"cache/invalidate", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		writeJSON(w, http.StatusOK, map[string]any{"invalidated": g.InvalidateCache(q.Get("method"), q.Get("path"))})
+	})
+	return mux
+}
+
+// isAdminPath reports whether the request targets an admin endpoint.
+func isAdminPath(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, AdminPrefix)
+}
+
+func (g *Gobo) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := g.OpenAPI()
+	if err != nil {
+		http.Error(w, "Gobo OpenAPI export failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(doc)
+}
@@ -0,0 +1,223 @@
+package gobo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAnthropicURL       = "https://api.anthropic.com"
+	defaultAnthropicKeyEnv    = "ANTHROPIC_API_KEY"
+	defaultAnthropicMaxTokens = 4096
+	anthropicVersion          = "2023-06-01"
+
+	// anthropicTool is the single tool the model is forced to call; its
+	// input schema is the response schema.
+	anthropicTool = "respond"
+)
+
+// AnthropicGenerator generates responses with the Anthropic Messages API.
+// The model is forced to call a single tool whose input schema is the JSON
+// Schema derived from the response schema, so its output is always a JSON
+// object of the right shape.
+type AnthropicGenerator struct {
+	baseURL    string
+	model      string
+	apiKeyEnv  string
+	maxTokens  int
+	httpClient *http.Client
+}
+
+// AnthropicOption configures an AnthropicGenerator.
+type AnthropicOption func(*AnthropicGenerator)
+
+// AnthropicBaseURL overrides the API address, for proxies or test servers.
+func AnthropicBaseURL(url string) AnthropicOption {
+	return func(c *AnthropicGenerator) {
+		c.baseURL = url
+	}
+}
+
+// AnthropicAPIKeyEnv names the environment variable holding the API key
+// (default ANTHROPIC_API_KEY).
+func AnthropicAPIKeyEnv(name string) AnthropicOption {
+	return func(c *AnthropicGenerator) {
+		c.apiKeyEnv = name
+	}
+}
+
+// AnthropicMaxTokens caps the length of each response (default 4096).
+func AnthropicMaxTokens(n int) AnthropicOption {
+	return func(c *AnthropicGenerator) {
+		c.maxTokens = n
+	}
+}
+
+// NewAnthropicGenerator initializes a client for the Anthropic Messages API.
+//
+//	g := gobo.New(gobo.WithGenerator(gobo.NewAnthropicGenerator("claude-sonnet-4-5")))
+func NewAnthropicGenerator(model string, opts ...AnthropicOption) *AnthropicGenerator {
+	c := &AnthropicGenerator{
+		baseURL:   defaultAnthropicURL,
+		model:     model,
+		apiKeyEnv: defaultAnthropicKeyEnv,
+		maxTokens: defaultAnthropicMaxTokens,
+		httpClient: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// GenerateResponse asks the model to call the response tool and returns the
+// tool input as the response body.
+func (c *AnthropicGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	apiKey := os.Getenv(c.apiKeyEnv)
+	if apiKey == "" {
+		return nil, fmt.Errorf("anthropic api key not set in %s", c.apiKeyEnv)
+	}
+
+	system, user, err := renderChatPrompt(reqCtx, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	}
+
+	// Tool inputs must be objects; other shapes are wrapped in one
+	inputSchema, wrapped := anthropicInputSchema(jsonSchemaFor(schema))
+
+	msgReq := map[string]any{
+		"model":      c.model,
+		"max_tokens": c.maxTokens,
+		"messages": []map[string]any{
+			{"role": "user", "content": user},
+		},
+		"tools": []map[string]any{{
+			"name":         anthropicTool,
+			"description":  "Send the mock HTTP response body.",
+			"input_schema": inputSchema,
+		}},
+		"tool_choice": map[string]any{"type": "tool", "name": anthropicTool},
+	}
+	if system != "" {
+		msgReq["system"] = system
+	}
+
+	reqBody, err := json.Marshal(msgReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal anthropic request: %w", err)
+	}
+
+	endpoint := strings.TrimRight(c.baseURL, "/") + "/v1/messages"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Api-Key", apiKey)
+	httpReq.Header.Set("Anthropic-Version", anthropicVersion)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(bodyBytes, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("anthropic responded with status %d: %s: %s", resp.StatusCode, apiErr.Error.Type, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("anthropic responded with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var msgResp struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&msgResp); err != nil {
+		return nil, fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+
+	for _, block := range msgResp.Content {
+		if block.Type != "tool_use" || block.Name != anthropicTool {
+			continue
+		}
+		if !wrapped {
+			return block.Input, nil
+		}
+		var input struct {
+			Response json.RawMessage `json:"response"`
+		}
+		if err := json.Unmarshal(block.Input, &input); err != nil || input.Response == nil {
+			return nil, fmt.Errorf("llm returned invalid json")
+		}
+		return input.Response, nil
+	}
+	return nil, fmt.Errorf("anthropic response has no %s tool call (stop reason %q)", anthropicTool, msgResp.StopReason)
+}
+
+// anthropicInputSchema returns a tool input schema for a response schema.
+// Tool inputs must be objects, so unless the root is explicitly an object
+// (typed "object", possibly with "null", or given properties) it is wrapped
+// in a "response" property and wrapped is true.
+func anthropicInputSchema(schema map[string]any) (map[string]any, bool) {
+	if schema["type"] == "object" {
+		return schema, false
+	}
+	if objectRoot(schema) {
+		input := make(map[string]any, len(schema)+1)
+		for k, v := range schema {
+			input[k] = v
+		}
+		input["type"] = "object"
+		return input, false
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"response": schema},
+		"required":   []any{"response"},
+	}, true
+}
+
+// objectRoot reports whether a schema's root is explicitly an object: typed
+// "object" (alone or with "null"), or untyped with properties.
+func objectRoot(schema map[string]any) bool {
+	if t, ok := schema["type"].(string); ok {
+		return t == "object"
+	}
+	if types, ok := schemaList(schema["type"]); ok {
+		object := false
+		for _, t := range types {
+			switch t {
+			case "object":
+				object = true
+			case "null":
+			default:
+				return false
+			}
+		}
+		return object
+	}
+	_, ok := schema["properties"]
+	return ok
+}
@@ -0,0 +1,127 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newAnthropicStub speaks the Messages API, answering every request by
+// calling the forced tool with input. It records the last request.
+func newAnthropicStub(t *testing.T, input string, got *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("X-Api-Key") != "test-key" || r.Header.Get("Anthropic-Version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+			return
+		}
+		*got = map[string]any{}
+		json.NewDecoder(r.Body).Decode(got)
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"tool_use","content":[` +
+			`{"type":"tool_use","id":"toolu_1","name":"respond","input":` + input + `}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAnthropicGenerator(t *testing.T) {
+	t.Setenv("GOBO_TEST_ANTHROPIC_KEY", "test-key")
+	var got map[string]any
+	srv := newAnthropicStub(t, `{"id":"u_1","name":"Ana"}`, &got)
+
+	c := NewAnthropicGenerator("claude-test", AnthropicBaseURL(srv.URL), AnthropicAPIKeyEnv("GOBO_TEST_ANTHROPIC_KEY"), AnthropicMaxTokens(512))
+	out, err := c.GenerateResponse(context.Background(), RequestContext{Method: "GET", URL: "/users/1"}, map[string]any{"id": "", "name": ""})
+	if err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+	if string(out) != `{"id":"u_1","name":"Ana"}` {
+		t.Errorf("Unexpected response %s", out)
+	}
+
+	if got["model"] != "claude-test" || got["max_tokens"] != 512.0 {
+		t.Errorf("Unexpected request %v", got)
+	}
+	if choice, _ := got["tool_choice"].(map[string]any); choice["type"] != "tool" || choice["name"] != "respond" {
+		t.Errorf("Expected forced tool choice, got %v", got["tool_choice"])
+	}
+	tools, _ := got["tools"].([]any)
+	tool, _ := tools[0].(map[string]any)
+	if schema, _ := tool["input_schema"].(map[string]any); schema["type"] != "object" || schema["properties"] == nil {
+		t.Errorf("Expected derived JSON Schema as input schema, got %v", tool["input_schema"])
+	}
+	if system, _ := got["system"].(string); !strings.Contains(system, "Gobo") {
+		t.Errorf("Expected system prompt, got %q", got["system"])
+	}
+}
+
+func TestAnthropicGenerator_ArrayRoot(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	var got map[string]any
+	srv := newAnthropicStub(t, `{"response":[{"id":"u_1"}]}`, &got)
+
+	c := NewAnthropicGenerator("claude-test", AnthropicBaseURL(srv.URL))
+	out, err := c.GenerateResponse(context.Background(), RequestContext{Method: "GET", URL: "/users"}, []map[string]any{{"id": ""}})
+	if err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+	if string(out) != `[{"id":"u_1"}]` {
+		t.Errorf("Expected unwrapped array, got %s", out)
+	}
+}
+
+func TestAnthropicInputSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  map[string]any
+		wrapped bool
+	}{
+		{"object", map[string]any{"type": "object"}, false},
+		{"nullable object", map[string]any{"type": []any{"object", "null"}}, false},
+		{"untyped object", map[string]any{"properties": map[string]any{"id": map[string]any{}}}, false},
+		{"enum", map[string]any{"enum": []any{"a", "b"}}, true},
+		{"oneOf scalars", map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "integer"}}}, true},
+		{"object or string", map[string]any{"type": []any{"object", "string"}}, true},
+		{"array", map[string]any{"type": "array"}, true},
+		{"nullable string", map[string]any{"type": []any{"string", "null"}}, true},
+	}
+	for _, tt := range tests {
+		input, wrapped := anthropicInputSchema(tt.schema)
+		if wrapped != tt.wrapped || input["type"] != "object" {
+			t.Errorf("%s: expected wrapped=%v with an object root, got %v %v", tt.name, tt.wrapped, wrapped, input)
+		}
+	}
+}
+
+func TestAnthropicGenerator_Errors(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "wrong-key")
+	var got map[string]any
+	srv := newAnthropicStub(t, `{}`, &got)
+
+	c := NewAnthropicGenerator("claude-test", AnthropicBaseURL(srv.URL))
+	_, err := c.GenerateResponse(context.Background(), RequestContext{}, map[string]any{"id": ""})
+	if err == nil || !strings.Contains(err.Error(), "authentication_error") {
+		t.Errorf("Expected API error, got %v", err)
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := c.GenerateResponse(context.Background(), RequestContext{}, map[string]any{}); err == nil {
+		t.Error("Expected error without an API key")
+	}
+}
+
+func TestAnthropicGenerator_WithGenerator(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	var got map[string]any
+	srv := newAnthropicStub(t, `{"status":"ok"}`, &got)
+
+	g := New(WithGenerator(NewAnthropicGenerator("claude-test", AnthropicBaseURL(srv.URL))))
+	rec := httptest.NewRecorder()
+	g.Stub(map[string]any{"status": ""}).ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Body.String() != `{"status":"ok"}` {
+		t.Errorf("Unexpected body %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,430 @@
+package gobo
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPlaceholderSide bounds the dimensions of placeholder images.
+const maxPlaceholderSide = 4096
+
+// ErrBadArtifactRequest is returned by Artifacts for requests they cannot
+// render, such as invalid query parameters; such requests get 400 Bad
+// Request.
+var ErrBadArtifactRequest = errors.New("bad artifact request")
+
+// Artifact produces the file served by a download route, see Download.
+type Artifact interface {
+	Render(req ArtifactRequest) (*ArtifactFile, error)
+}
+
+// ArtifactRequest is what an Artifact renders a file for.
+type ArtifactRequest struct {
+	Request *http.Request
+	// Pattern is the route pattern, used to resolve path wildcards when the
+	// route is matched by Gobo rather than ServeMux.
+	Pattern string
+	// Generate asks the route's generator for a response shaped by schema.
+	Generate func(schema any) ([]byte, error)
+}
+
+// PathValue returns a path wildcard of the request, such as "id" in
+// "/invoices/{id}".
+func (a ArtifactRequest) PathValue(name string) string {
+	if v := a.Request.PathValue(name); v != "" {
+		return v
+	}
+	return pathValues(a.Pattern, a.Request.URL.Path)[name]
+}
+
+// ArtifactFile is a rendered download.
+type ArtifactFile struct {
+	Name        string // file name for Content-Disposition
+	ContentType string // defaults to the type of Name's extension
+	Content     []byte
+	ModTime     time.Time // zero omits Last-Modified
+	Inline      bool      // display in the browser instead of downloading
+}
+
+// RouteArtifact makes a route serve a file instead of a generated JSON body.
+func RouteArtifact(a Artifact) RouteOption {
+	return func(r *routeSchema) {
+		r.Artifact = a
+	}
+}
+
+// Download returns an http.Handler serving files, for routes such as
+// invoice PDFs or avatars. Responses carry Content-Type, Content-Length and
+// Content-Disposition, and support Range and conditional requests. Route
+// headers and latency apply; the status is 200, or 206 for ranges.
+//
+//	mux.Handle("GET /invoices/{id}", g.Download(gobo.PlaceholderPDF{Title: "Invoice {id}"}))
+//	mux.Handle("GET /avatars/{file}", g.Download(gobo.FixtureFiles{Dir: "testdata/avatars"}))
+func (g *Gobo) Download(a Artifact, opts ...RouteOption) http.Handler {
+	return g.Stub(nil, append(opts, RouteArtifact(a))...)
+}
+
+// writeArtifact renders and serves a route's file.
+func (g *Gobo) writeArtifact(w http.ResponseWriter, r *http.Request, route *routeSchema, reqCtx RequestContext) {
+	gen := g.generator(route)
+	if gen == nil {
+		gen = StaticGenerator{}
+	}
+	file, err := route.Artifact.Render(ArtifactRequest{
+		Request: r,
+		Pattern: route.PathPrefix,
+		Generate: func(schema any) ([]byte, error) {
+			return gen.GenerateResponse(r.Context(), reqCtx, schema)
+		},
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "Gobo Mock File Not Found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrBadArtifactRequest) {
+		http.Error(w, "Gobo Mock Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		g.logf("Error rendering file: %v", err)
+		http.Error(w, "Gobo Mock Generation Failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(file.Name))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(file.Content)
+	}
+	disposition := "attachment"
+	if file.Inline {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": file.Name}))
+	for k, v := range route.Headers {
+		w.Header().Set(k, v)
+	}
+	http.ServeContent(w, r, file.Name, file.ModTime, bytes.NewReader(file.Content))
+}
+
+// FixtureFiles serves files from a directory. The file is named after the
+// last segment of the request path ("/avatars/ada.png" serves
+// "Dir/ada.png"), or by Name with path wildcards filled in.
+type FixtureFiles struct {
+	Dir string
+	// Name is the file to serve, such as "invoice-{id}.pdf".
+	Name string
+	// Default is served when the requested file does not exist.
+	Default string
+}
+
+// Render implements the Artifact interface.
+func (f FixtureFiles) Render(req ArtifactRequest) (*ArtifactFile, error) {
+	name := path.Base(req.Request.URL.Path)
+	if f.Name != "" {
+		name = fillWildcards(f.Name, req)
+	}
+	file, err := readFixture(f.Dir, name)
+	if errors.Is(err, fs.ErrNotExist) && f.Default != "" {
+		file, err = readFixture(f.Dir, f.Default)
+	}
+	if err != nil {
+		return nil, err
+	}
+	file.Inline = strings.HasPrefix(mime.TypeByExtension(path.Ext(file.Name)), "image/")
+	return file, nil
+}
+
+// readFixture reads name from dir, refusing names that escape it.
+func readFixture(dir, name string) (*ArtifactFile, error) {
+	if !filepath.IsLocal(name) {
+		return nil, fmt.Errorf("fixture %q: %w", name, fs.ErrNotExist)
+	}
+	p := filepath.Join(dir, name)
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("fixture %q is a directory: %w", name, fs.ErrNotExist)
+	}
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	return &ArtifactFile{Name: filepath.Base(p), Content: content, ModTime: info.ModTime()}, nil
+}
+
+// PlaceholderPNG generates a solid-color PNG. The "width", "height" and
+// "color" query parameters (or path wildcards) override the defaults, so
+// "/avatars/1?width=64&height=64" yields a 64x64 image.
+type PlaceholderPNG struct {
+	Width, Height int    // default 100x100
+	Color         string // hex such as "#cccccc" (the default)
+}
+
+// Render implements the Artifact interface.
+func (p PlaceholderPNG) Render(req ArtifactRequest) (*ArtifactFile, error) {
+	width, err := intParam(req, "width", p.Width, 100)
+	if err != nil {
+		return nil, err
+	}
+	height, err := intParam(req, "height", p.Height, 100)
+	if err != nil {
+		return nil, err
+	}
+	if width < 1 || height < 1 || width > maxPlaceholderSide || height > maxPlaceholderSide {
+		return nil, fmt.Errorf("%w: placeholder dimensions must be between 1 and %d", ErrBadArtifactRequest, maxPlaceholderSide)
+	}
+	hex := stringParam(req, "color", p.Color, "#cccccc")
+	fill, err := parseHexColor(hex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadArtifactRequest, err)
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{fill})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return &ArtifactFile{
+		Name:        fileName(req, ".png"),
+		ContentType: "image/png",
+		Content:     buf.Bytes(),
+		Inline:      true,
+	}, nil
+}
+
+// PlaceholderPDF generates a minimal one-page PDF. With a Schema, the
+// route's generator fills it and its fields are printed as "key: value"
+// lines, giving documents with plausible content.
+type PlaceholderPDF struct {
+	Title  string // may use path wildcards, such as "Invoice {id}"
+	Text   string // body text; lines are split on newlines
+	Schema any
+}
+
+// Render implements the Artifact interface.
+func (p PlaceholderPDF) Render(req ArtifactRequest) (*ArtifactFile, error) {
+	title := fillWildcards(p.Title, req)
+	if title == "" {
+		title = "Placeholder for " + req.Request.URL.Path
+	}
+	lines := []string{title, ""}
+	if p.Text != "" {
+		lines = append(lines, strings.Split(fillWildcards(p.Text, req), "\n")...)
+	}
+	if p.Schema != nil {
+		out, err := req.Generate(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(out, &fields); err != nil {
+			return nil, fmt.Errorf("pdf schema must generate a json object: %w", err)
+		}
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			lines = append(lines, k+": "+scalarText(fields[k]))
+		}
+	}
+	return &ArtifactFile{
+		Name:        fileName(req, ".pdf"),
+		ContentType: "application/pdf",
+		Content:     minimalPDF(lines),
+	}, nil
+}
+
+// minimalPDF writes a valid single-page PDF printing lines in Helvetica.
+func minimalPDF(lines []string) []byte {
+	var text strings.Builder
+	text.WriteString("BT /F1 12 Tf 14 TL 72 770 Td\n")
+	for _, line := range lines {
+		text.WriteString("(" + pdfEscape(line) + ") Tj T*\n")
+	}
+	text.WriteString("ET")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", text.Len(), text.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfEscape escapes a PDF string literal, dropping characters Helvetica's
+// standard encoding cannot show.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7F:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('?')
+		}
+	}
+	return b.String()
+}
+
+// PlaceholderZip generates a zip archive holding Files, keyed by name.
+// Without files it holds a single README.txt.
+type PlaceholderZip struct {
+	Files map[string]string
+}
+
+// Render implements the Artifact interface.
+func (p PlaceholderZip) Render(req ArtifactRequest) (*ArtifactFile, error) {
+	files := p.Files
+	if len(files) == 0 {
+		files = map[string]string{"README.txt": "Placeholder archive for " + req.Request.URL.Path + "\n"}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		f, err := zw.Create(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zip entry: %w", err)
+		}
+		if _, err := f.Write([]byte(files[name])); err != nil {
+			return nil, fmt.Errorf("failed to write zip entry: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish zip: %w", err)
+	}
+	return &ArtifactFile{
+		Name:        fileName(req, ".zip"),
+		ContentType: "application/zip",
+		Content:     buf.Bytes(),
+	}, nil
+}
+
+// fileName names a generated file after the last path segment, adding ext
+// when the segment lacks it.
+func fileName(req ArtifactRequest, ext string) string {
+	name := path.Base(req.Request.URL.Path)
+	if name == "/" || name == "." {
+		name = "placeholder"
+	}
+	if !strings.EqualFold(path.Ext(name), ext) {
+		name += ext
+	}
+	return name
+}
+
+// fillWildcards replaces {name} in s with the request's path values.
+func fillWildcards(s string, req ArtifactRequest) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '{')
+		end := strings.IndexByte(s[start+1:], '}')
+		if start < 0 || end < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:start])
+		b.WriteString(req.PathValue(s[start+1 : start+1+end]))
+		s = s[start+end+2:]
+	}
+}
+
+// stringParam reads a query parameter or path wildcard, falling back to
+// value and then to def.
+func stringParam(req ArtifactRequest, name, value, def string) string {
+	if v := req.Request.URL.Query().Get(name); v != "" {
+		return v
+	}
+	if v := req.PathValue(name); v != "" {
+		return v
+	}
+	if value != "" {
+		return value
+	}
+	return def
+}
+
+// intParam is stringParam for integers; values that are not integers are
+// bad requests.
+func intParam(req ArtifactRequest, name string, value, def int) (int, error) {
+	if s := stringParam(req, name, "", ""); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s must be an integer, got %q", ErrBadArtifactRequest, name, s)
+		}
+		return n, nil
+	}
+	if value != 0 {
+		return value, nil
+	}
+	return def, nil
+}
+
+// parseHexColor parses "#rgb" or "#rrggbb", with or without the hash.
+func parseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xFF}, nil
+}
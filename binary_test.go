@@ -0,0 +1,155 @@
+package gobo
+
+import (
+	"archive/zip"
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func serve(h http.Handler, pattern string, req *http.Request) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle(pattern, h)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDownload_PNG(t *testing.T) {
+	g := New()
+	rec := serve(g.Download(PlaceholderPNG{}), "GET /avatars/{id}",
+		httptest.NewRequest("GET", "/avatars/7?width=32&height=16&color=f00", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `inline; filename=7.png` {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 32 || b.Dy() != 16 {
+		t.Errorf("Expected 32x16, got %v", b)
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0xFFFF || g != 0 || b != 0 {
+		t.Errorf("Expected red, got %v %v %v", r, g, b)
+	}
+
+	for _, query := range []string{"width=abc", "height=0", "width=99999", "color=purple"} {
+		rec := serve(g.Download(PlaceholderPNG{}), "GET /avatars/{id}",
+			httptest.NewRequest("GET", "/avatars/7?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestDownload_PDFWithGeneratedFields(t *testing.T) {
+	g := New(WithGenerator(&mockGenerator{Response: []byte(`{"total":"12.50 (EUR)"}`)}))
+	rec := serve(g.Download(PlaceholderPDF{Title: "Invoice {id}", Schema: map[string]any{"total": ""}}),
+		"GET /invoices/{id}", httptest.NewRequest("GET", "/invoices/42.pdf", nil))
+
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "%PDF-1.4") || !strings.HasSuffix(body, "%%EOF\n") {
+		t.Fatalf("Expected a PDF document, got %q", body)
+	}
+	for _, want := range []string{"(Invoice 42.pdf) Tj", `(total: 12.50 \(EUR\)) Tj`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the PDF", want)
+		}
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename=42.pdf` {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+	if cl := rec.Header().Get("Content-Length"); cl != strconv.Itoa(len(body)) {
+		t.Errorf("Expected Content-Length %d, got %q", len(body), cl)
+	}
+}
+
+func TestDownload_ZipAndRange(t *testing.T) {
+	g := New()
+	h := g.Download(PlaceholderZip{Files: map[string]string{"a.txt": "hello"}})
+
+	rec := serve(h, "GET /export", httptest.NewRequest("GET", "/export", nil))
+	full := rec.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(full), int64(len(full)))
+	if err != nil || len(zr.File) != 1 || zr.File[0].Name != "a.txt" {
+		t.Fatalf("Unexpected zip (%v)", err)
+	}
+	if rec.Header().Get("Accept-Ranges") != "bytes" {
+		t.Error("Expected Accept-Ranges: bytes")
+	}
+
+	req := httptest.NewRequest("GET", "/export", nil)
+	req.Header.Set("Range", "bytes=0-3")
+	rec = serve(h, "GET /export", req)
+	if rec.Code != http.StatusPartialContent || !bytes.Equal(rec.Body.Bytes(), full[:4]) {
+		t.Errorf("Expected the first 4 bytes with 206, got %d %q", rec.Code, rec.Body.Bytes())
+	}
+	if cr := rec.Header().Get("Content-Range"); cr != "bytes 0-3/"+strconv.Itoa(len(full)) {
+		t.Errorf("Unexpected Content-Range %q", cr)
+	}
+}
+
+func TestDownload_FixtureFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "invoice-1.pdf"), []byte("%PDF-fixture"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sample.pdf"), []byte("%PDF-sample"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := New()
+	h := g.Download(FixtureFiles{Dir: dir, Name: "invoice-{id}.pdf", Default: "sample.pdf"})
+
+	rec := serve(h, "GET /invoices/{id}", httptest.NewRequest("GET", "/invoices/1", nil))
+	if rec.Body.String() != "%PDF-fixture" || rec.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("Unexpected response %q %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	if rec.Header().Get("Last-Modified") == "" {
+		t.Error("Expected Last-Modified for fixtures")
+	}
+	rec = serve(h, "GET /invoices/{id}", httptest.NewRequest("GET", "/invoices/2", nil))
+	if rec.Body.String() != "%PDF-sample" {
+		t.Errorf("Expected the default fixture, got %q", rec.Body.String())
+	}
+
+	rec = serve(g.Download(FixtureFiles{Dir: dir}), "GET /files/{name}", httptest.NewRequest("GET", "/files/missing.pdf", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing fixture, got %d", rec.Code)
+	}
+}
+
+func TestDownload_ConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gobo.yaml")
+	config := "routes:\n  - pattern: GET /thumbs/{id}\n    download:\n      placeholder: png\n      width: 8\n      height: 8\n"
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := New()
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	g.Middleware(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "/thumbs/3", nil))
+	img, err := png.Decode(rec.Body)
+	if err != nil || img.Bounds().Dx() != 8 {
+		t.Fatalf("Expected an 8px PNG (%v)", err)
+	}
+}
+
+func TestPathValues(t *testing.T) {
+	values := pathValues("/files/{bucket}/{key...}", "/files/b1/a/b.txt")
+	if values["bucket"] != "b1" || values["key"] != "a/b.txt" {
+		t.Errorf("Unexpected path values %v", values)
+	}
+}
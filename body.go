@@ -0,0 +1,238 @@
+package gobo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultMaxBodyCapture bounds how much of a request body reaches
+// generators, see WithMaxBodyCapture.
+const defaultMaxBodyCapture = 1 << 20
+
+// FileSummary describes an uploaded file in place of its contents.
+type FileSummary struct {
+	Field       string `json:"field"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type,omitempty"`
+	SHA256      string `json:"sha256"`
+}
+
+// WithMaxBodyCapture sets how many bytes of a request body are captured in
+// RequestContext.Body (default 1 MiB); longer bodies are cut and flagged
+// with BodyTruncated. Handlers behind Gobo still receive the whole body.
+// Zero or less captures bodies of any size.
+func WithMaxBodyCapture(n int64) Option {
+	return func(g *Gobo) {
+		g.maxBody = n
+	}
+}
+
+// extractRequestContext pulls relevant info from an http.Request. Form
+// bodies are parsed into Form, and multipart file parts are summarized in
+// Files instead of being captured. At most maxBody bytes of the body are
+// captured; zero or less means no limit.
+func extractRequestContext(r *http.Request, maxBody int64) RequestContext {
+	ctx := RequestContext{
+		Method:     r.Method,
+		URL:        r.URL.String(),
+		Headers:    r.Header,
+		Validation: validationFromContext(r.Context()),
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return ctx
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		// File parts are streamed through a hasher; the body is spooled for
+		// handlers downstream, in memory up to maxBody and on disk beyond.
+		spool := &bodySpool{limit: maxBody}
+		src := io.TeeReader(r.Body, spool)
+		form, files, truncated, err := parseMultipart(src, params["boundary"], maxBody)
+		if _, drainErr := io.Copy(io.Discard, src); err == nil {
+			err = drainErr
+		}
+		r.Body = spool.body(r.Context(), r.Body)
+		if err == nil {
+			ctx.Form, ctx.Files, ctx.BodyTruncated = form, files, truncated
+			return ctx
+		}
+		ctx.Body, ctx.BodyTruncated = spool.mem.String(), spool.file != nil
+		if ctx.BodyTruncated {
+			ctx.Body = strings.ToValidUTF8(ctx.Body, "")
+		}
+		return ctx
+	}
+
+	ctx.Body, ctx.BodyTruncated = captureBody(r, maxBody)
+	if mediaType == "application/x-www-form-urlencoded" && !ctx.BodyTruncated {
+		if values, err := url.ParseQuery(ctx.Body); err == nil && len(values) > 0 {
+			ctx.Form = values
+		}
+	}
+	return ctx
+}
+
+// captureBody reads up to maxBody bytes of the request body and puts them
+// back in front of the rest, so the body can still be read in full.
+func captureBody(r *http.Request, maxBody int64) (string, bool) {
+	src := io.Reader(r.Body)
+	if maxBody > 0 {
+		src = io.LimitReader(r.Body, maxBody+1)
+	}
+	head, err := io.ReadAll(src)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	if err != nil {
+		return "", false
+	}
+	return truncate(head, maxBody)
+}
+
+// bodySpool keeps a copy of a request body read through it: up to limit
+// bytes in memory and the rest in a temporary file, so large uploads are
+// not buffered in memory. A limit of zero or less keeps everything in memory.
+type bodySpool struct {
+	limit int64
+	mem   bytes.Buffer
+	file  *os.File
+	err   error // spooling failed; the copy is incomplete
+}
+
+func (s *bodySpool) Write(p []byte) (int, error) {
+	if s.file == nil && (s.limit <= 0 || int64(s.mem.Len()+len(p)) <= s.limit) {
+		return s.mem.Write(p)
+	}
+	if s.file == nil && s.err == nil {
+		s.file, s.err = os.CreateTemp("", "gobo-body-*")
+	}
+	if s.err == nil {
+		_, s.err = s.file.Write(p)
+	}
+	// Failing here would also fail the reader; the copy is incomplete instead
+	return len(p), nil
+}
+
+// body returns the spooled copy as a request body closing orig. The
+// temporary file is removed when the body is closed or ctx is done.
+func (s *bodySpool) body(ctx context.Context, orig io.Closer) io.ReadCloser {
+	if s.file == nil {
+		return struct {
+			io.Reader
+			io.Closer
+		}{bytes.NewReader(s.mem.Bytes()), orig}
+	}
+	var once sync.Once
+	remove := func() {
+		once.Do(func() {
+			s.file.Close()
+			os.Remove(s.file.Name())
+		})
+	}
+	context.AfterFunc(ctx, remove)
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		s.err = err
+	}
+	return spooledBody{io.MultiReader(bytes.NewReader(s.mem.Bytes()), s.file), func() error {
+		remove()
+		return orig.Close()
+	}}
+}
+
+type spooledBody struct {
+	io.Reader
+	close func() error
+}
+
+func (b spooledBody) Close() error {
+	return b.close()
+}
+
+// readFullBody reads the whole request body and puts it back.
+func readFullBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{bytes.NewReader(body), r.Body}
+	return body, err
+}
+
+// truncate cuts body to maxBody bytes, dropping a partial trailing rune.
+func truncate(body []byte, maxBody int64) (string, bool) {
+	if maxBody <= 0 || int64(len(body)) <= maxBody {
+		return string(body), false
+	}
+	return strings.ToValidUTF8(string(body[:maxBody]), ""), true
+}
+
+// parseMultipart splits a multipart body into form values and file
+// summaries. Form values share the maxBody budget; values beyond it are
+// cut and reported as truncated.
+func parseMultipart(body io.Reader, boundary string, maxBody int64) (map[string][]string, []FileSummary, bool, error) {
+	form := make(map[string][]string)
+	var files []FileSummary
+	truncated := false
+	budget := maxBody
+
+	mr := multipart.NewReader(body, boundary)
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		name := part.FormName()
+		if part.FileName() != "" {
+			h := sha256.New()
+			size, err := io.Copy(h, part)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			files = append(files, FileSummary{
+				Field:       name,
+				Name:        part.FileName(),
+				Size:        size,
+				ContentType: part.Header.Get("Content-Type"),
+				SHA256:      hex.EncodeToString(h.Sum(nil)),
+			})
+			continue
+		}
+
+		src := io.Reader(part)
+		if maxBody > 0 {
+			src = io.LimitReader(part, max(budget, 0)+1)
+		}
+		value, err := io.ReadAll(src)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if maxBody > 0 && int64(len(value)) > budget {
+			value = []byte(strings.ToValidUTF8(string(value[:max(budget, 0)]), ""))
+			truncated = true
+		}
+		budget -= int64(len(value))
+		form[name] = append(form[name], string(value))
+	}
+	if len(form) == 0 {
+		form = nil
+	}
+	return form, files, truncated, nil
+}
@@ -0,0 +1,134 @@
+package gobo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExtractRequestContext_Multipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("title", "Holiday")
+	_ = mw.WriteField("tag", "beach")
+	_ = mw.WriteField("tag", "sun")
+	fw, _ := mw.CreateFormFile("photo", "IMG_1.jpg")
+	content := bytes.Repeat([]byte{0xFF, 0xD8, 0x00}, 1000)
+	_, _ = fw.Write(content)
+	_ = mw.Close()
+	raw := body.Bytes()
+
+	req := httptest.NewRequest("POST", "/upload", bytes.NewReader(raw))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	ctx := extractRequestContext(req, defaultMaxBodyCapture)
+
+	if ctx.Body != "" {
+		t.Errorf("Expected no raw body for multipart, got %d bytes", len(ctx.Body))
+	}
+	if ctx.Form["title"][0] != "Holiday" || len(ctx.Form["tag"]) != 2 {
+		t.Errorf("Unexpected form %v", ctx.Form)
+	}
+	sum := sha256.Sum256(content)
+	want := FileSummary{Field: "photo", Name: "IMG_1.jpg", Size: 3000, ContentType: "application/octet-stream", SHA256: hex.EncodeToString(sum[:])}
+	if len(ctx.Files) != 1 || ctx.Files[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, ctx.Files)
+	}
+
+	// The body is still there for handlers downstream
+	rest, _ := io.ReadAll(req.Body)
+	if !bytes.Equal(rest, raw) {
+		t.Error("Expected the body to be restored")
+	}
+}
+
+func TestExtractRequestContext_MultipartSpool(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("title", "Holiday")
+	fw, _ := mw.CreateFormFile("video", "clip.mp4")
+	content := bytes.Repeat([]byte("frame"), 100_000)
+	_, _ = fw.Write(content)
+	_ = mw.Close()
+	raw := body.Bytes()
+
+	// Beyond the capture limit the body is spooled to disk, not memory
+	req := httptest.NewRequest("POST", "/upload", bytes.NewReader(raw))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	ctx := extractRequestContext(req, 1024)
+	sum := sha256.Sum256(content)
+	if len(ctx.Files) != 1 || ctx.Files[0].Size != int64(len(content)) || ctx.Files[0].SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Unexpected files %+v", ctx.Files)
+	}
+	if ctx.Form["title"][0] != "Holiday" {
+		t.Errorf("Unexpected form %v", ctx.Form)
+	}
+	spooled, ok := req.Body.(spooledBody)
+	if !ok {
+		t.Fatalf("Expected a spooled body, got %T", req.Body)
+	}
+	rest, _ := io.ReadAll(req.Body)
+	if !bytes.Equal(rest, raw) {
+		t.Error("Expected the whole body downstream")
+	}
+	_ = spooled.Close()
+}
+
+func TestExtractRequestContext_URLEncoded(t *testing.T) {
+	req := httptest.NewRequest("POST", "/login", strings.NewReader("user=ada&remember=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ctx := extractRequestContext(req, defaultMaxBodyCapture)
+	if ctx.Form["user"][0] != "ada" || ctx.Form["remember"][0] != "1" {
+		t.Errorf("Unexpected form %v", ctx.Form)
+	}
+	if ctx.Body != "user=ada&remember=1" {
+		t.Errorf("Expected the raw body to be kept, got %q", ctx.Body)
+	}
+}
+
+func TestExtractRequestContext_Truncation(t *testing.T) {
+	long := strings.Repeat("a", 50) + "é"
+	req := httptest.NewRequest("POST", "/notes", strings.NewReader(long))
+	ctx := extractRequestContext(req, 51)
+	if !ctx.BodyTruncated || ctx.Body != strings.Repeat("a", 50) {
+		t.Errorf("Expected 50 bytes and a truncation flag, got %q %v", ctx.Body, ctx.BodyTruncated)
+	}
+	rest, _ := io.ReadAll(req.Body)
+	if string(rest) != long {
+		t.Errorf("Expected the full body downstream, got %q", rest)
+	}
+
+	req = httptest.NewRequest("POST", "/notes", strings.NewReader(long))
+	if ctx := extractRequestContext(req, 0); ctx.BodyTruncated || ctx.Body != long {
+		t.Errorf("Expected no limit, got %q", ctx.Body)
+	}
+}
+
+func TestMaxBodyCapture_Validation(t *testing.T) {
+	gen := &captureGenerator{}
+	g := New(WithGenerator(gen), WithMaxBodyCapture(8))
+
+	rec := httptest.NewRecorder()
+	g.Stub(map[string]any{}).ServeHTTP(rec, httptest.NewRequest("POST", "/x", strings.NewReader(`{"name":"a long one"}`)))
+	if !gen.reqCtx.BodyTruncated || len(gen.reqCtx.Body) != 8 {
+		t.Errorf("Expected a truncated body, got %q", gen.reqCtx.Body)
+	}
+
+	// Validation reads the whole body
+	stub := g.Stub(map[string]any{}, RouteRequest(RequestSpec{Body: map[string]any{"name": ""}}))
+	rec = httptest.NewRecorder()
+	stub.ServeHTTP(rec, httptest.NewRequest("POST", "/x", strings.NewReader(`{"name":"a long one"}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected a long valid body to pass, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	stub.ServeHTTP(rec, httptest.NewRequest("POST", "/x", strings.NewReader(`{"name":12345678}`)))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected a long invalid body to fail, got %d %s", rec.Code, rec.Body.String())
+	}
+}
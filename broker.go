@@ -2,7 +2,10 @@ package gobo
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,8 +18,18 @@ type PendingRequest struct {
 	Method    string         `json:"method"`
 	URL       string         `json:"url"`
 	Context   RequestContext `json:"context"`
-	Schema    any            `json:"schema"` // Used by agents to understand what to generate
+	Schema    any            `json:"schema"`              // Used by agents to understand what to generate
+	Operation string         `json:"operation,omitempty"` // The unit of work, such as "query GetUser" or a JSON-RPC method, when the route has one
+	// Shape is the exact JSON Schema the response must match, for routes
+	// that define one, such as a GraphQL selection set.
+	Shape     map[string]any `json:"shape,omitempty"`
+	Examples  []Example      `json:"examples,omitempty"` // Real responses to imitate, when the route has any
 	Timestamp time.Time      `json:"timestamp"`
+	// Stream marks a parked text/event-stream: push events with PushEvent
+	// and end it with CloseStream. Schema describes each event's data.
+	Stream bool `json:"stream,omitempty"`
+	// LastEventID is the id the client resumes after, when reconnecting.
+	LastEventID string `json:"last_event_id,omitempty"`
 }
 
 // responseChannel allows sending the mocked JSON back to the blocked Generation routine.
@@ -25,17 +38,44 @@ type responseChannel chan []byte
 // AsyncBroker implements the Generator interface. It intentionally blocks the HTTP request
 // and exposes an API for external agents to pull pending requests and submit JSON responses asynchronously.
 type AsyncBroker struct {
-	mu       sync.Mutex
-	pending  map[string]PendingRequest
-	channels map[string]responseChannel
+	mu         sync.Mutex
+	pending    map[string]PendingRequest
+	channels   map[string]responseChannel
+	streams    map[string]*brokerStream
+	maxPending int // zero means unlimited
+}
+
+// brokerStream carries events pushed by an agent to a parked stream.
+type brokerStream struct {
+	events    chan Event
+	closed    chan struct{} // closed by CloseStream
+	closeOnce sync.Once
+	done      chan struct{} // closed when the client is gone
+}
+
+// BrokerOption configures an AsyncBroker.
+type BrokerOption func(*AsyncBroker)
+
+// BrokerMaxPending caps how many requests may be parked at once. Requests
+// beyond it fail with ErrOverloaded and are answered with 503, instead of
+// piling up while no agent is answering.
+func BrokerMaxPending(n int) BrokerOption {
+	return func(b *AsyncBroker) {
+		b.maxPending = n
+	}
 }
 
 // NewAsyncBroker creates a new broker ready to be passed to Gobo's config.
-func NewAsyncBroker() *AsyncBroker {
-	return &AsyncBroker{
+func NewAsyncBroker(opts ...BrokerOption) *AsyncBroker {
+	b := &AsyncBroker{
 		pending:  make(map[string]PendingRequest),
 		channels: make(map[string]responseChannel),
+		streams:  make(map[string]*brokerStream),
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
 
 // GenerateResponse implements the Generator interface.
@@ -53,10 +93,17 @@ func (b *AsyncBroker) GenerateResponse(ctx context.Context, reqCtx RequestContex
 		URL:       reqCtx.URL,
 		Context:   reqCtx,
 		Schema:    schema,
+		Operation: operationOf(reqCtx),
+		Shape:     shapeOf(reqCtx, schema),
+		Examples:  reqCtx.Examples,
 		Timestamp: time.Now(),
 	}
 
 	b.mu.Lock()
+	if b.maxPending > 0 && len(b.pending) >= b.maxPending {
+		b.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d requests already parked", ErrOverloaded, b.maxPending)
+	}
 	b.pending[reqID] = pr
 	b.channels[reqID] = respChan
 	b.mu.Unlock()
@@ -78,6 +125,139 @@ func (b *AsyncBroker) GenerateResponse(ctx context.Context, reqCtx RequestContex
 	}
 }
 
+// operationOf names the operation a request carries, if any.
+func operationOf(reqCtx RequestContext) string {
+	if q := reqCtx.GraphQL; q != nil {
+		return strings.TrimSpace(q.OperationType + " " + q.OperationName)
+	}
+	if reqCtx.RPC != nil {
+		return reqCtx.RPC.Method
+	}
+	if c := reqCtx.GRPC; c != nil {
+		return "rpc " + c.Service + "/" + c.Method
+	}
+	if wh := reqCtx.Webhook; wh != nil {
+		return strings.TrimSpace("webhook " + wh.Name)
+	}
+	if it := reqCtx.Item; it != nil {
+		return fmt.Sprintf("item %d of %d", it.Index+1, it.Size)
+	}
+	return ""
+}
+
+// shapeOf returns the JSON Schema of operation routes, whose schema is
+// exact rather than a sample.
+func shapeOf(reqCtx RequestContext, schema any) map[string]any {
+	if reqCtx.GraphQL == nil && reqCtx.GRPC == nil {
+		return nil
+	}
+	return asSchemaNode(schema)
+}
+
+// GenerateStream implements the StreamGenerator interface. It parks the
+// request as a stream; events pushed with PushEvent are sent to the client
+// as they arrive, until CloseStream or the client disconnects.
+func (b *AsyncBroker) GenerateStream(ctx context.Context, reqCtx RequestContext, schema any, send func(Event) error) error {
+	reqID := uuid.New().String()
+	stream := &brokerStream{
+		events: make(chan Event, 64),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	pr := PendingRequest{
+		ID:          reqID,
+		Method:      reqCtx.Method,
+		URL:         reqCtx.URL,
+		Context:     reqCtx,
+		Schema:      schema,
+		Operation:   operationOf(reqCtx),
+		Shape:       shapeOf(reqCtx, schema),
+		Examples:    reqCtx.Examples,
+		Timestamp:   time.Now(),
+		Stream:      true,
+		LastEventID: http.Header(reqCtx.Headers).Get("Last-Event-ID"),
+	}
+
+	b.mu.Lock()
+	if b.maxPending > 0 && len(b.pending) >= b.maxPending {
+		b.mu.Unlock()
+		return fmt.Errorf("%w: %d requests already parked", ErrOverloaded, b.maxPending)
+	}
+	b.pending[reqID] = pr
+	b.streams[reqID] = stream
+	b.mu.Unlock()
+
+	defer func() {
+		close(stream.done)
+		b.mu.Lock()
+		delete(b.pending, reqID)
+		delete(b.streams, reqID)
+		b.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("request cancelled by client")
+		case e := <-stream.events:
+			if err := send(e); err != nil {
+				return err
+			}
+		case <-stream.closed:
+			// Flush what was pushed before the stream was closed
+			for {
+				select {
+				case e := <-stream.events:
+					if err := send(e); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// PushEvent sends an event to a parked stream. It blocks while the stream's
+// buffer is full.
+func (b *AsyncBroker) PushEvent(id string, e Event) error {
+	b.mu.Lock()
+	stream, exists := b.streams[id]
+	b.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("no pending stream found with id %s", id)
+	}
+	select {
+	case <-stream.closed:
+		return fmt.Errorf("stream %s is closed", id)
+	default:
+	}
+	select {
+	case stream.events <- e:
+		return nil
+	case <-stream.closed:
+		return fmt.Errorf("stream %s is closed", id)
+	case <-stream.done:
+		return fmt.Errorf("stream %s was disconnected by the client", id)
+	}
+}
+
+// CloseStream ends a parked stream once the events pushed so far are sent.
+func (b *AsyncBroker) CloseStream(id string) error {
+	b.mu.Lock()
+	stream, exists := b.streams[id]
+	b.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("no pending stream found with id %s", id)
+	}
+	stream.closeOnce.Do(func() { close(stream.closed) })
+	return nil
+}
+
 // GetPendingRequests returns all currently blocked HTTP requests waiting for an agent.
 func (b *AsyncBroker) GetPendingRequests() []PendingRequest {
 	b.mu.Lock()
@@ -91,11 +271,26 @@ func (b *AsyncBroker) GetPendingRequests() []PendingRequest {
 }
 
 // SubmitResponse is called by an external agent to immediately flush the generated JSON to the blocked HTTP request.
+// For a stream, responseJSON is a JSON array of events, which are all pushed before the stream is closed.
 func (b *AsyncBroker) SubmitResponse(id string, responseJSON []byte) error {
 	b.mu.Lock()
 	ch, exists := b.channels[id]
+	_, isStream := b.streams[id]
 	b.mu.Unlock()
 
+	if isStream {
+		var events []Event
+		if err := json.Unmarshal(responseJSON, &events); err != nil {
+			return fmt.Errorf("stream responses must be a json array of events: %w", err)
+		}
+		for _, e := range events {
+			if err := b.PushEvent(id, e); err != nil {
+				return err
+			}
+		}
+		return b.CloseStream(id)
+	}
+
 	if !exists {
 		return fmt.Errorf("no pending request found with id %s", id)
 	}
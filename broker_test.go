@@ -2,6 +2,7 @@ package gobo
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"testing"
@@ -26,7 +27,7 @@ func TestAsyncBroker_Blocking(t *testing.T) {
 
 	go func() {
 		// GenerateResponse will block here
-		respBytes, err := broker.GenerateResponse(context.Background(), extractRequestContext(req), schema.ResponseSchema)
+		respBytes, err := broker.GenerateResponse(context.Background(), extractRequestContext(req, defaultMaxBodyCapture), schema.ResponseSchema)
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -69,3 +70,33 @@ func TestAsyncBroker_Blocking(t *testing.T) {
 		t.Fatalf("Expected 0 pending requests after completion, got %d", len(pendingEnd))
 	}
 }
+
+func TestAsyncBroker_PendingRequestExamples(t *testing.T) {
+	broker := NewAsyncBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go broker.GenerateResponse(ctx, RequestContext{Method: "GET", URL: "/x", Examples: []Example{example(`{"a":1}`)}}, nil)
+	time.Sleep(50 * time.Millisecond)
+
+	pending := broker.GetPendingRequests()
+	if len(pending) != 1 || len(pending[0].Examples) != 1 {
+		t.Fatalf("Expected pending request with one example, got %+v", pending)
+	}
+}
+
+func TestAsyncBroker_MaxPending(t *testing.T) {
+	broker := NewAsyncBroker(BrokerMaxPending(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go broker.GenerateResponse(ctx, RequestContext{Method: "GET", URL: "/a"}, nil)
+	for len(broker.GetPendingRequests()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err := broker.GenerateResponse(context.Background(), RequestContext{Method: "GET", URL: "/b"}, nil)
+	if !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Expected ErrOverloaded beyond the cap, got %v", err)
+	}
+}
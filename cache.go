@@ -0,0 +1,327 @@
+package gobo
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultCacheEntries = 1000
+
+// CacheConfig controls how a CachingGenerator keys and keeps responses.
+// By default the key is the method, path, query string and a hash of the
+// body, plus the collection item or webhook being generated; responses
+// never expire and at most 1000 are kept.
+type CacheConfig struct {
+	// IgnoreQuery leaves the query string out of the key.
+	IgnoreQuery bool
+	// IgnoreBody leaves the request body out of the key.
+	IgnoreBody bool
+	// Headers lists request headers that are part of the key, such as
+	// "Authorization" to cache per user.
+	Headers []string
+	// Key replaces the default key entirely.
+	Key func(reqCtx RequestContext) string
+	// TTL is how long a response is served; zero keeps it until evicted.
+	TTL time.Duration
+	// MaxEntries bounds the cache; the least recently used response is
+	// evicted first.
+	MaxEntries int
+}
+
+// CacheStats reports cache effectiveness.
+type CacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	// Shared counts requests that waited for an identical in-flight
+	// generation instead of starting their own.
+	Shared int64 `json:"shared"`
+}
+
+// CachingGenerator wraps a generator and reuses its responses for identical
+// requests. Concurrent identical requests are collapsed into a single call,
+// so they also share one parked request in the AsyncBroker. Errors are not
+// cached.
+//
+//	gen := gobo.NewCachingGenerator(gobo.NewOllamaGenerator(url, "llama3"), gobo.CacheConfig{TTL: time.Minute})
+type CachingGenerator struct {
+	gen Generator
+	cfg CacheConfig
+
+	mu       sync.Mutex
+	lru      *list.List // of *cacheEntry, most recent first
+	entries  map[string]*list.Element
+	inflight map[string]*cacheCall
+	stats    CacheStats
+}
+
+type cacheEntry struct {
+	key     string
+	method  string
+	path    string
+	body    []byte
+	expires time.Time
+}
+
+// cacheCall is a generation in progress that identical requests wait for.
+type cacheCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+	// abandoned is set when the caller's own context ended the call, which
+	// says nothing about the waiters' requests.
+	abandoned bool
+}
+
+// NewCachingGenerator wraps gen with a response cache.
+func NewCachingGenerator(gen Generator, cfg CacheConfig) *CachingGenerator {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultCacheEntries
+	}
+	return &CachingGenerator{
+		gen:      gen,
+		cfg:      cfg,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		inflight: make(map[string]*cacheCall),
+	}
+}
+
+// GenerateResponse implements the Generator interface.
+func (c *CachingGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	key := c.key(reqCtx)
+	for {
+		c.mu.Lock()
+		if el, ok := c.entries[key]; ok {
+			entry := el.Value.(*cacheEntry)
+			if entry.expires.IsZero() || time.Now().Before(entry.expires) {
+				c.lru.MoveToFront(el)
+				c.stats.Hits++
+				c.mu.Unlock()
+				return entry.body, nil
+			}
+			c.remove(el)
+		}
+
+		if call, ok := c.inflight[key]; ok {
+			c.stats.Shared++
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if call.abandoned {
+				continue // the leader's client went away; try again
+			}
+			return call.body, call.err
+		}
+
+		call := &cacheCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		c.stats.Misses++
+		c.mu.Unlock()
+
+		call.body, call.err = c.gen.GenerateResponse(ctx, reqCtx, schema)
+		call.abandoned = call.err != nil && ctx.Err() != nil
+
+		c.mu.Lock()
+		delete(c.inflight, key)
+		if call.err == nil {
+			c.store(key, reqCtx, call.body)
+		}
+		c.mu.Unlock()
+		close(call.done)
+		return call.body, call.err
+	}
+}
+
+// GenerateStream implements the StreamGenerator interface. Streams are live,
+// so they are passed to the cached generator without caching.
+func (c *CachingGenerator) GenerateStream(ctx context.Context, reqCtx RequestContext, schema any, send func(Event) error) error {
+	return streamFrom(ctx, c.gen, reqCtx, schema, send)
+}
+
+// Unwrap returns the cached generator, see GeneratorWrapper.
+func (c *CachingGenerator) Unwrap() []Generator {
+	return []Generator{c.gen}
+}
+
+// HealthCheck checks the cached generator, see HealthChecker.
+func (c *CachingGenerator) HealthCheck(ctx context.Context) error {
+	return checkWrapped(ctx, c.gen)
+}
+
+// Invalidate drops cached responses for requests with the given method
+// (empty for any) whose path starts with pathPrefix (empty for all). It
+// returns how many entries were removed.
+func (c *CachingGenerator) Invalidate(method, pathPrefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		entry := el.Value.(*cacheEntry)
+		if (method == "" || strings.EqualFold(method, entry.method)) && strings.HasPrefix(entry.path, pathPrefix) {
+			c.remove(el)
+			removed++
+		}
+		el = next
+	}
+	return removed
+}
+
+// Stats returns the cache counters.
+func (c *CachingGenerator) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// store adds a response, evicting the least recently used ones over the
+// bound. Callers hold c.mu.
+func (c *CachingGenerator) store(key string, reqCtx RequestContext, body []byte) {
+	entry := &cacheEntry{key: key, method: reqCtx.Method, path: requestPath(reqCtx.URL), body: body}
+	if c.cfg.TTL > 0 {
+		entry.expires = time.Now().Add(c.cfg.TTL)
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.cfg.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops a cache element. Callers hold c.mu.
+func (c *CachingGenerator) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
+
+// key builds the cache key for a request.
+func (c *CachingGenerator) key(reqCtx RequestContext) string {
+	if c.cfg.Key != nil {
+		return c.cfg.Key(reqCtx)
+	}
+
+	var b strings.Builder
+	b.WriteString(reqCtx.Method)
+	b.WriteByte(' ')
+	u, err := url.Parse(reqCtx.URL)
+	if err != nil {
+		b.WriteString(reqCtx.URL)
+	} else {
+		b.WriteString(u.Path)
+		if !c.cfg.IgnoreQuery && u.RawQuery != "" {
+			// Encode sorts by key, so parameter order does not matter
+			b.WriteString("?" + u.Query().Encode())
+		}
+	}
+
+	headers := append([]string(nil), c.cfg.Headers...)
+	sort.Strings(headers)
+	for _, name := range headers {
+		values := http.Header(reqCtx.Headers).Values(name)
+		b.WriteString("\n" + http.CanonicalHeaderKey(name) + ": " + strings.Join(values, ","))
+	}
+
+	if !c.cfg.IgnoreBody && reqCtx.Body != "" {
+		sum := sha256.Sum256([]byte(reqCtx.Body))
+		b.WriteString("\nbody: " + hex.EncodeToString(sum[:]))
+	} else if !c.cfg.IgnoreBody && (reqCtx.Form != nil || reqCtx.Files != nil) {
+		// Multipart bodies are not captured; their fields and file hashes
+		// stand in for them
+		parts, _ := json.Marshal([]any{reqCtx.Form, reqCtx.Files})
+		sum := sha256.Sum256(parts)
+		b.WriteString("\nbody: " + hex.EncodeToString(sum[:]))
+	}
+	if reqCtx.Item != nil {
+		// Items of a collection share a request; each is its own response
+		b.WriteString("\nitem: " + strconv.Itoa(reqCtx.Item.Index))
+	}
+	if reqCtx.Webhook != nil {
+		// Webhook payloads share the request that fired them
+		b.WriteString("\nwebhook: " + reqCtx.Webhook.Name + " " + reqCtx.Webhook.URL)
+	}
+	return b.String()
+}
+
+// requestPath returns the path of a request URL.
+func requestPath(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Path
+	}
+	return rawURL
+}
+
+// cacheInvalidator is implemented by generators holding cached responses.
+type cacheInvalidator interface {
+	Invalidate(method, pathPrefix string) int
+}
+
+// InvalidateCache drops cached responses from every CachingGenerator used by
+// g, as the instance generator or a route generator. See
+// CachingGenerator.Invalidate for the arguments.
+func (g *Gobo) InvalidateCache(method, pathPrefix string) int {
+	removed := 0
+	for _, gen := range g.generators() {
+		if inv, ok := gen.(cacheInvalidator); ok {
+			removed += inv.Invalidate(method, pathPrefix)
+		}
+	}
+	g.logf("Invalidated %d cached responses for %q %q", removed, method, pathPrefix)
+	return removed
+}
+
+// generators returns the distinct generators in use by g, including those
+// wrapped by others (see GeneratorWrapper).
+func (g *Gobo) generators() []Generator {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var out []Generator
+	seen := make(map[Generator]bool)
+	var add func(gen Generator)
+	add = func(gen Generator) {
+		// Generators of uncomparable types cannot be map keys, and are not caches
+		if gen == nil || !reflect.TypeOf(gen).Comparable() || seen[gen] {
+			return
+		}
+		seen[gen] = true
+		out = append(out, gen)
+		if w, ok := gen.(GeneratorWrapper); ok {
+			for _, inner := range w.Unwrap() {
+				add(inner)
+			}
+		}
+	}
+
+	add(g.client)
+	add(g.fileClient)
+	for _, r := range g.routes {
+		add(r.Generator)
+	}
+	for _, r := range g.fileRoutes {
+		add(r.Generator)
+	}
+	for _, r := range g.handlerRoutes {
+		add(r.Generator)
+	}
+	return out
+}
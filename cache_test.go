@@ -0,0 +1,183 @@
+package gobo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingGenerator returns a new body on every call, optionally waiting
+// for release first.
+type countingGenerator struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (c *countingGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	n := c.calls.Add(1)
+	if c.release != nil {
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return fmt.Appendf(nil, `{"n":%d}`, n), nil
+}
+
+func generate(t *testing.T, gen Generator, reqCtx RequestContext) string {
+	t.Helper()
+	out, err := gen.GenerateResponse(context.Background(), reqCtx, nil)
+	if err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+	return string(out)
+}
+
+func TestCachingGenerator_Keys(t *testing.T) {
+	inner := &countingGenerator{}
+	c := NewCachingGenerator(inner, CacheConfig{Headers: []string{"Authorization"}})
+
+	get := func(url, auth, body string) string {
+		return generate(t, c, RequestContext{Method: "GET", URL: url, Headers: map[string][]string{"Authorization": {auth}}, Body: body})
+	}
+
+	first := get("/users?a=1&b=2", "alice", "")
+	if got := get("/users?b=2&a=1", "alice", ""); got != first {
+		t.Errorf("Expected reordered query to hit the cache, got %s", got)
+	}
+	if got := get("/users?a=1&b=2", "bob", ""); got == first {
+		t.Error("Expected a different Authorization header to miss the cache")
+	}
+	if got := get("/users?a=1&b=2", "alice", `{"x":1}`); got == first {
+		t.Error("Expected a different body to miss the cache")
+	}
+
+	c = NewCachingGenerator(inner, CacheConfig{IgnoreQuery: true})
+	first = get("/users?page=1", "", "")
+	if got := get("/users?page=2", "", ""); got != first {
+		t.Errorf("Expected IgnoreQuery to share responses, got %s", got)
+	}
+}
+
+func TestCachingGenerator_TTLAndLRU(t *testing.T) {
+	inner := &countingGenerator{}
+	c := NewCachingGenerator(inner, CacheConfig{TTL: 30 * time.Millisecond, MaxEntries: 2})
+	req := func(path string) RequestContext { return RequestContext{Method: "GET", URL: path} }
+
+	a := generate(t, c, req("/a"))
+	generate(t, c, req("/b"))
+	generate(t, c, req("/a")) // /a is now the most recently used
+	generate(t, c, req("/c")) // evicts /b
+
+	if got := generate(t, c, req("/a")); got != a {
+		t.Errorf("Expected /a to stay cached, got %s", got)
+	}
+	if stats := c.Stats(); stats.Entries != 2 || stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if got := generate(t, c, req("/a")); got == a {
+		t.Error("Expected /a to expire")
+	}
+}
+
+func TestCachingGenerator_Singleflight(t *testing.T) {
+	inner := &countingGenerator{release: make(chan struct{})}
+	c := NewCachingGenerator(inner, CacheConfig{})
+
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = generate(t, c, RequestContext{Method: "GET", URL: "/slow"})
+		}()
+	}
+	for c.Stats().Shared < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	close(inner.release)
+	wg.Wait()
+
+	if n := inner.calls.Load(); n != 1 {
+		t.Errorf("Expected one generation, got %d", n)
+	}
+	for _, r := range results {
+		if r != results[0] {
+			t.Errorf("Expected identical responses, got %v", results)
+		}
+	}
+}
+
+func TestCachingGenerator_LeaderCancelled(t *testing.T) {
+	inner := &countingGenerator{release: make(chan struct{})}
+	c := NewCachingGenerator(inner, CacheConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error)
+	go func() {
+		_, err := c.GenerateResponse(ctx, RequestContext{Method: "GET", URL: "/slow"}, nil)
+		leaderDone <- err
+	}()
+	for inner.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	waiter := make(chan string)
+	go func() { waiter <- generate(t, c, RequestContext{Method: "GET", URL: "/slow"}) }()
+	for c.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-leaderDone; err == nil {
+		t.Error("Expected the cancelled leader to fail")
+	}
+	close(inner.release)
+	if got := <-waiter; got != `{"n":2}` {
+		t.Errorf("Expected the waiter to generate on its own, got %s", got)
+	}
+}
+
+func TestInvalidateCache_Admin(t *testing.T) {
+	inner := &countingGenerator{}
+	cache := NewCachingGenerator(inner, CacheConfig{})
+	g := New(WithGenerator(cache))
+	g.Register("GET", "/users", map[string]any{})
+	g.Register("GET", "/orders", map[string]any{})
+	handler := g.Middleware(nil)
+
+	for _, path := range []string{"/users/1", "/users/2", "/orders/1"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/_gobo/cache/invalidate?path=/users", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"invalidated\":2}\n" {
+		t.Errorf("Unexpected invalidate response %d %q", rec.Code, rec.Body.String())
+	}
+	if stats := cache.Stats(); stats.Entries != 1 {
+		t.Errorf("Expected only /orders/1 to remain, got %+v", stats)
+	}
+}
+
+func TestInvalidateCache_Nested(t *testing.T) {
+	cache := NewCachingGenerator(&countingGenerator{}, CacheConfig{})
+	g := New(WithGenerator(NewFallbackGenerator([]FallbackBackend{
+		{Name: "llm", Generator: NewLimitingGenerator(cache, LimitConfig{})},
+	})))
+	g.Register("GET", "/users", map[string]any{})
+	g.Middleware(nil).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	if removed := g.InvalidateCache("", ""); removed != 1 || cache.Stats().Entries != 0 {
+		t.Errorf("Expected the nested cache to be invalidated, removed %d", removed)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gabriel-feang/gobo"
+)
+
+const usage = `Usage: gobo <command> [flags]
+
+Commands:
+  openapi   Print an OpenAPI 3.1 document for a mock configuration or a running app
+
+Run 'gobo <command> -h' for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "openapi":
+		err = runOpenAPI(os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "gobo: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gobo: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runOpenAPI exports an OpenAPI document, either from a mock configuration
+// file or from the admin endpoint of an app running with Gobo.
+func runOpenAPI(args []string) error {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	config := fs.String("config", os.Getenv(gobo.ConfigEnvVar), "mock configuration file (YAML or JSON)")
+	url := fs.String("url", "", "base URL of a running app, e.g. http://localhost:8080")
+	out := fs.String("o", "", "write the document to this file instead of stdout")
+	_ = fs.Parse(args)
+
+	var doc []byte
+	var err error
+	switch {
+	case *url != "":
+		doc, err = fetchOpenAPI(*url)
+	case *config != "":
+		g := gobo.New()
+		if err := g.LoadConfigFile(*config); err != nil {
+			return err
+		}
+		doc, err = g.OpenAPI()
+	default:
+		return fmt.Errorf("openapi: one of -config or -url is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(append(doc, '\n'))
+		return err
+	}
+	return os.WriteFile(*out, append(doc, '\n'), 0o644)
+}
+
+// fetchOpenAPI downloads the document served at AdminPrefix by a running app.
+func fetchOpenAPI(baseURL string) ([]byte, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(baseURL, "/") + gobo.AdminPrefix + "openapi.json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with status %d: %s", baseURL, resp.StatusCode, string(body))
+	}
+	return body, nil
+}
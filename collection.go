@@ -0,0 +1,493 @@
+package gobo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Collection query parameters; any other parameter filters the items.
+const (
+	collectionPage   = "page"
+	collectionLimit  = "limit"
+	collectionCursor = "cursor"
+	collectionOffset = "offset"
+)
+
+// CollectionSpec describes a paginated list route, see Gobo.Collection. The
+// zero value generates 25 items served 10 per page.
+type CollectionSpec struct {
+	// Size is the number of items to generate, default 25.
+	Size int
+	// Items is a fixed backing list (a slice, or a JSON array as
+	// json.RawMessage) served instead of generated items.
+	Items any
+	// PageSize is the default page size, default 10.
+	PageSize int
+	// MaxPageSize caps the limit clients may ask for, default 100.
+	MaxPageSize int
+	// Filters lists the query parameters that filter items on the field of
+	// the same name. Nil allows every top-level field.
+	Filters []string
+	// Bare answers with a plain JSON array instead of the page envelope;
+	// totals and cursors are then only sent as headers.
+	Bare bool
+}
+
+// CollectionItem identifies the item being generated for a collection
+// route, see RequestContext.Item.
+type CollectionItem struct {
+	Index int `json:"index"`
+	Size  int `json:"size"`
+}
+
+// collectionPageBody is the default response body of a collection route.
+type collectionPageBody struct {
+	Items      []json.RawMessage `json:"items"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page,omitempty"` // unset when the offset is not on a page boundary
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+}
+
+// collection is a route's CollectionSpec with its backing list, built on first
+// use and kept for the life of the route.
+type collection struct {
+	CollectionSpec
+
+	mu    sync.Mutex
+	items []json.RawMessage
+}
+
+// RouteCollection makes the route serve pages of a stable list of items;
+// the route schema then describes a single item. See Gobo.Collection.
+func RouteCollection(c CollectionSpec) RouteOption {
+	return func(r *routeSchema) {
+		r.Collection = &collection{CollectionSpec: c}
+	}
+}
+
+// Collection returns an http.Handler serving a paginated list whose items
+// match the item schema. The backing list is generated once, one generator
+// call per item, and pages are cut from it, so pages never overlap and
+// totals stay consistent. Clients page with ?page=2&limit=20, ?offset=40 or
+// the opaque ?cursor= from the previous page, and filter with query
+// parameters named after item fields (?status=active). Responses carry
+// X-Total-Count and a Link header with first, prev, next and last pages.
+//
+//	mux.Handle("GET /users", g.Collection(User{}, gobo.CollectionSpec{Size: 42}))
+func (g *Gobo) Collection(item any, c CollectionSpec, opts ...RouteOption) http.Handler {
+	return g.Stub(item, append(opts, RouteCollection(c))...)
+}
+
+// writeCollection answers a request to a collection route.
+func (g *Gobo) writeCollection(w http.ResponseWriter, r *http.Request, route *routeSchema, reqCtx RequestContext) {
+	c := route.Collection
+	query := r.URL.Query()
+	limit := c.PageSize
+	if limit <= 0 {
+		limit = 10
+	}
+	if v := query.Get(collectionLimit); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Gobo Collection: limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	maxLimit := c.MaxPageSize
+	if maxLimit <= 0 {
+		maxLimit = 100
+	}
+	limit = min(limit, maxLimit)
+
+	offset := 0
+	switch {
+	case query.Get(collectionCursor) != "":
+		n, ok := decodeCursor(query.Get(collectionCursor))
+		if !ok {
+			http.Error(w, "Gobo Collection: invalid cursor", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	case query.Get(collectionOffset) != "":
+		n, err := strconv.Atoi(query.Get(collectionOffset))
+		if err != nil || n < 0 {
+			http.Error(w, "Gobo Collection: offset must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	case query.Get(collectionPage) != "":
+		n, err := strconv.Atoi(query.Get(collectionPage))
+		if err != nil || n < 1 || n-1 > math.MaxInt/limit {
+			http.Error(w, "Gobo Collection: page must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		offset = (n - 1) * limit
+	}
+
+	genCtx, backend := withBackend(r.Context())
+	items, err := g.collectionItems(genCtx, r, route, reqCtx)
+	if errors.Is(err, ErrOverloaded) {
+		g.logf("Shedding %s %s: %v", r.Method, r.URL.Path, err)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Gobo Mock Generation Overloaded: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		g.logf("Error generating collection: %v", err)
+		http.Error(w, "Gobo Mock Generation Failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items = filterItems(items, query, c.Filters)
+	total := len(items)
+	// Past the end is an empty page; clamping first keeps offset+limit from
+	// overflowing
+	offset = min(offset, total)
+	page := items[offset:min(offset+limit, total)]
+	body := collectionPageBody{Items: page, Total: total, Limit: limit, Offset: offset}
+	if offset%limit == 0 {
+		body.Page = offset/limit + 1
+	}
+	if body.Items == nil {
+		body.Items = []json.RawMessage{}
+	}
+	if offset+limit < total {
+		body.NextCursor = encodeCursor(offset + limit)
+	}
+	if offset > 0 {
+		body.PrevCursor = encodeCursor(max(offset-limit, 0))
+	}
+
+	var out []byte
+	var schema any
+	if c.Bare {
+		out, err = json.Marshal(body.Items)
+		schema = listSchema(route.ResponseSchema)
+	} else {
+		out, err = json.Marshal(body)
+	}
+	if err == nil {
+		format := negotiateFormat(route.Formats, r.Header.Get("Accept"))
+		if out, err = format.Encode(out, schema); err == nil {
+			w.Header().Set("Content-Type", format.ContentType)
+			if len(route.Formats) > 1 {
+				w.Header().Add("Vary", "Accept")
+			}
+		}
+	}
+	if err != nil {
+		g.logf("Error encoding collection page: %v", err)
+		http.Error(w, "Gobo Mock Generation Failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if link := pageLinks(r, offset, limit, total); link != "" {
+		w.Header().Set("Link", link)
+	}
+	if *backend != "" {
+		w.Header().Set(BackendHeader, *backend)
+	}
+	for k, v := range route.Headers {
+		w.Header().Set(k, v)
+	}
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+}
+
+// collectionItems returns the route's backing list, generating it on first
+// use. Generation failures are not kept, so a later request retries.
+func (g *Gobo) collectionItems(ctx context.Context, r *http.Request, route *routeSchema, reqCtx RequestContext) ([]json.RawMessage, error) {
+	c := route.Collection
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.items != nil {
+		return c.items, nil
+	}
+
+	if c.Items != nil {
+		items, err := fixedItems(c.Items)
+		if err != nil {
+			return nil, err
+		}
+		c.items = items
+		return items, nil
+	}
+
+	size := c.Size
+	if size <= 0 {
+		size = 25
+	}
+	key := routeKey(r, route)
+	reqCtx.Examples = g.examplesFor(key, route)
+	reqCtx.Prompt = g.promptFor(key, route)
+	// Items describe the collection, not the page that asked for it
+	reqCtx.URL, reqCtx.Body, reqCtx.Form = r.URL.Path, "", nil
+	gen := g.generator(route)
+	if gen == nil {
+		gen = StaticGenerator{}
+	}
+
+	g.logf("Generating %d items for %s", size, r.URL.Path)
+	items := make([]json.RawMessage, size)
+	errs := make([]error, size)
+	// A few items at a time, so limits and brokers are not flooded
+	sem := make(chan struct{}, 4)
+	var wg sync.WaitGroup
+	for i := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			itemCtx := reqCtx
+			itemCtx.Item = &CollectionItem{Index: i, Size: size}
+			out, err := gen.GenerateResponse(ctx, itemCtx, route.ResponseSchema)
+			if err == nil && !json.Valid(out) {
+				err = errors.New("item is not valid JSON")
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("item %d: %w", i, err)
+				return
+			}
+			items[i] = out
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	c.items = uniqueIDs(items)
+	return c.items, nil
+}
+
+// fixedItems decodes a fixed backing list.
+func fixedItems(list any) ([]json.RawMessage, error) {
+	data, ok := list.(json.RawMessage)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(list); err != nil {
+			return nil, fmt.Errorf("failed to marshal collection items: %w", err)
+		}
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("collection items must be a list: %w", err)
+	}
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	return items, nil
+}
+
+// uniqueIDs renumbers the "id" fields of generated items when they clash,
+// as static generators repeat the sample and LLMs may too. Numeric ids
+// become 1..N; string ids get the item number appended.
+func uniqueIDs(items []json.RawMessage) []json.RawMessage {
+	seen := make(map[string]bool, len(items))
+	clash := false
+	objects := make([]map[string]any, len(items))
+	for i, item := range items {
+		if json.Unmarshal(item, &objects[i]) != nil || objects[i]["id"] == nil {
+			return items
+		}
+		id := scalarText(objects[i]["id"])
+		clash = clash || seen[id]
+		seen[id] = true
+	}
+	if !clash {
+		return items
+	}
+
+	renumbered := make([]json.RawMessage, len(items))
+	for i, obj := range objects {
+		switch id := obj["id"].(type) {
+		case float64:
+			obj["id"] = i + 1
+		case string:
+			obj["id"] = id + "-" + strconv.Itoa(i+1)
+		}
+		out, err := json.Marshal(obj)
+		if err != nil {
+			return items
+		}
+		renumbered[i] = out
+	}
+	return renumbered
+}
+
+// filterItems keeps the items whose fields match the filter parameters of
+// query. Values of a repeated parameter are alternatives; comparisons
+// ignore case. allowed limits the parameters that filter, nil allows all.
+func filterItems(items []json.RawMessage, query url.Values, allowed []string) []json.RawMessage {
+	filters := url.Values{}
+	for name, values := range query {
+		switch name {
+		case collectionPage, collectionLimit, collectionCursor, collectionOffset:
+			continue
+		}
+		if allowed == nil || slices.Contains(allowed, name) {
+			filters[name] = values
+		}
+	}
+	if len(filters) == 0 {
+		return items
+	}
+
+	var kept []json.RawMessage
+	for _, item := range items {
+		var fields map[string]any
+		if json.Unmarshal(item, &fields) != nil {
+			continue
+		}
+		if matchesFilters(fields, filters) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func matchesFilters(fields map[string]any, filters url.Values) bool {
+	for name, values := range filters {
+		value, ok := fields[name]
+		if !ok {
+			// Parameters that are not fields do not filter
+			continue
+		}
+		text := scalarText(value)
+		match := false
+		for _, v := range values {
+			if strings.EqualFold(text, v) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeCursor returns the opaque cursor of an offset.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+	if err != nil || n < 0 || !strings.HasPrefix(string(raw), "offset:") {
+		return 0, false
+	}
+	return n, true
+}
+
+// pageLinks builds an RFC 8288 Link header for the pages around the
+// current one. Requests that paged with a cursor or an offset get links of
+// the same kind, others page numbers; filters are kept.
+func pageLinks(r *http.Request, offset, limit, total int) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	query := r.URL.Query()
+	byCursor := query.Get(collectionCursor) != ""
+	byOffset := !byCursor && query.Get(collectionOffset) != ""
+	link := func(rel string, to int) string {
+		q := r.URL.Query()
+		for _, name := range []string{collectionPage, collectionCursor, collectionOffset} {
+			q.Del(name)
+		}
+		q.Set(collectionLimit, strconv.Itoa(limit))
+		switch {
+		case byCursor:
+			q.Set(collectionCursor, encodeCursor(to))
+		case byOffset:
+			q.Set(collectionOffset, strconv.Itoa(to))
+		default:
+			q.Set(collectionPage, strconv.Itoa(to/limit+1))
+		}
+		u := url.URL{Scheme: scheme, Host: r.Host, Path: r.URL.Path, RawQuery: q.Encode()}
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links := []string{link("first", 0)}
+	if offset > 0 {
+		links = append(links, link("prev", max(offset-limit, 0)))
+	}
+	if offset+limit < total {
+		links = append(links, link("next", offset+limit))
+	}
+	links = append(links, link("last", last))
+	return strings.Join(links, ", ")
+}
+
+// schema describes the pages of a collection of items.
+func (c *collection) schema(item any) map[string]any {
+	items := map[string]any{"type": "array", "items": jsonSchemaFor(item)}
+	if c.Bare {
+		return items
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"items":       items,
+			"total":       map[string]any{"type": "integer"},
+			"page":        map[string]any{"type": "integer"},
+			"limit":       map[string]any{"type": "integer"},
+			"offset":      map[string]any{"type": "integer"},
+			"next_cursor": map[string]any{"type": "string"},
+			"prev_cursor": map[string]any{"type": "string"},
+		},
+		"required": []any{"items", "total", "limit", "offset"},
+	}
+}
+
+// listSchema returns a slice schema of a Go struct item schema, so formats
+// keep its field order; other schemas need none.
+func listSchema(item any) any {
+	item = bareSchema(item)
+	if item == nil {
+		return nil
+	}
+	t := reflect.TypeOf(item)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return reflect.MakeSlice(reflect.SliceOf(t), 0, 0).Interface()
+}
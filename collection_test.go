@@ -0,0 +1,198 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type collectionUser struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// itemGenerator numbers items by their collection index.
+type itemGenerator struct {
+	calls atomic.Int32
+}
+
+func (g *itemGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	g.calls.Add(1)
+	status := "active"
+	if reqCtx.Item.Index%3 == 0 {
+		status = "banned"
+	}
+	return json.Marshal(collectionUser{ID: reqCtx.Item.Index + 1, Name: "user", Status: status})
+}
+
+func getPage(t *testing.T, h http.Handler, target string) (*httptest.ResponseRecorder, collectionPageBody) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+	var page collectionPageBody
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Invalid page %q", rec.Body.String())
+		}
+	}
+	return rec, page
+}
+
+func pageIDs(page collectionPageBody) []int {
+	var ids []int
+	for _, raw := range page.Items {
+		var u collectionUser
+		_ = json.Unmarshal(raw, &u)
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+func TestCollection_Pages(t *testing.T) {
+	gen := &itemGenerator{}
+	h := New(WithGenerator(gen)).Collection(collectionUser{}, CollectionSpec{Size: 23})
+
+	rec, page := getPage(t, h, "/users?page=2&limit=10")
+	if ids := pageIDs(page); len(ids) != 10 || ids[0] != 11 || ids[9] != 20 {
+		t.Errorf("Expected items 11-20, got %v", ids)
+	}
+	if page.Total != 23 || page.Page != 2 || page.Limit != 10 || rec.Header().Get("X-Total-Count") != "23" {
+		t.Errorf("Unexpected totals %+v", page)
+	}
+	link := rec.Header().Get("Link")
+	for _, want := range []string{`page=1>; rel="first"`, `page=1>; rel="prev"`, `page=3>; rel="next"`, `page=3>; rel="last"`} {
+		if !strings.Contains(link, want) {
+			t.Errorf("Expected %s in Link %q", want, link)
+		}
+	}
+
+	_, page = getPage(t, h, "/users?offset=20")
+	if ids := pageIDs(page); len(ids) != 3 || ids[0] != 21 || page.NextCursor != "" {
+		t.Errorf("Expected the last 3 items, got %v", ids)
+	}
+	rec, page = getPage(t, h, "/users?offset=5&limit=10")
+	if ids := pageIDs(page); len(ids) != 10 || ids[0] != 6 || page.Offset != 5 || page.Page != 0 {
+		t.Errorf("Expected items 6-15 off page boundaries, got %v (%+v)", ids, page)
+	}
+	if link := rec.Header().Get("Link"); !strings.Contains(link, `offset=15>; rel="next"`) || strings.Contains(link, "page=") {
+		t.Errorf("Expected offset links after paging by offset, got %q", link)
+	}
+	if gen.calls.Load() != 23 {
+		t.Errorf("Expected one generator call per item, got %d", gen.calls.Load())
+	}
+}
+
+func TestCollection_HugeOffsets(t *testing.T) {
+	h := New(WithGenerator(&itemGenerator{})).Collection(collectionUser{}, CollectionSpec{Size: 5})
+
+	for _, target := range []string{"/users?offset=9223372036854775807", "/users?cursor=" + encodeCursor(math.MaxInt)} {
+		rec, page := getPage(t, h, target)
+		if rec.Code != http.StatusOK || len(page.Items) != 0 || page.Offset != 5 {
+			t.Errorf("Expected an empty last page for %s, got %d %+v", target, rec.Code, page)
+		}
+	}
+	if rec, _ := getPage(t, h, "/users?page=9223372036854775807"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an overflowing page, got %d", rec.Code)
+	}
+}
+
+func TestCollection_Cursor(t *testing.T) {
+	h := New(WithGenerator(&itemGenerator{})).Collection(collectionUser{}, CollectionSpec{Size: 12, PageSize: 5})
+
+	var seen []int
+	target := "/users"
+	for range 4 {
+		rec, page := getPage(t, h, target)
+		seen = append(seen, pageIDs(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		if target != "/users" && !strings.Contains(rec.Header().Get("Link"), "cursor=") {
+			t.Errorf("Expected cursor links after paging by cursor, got %q", rec.Header().Get("Link"))
+		}
+		target = "/users?cursor=" + page.NextCursor
+	}
+	if len(seen) != 12 || seen[0] != 1 || seen[11] != 12 {
+		t.Errorf("Expected every item once across pages, got %v", seen)
+	}
+
+	if rec, _ := getPage(t, h, "/users?cursor=bogus"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid cursor, got %d", rec.Code)
+	}
+	if rec, _ := getPage(t, h, "/users?limit=0"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a zero limit, got %d", rec.Code)
+	}
+}
+
+func TestCollection_Filters(t *testing.T) {
+	h := New(WithGenerator(&itemGenerator{})).Collection(collectionUser{}, CollectionSpec{Size: 9})
+
+	_, page := getPage(t, h, "/users?status=BANNED")
+	if ids := pageIDs(page); page.Total != 3 || len(ids) != 3 || ids[0] != 1 || ids[1] != 4 {
+		t.Errorf("Expected the banned users, got %v (total %d)", ids, page.Total)
+	}
+	_, page = getPage(t, h, "/users?status=banned&status=active&unknown=x")
+	if page.Total != 9 {
+		t.Errorf("Expected alternatives and unknown parameters to keep all items, got %d", page.Total)
+	}
+
+	h = New(WithGenerator(&itemGenerator{})).Collection(collectionUser{}, CollectionSpec{Size: 9, Filters: []string{"name"}})
+	if _, page = getPage(t, h, "/users?status=banned"); page.Total != 9 {
+		t.Errorf("Expected only declared filters to apply, got %d", page.Total)
+	}
+}
+
+func TestCollection_StaticItems(t *testing.T) {
+	// The static generator repeats the sample; ids are made unique
+	h := New().Collection(collectionUser{ID: 7, Name: "Ada"}, CollectionSpec{Size: 3, Bare: true})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+	var users []collectionUser
+	if err := json.Unmarshal(rec.Body.Bytes(), &users); err != nil {
+		t.Fatalf("Expected a bare array, got %q", rec.Body.String())
+	}
+	if len(users) != 3 || users[0].ID != 1 || users[2].ID != 3 || users[2].Name != "Ada" {
+		t.Errorf("Unexpected items %+v", users)
+	}
+
+	fixed := []collectionUser{{ID: 5, Name: "a"}, {ID: 9, Name: "b"}}
+	h = New().Collection(collectionUser{}, CollectionSpec{Items: fixed, PageSize: 1})
+	_, page := getPage(t, h, "/users?page=2")
+	if ids := pageIDs(page); page.Total != 2 || len(ids) != 1 || ids[0] != 9 {
+		t.Errorf("Expected the fixed list, got %v", ids)
+	}
+}
+
+func TestCollection_BrokerOperation(t *testing.T) {
+	broker := NewAsyncBroker()
+	h := New(WithGenerator(broker)).Collection(collectionUser{}, CollectionSpec{Size: 2})
+
+	done := make(chan collectionPageBody)
+	go func() {
+		_, page := getPage(t, h, "/users")
+		done <- page
+	}()
+
+	answered := 0
+	for deadline := time.Now().Add(time.Second); answered < 2 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		for _, p := range broker.GetPendingRequests() {
+			if !strings.HasPrefix(p.Operation, "item ") || !strings.HasSuffix(p.Operation, " of 2") {
+				t.Errorf("Unexpected operation %q", p.Operation)
+			}
+			if broker.SubmitResponse(p.ID, []byte(`{"id":`+p.Operation[5:6]+`}`)) == nil {
+				answered++
+			}
+		}
+	}
+	if page := <-done; page.Total != 2 {
+		t.Errorf("Unexpected page %+v", page)
+	}
+}
@@ -0,0 +1,715 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnvVar names the environment variable that points Start at a mock
+// configuration file.
+const ConfigEnvVar = "GOBO_CONFIG"
+
+// FileConfig is the declarative mock configuration, loaded from YAML or JSON.
+//
+//	generator:
+//	  type: ollama
+//	  url: http://localhost:11434
+//	  model: llama3
+//	  mode: chat
+//	  seed: 42
+//	routes:
+//	  - method: GET
+//	    pattern: /users/{id}
+//	    status: 200
+//	    latency: 250ms
+//	    headers: {X-Mocked: "true"}
+//	    example: {id: "", name: ""}
+//	    instructions:
+//	      id: A UUID v4
+type FileConfig struct {
+	// Generator replaces the instance generator when set.
+	Generator *GeneratorConfig `json:"generator,omitempty"`
+	// Debug enables verbose logging.
+	Debug bool `json:"debug,omitempty"`
+	// Prompt customizes LLM prompts for every route, see PromptConfig.
+	Prompt *PromptFileConfig `json:"prompt,omitempty"`
+	// Routes are matched before routes registered in code.
+	Routes []RouteConfig `json:"routes"`
+}
+
+// GeneratorConfig selects a built-in generator.
+type GeneratorConfig struct {
+	// Type is "ollama", "anthropic" or "static".
+	Type  string `json:"type"`
+	URL   string `json:"url,omitempty"`
+	Model string `json:"model,omitempty"`
+	// APIKeyEnv and MaxTokens configure the anthropic generator, see
+	// AnthropicAPIKeyEnv and AnthropicMaxTokens.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	MaxTokens int    `json:"max_tokens,omitempty"`
+	// Mode is "generate" (default) or "chat", see OllamaChat.
+	Mode string `json:"mode,omitempty"`
+	// Temperature, Seed and NumCtx are Ollama model options.
+	Temperature *float64 `json:"temperature,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	// KeepAlive is how long Ollama keeps the model loaded, such as "10m".
+	KeepAlive string `json:"keep_alive,omitempty"`
+}
+
+// anthropic builds the AnthropicGenerator described by gc.
+func (gc *GeneratorConfig) anthropic() *AnthropicGenerator {
+	var opts []AnthropicOption
+	if gc.URL != "" {
+		opts = append(opts, AnthropicBaseURL(gc.URL))
+	}
+	if gc.APIKeyEnv != "" {
+		opts = append(opts, AnthropicAPIKeyEnv(gc.APIKeyEnv))
+	}
+	if gc.MaxTokens > 0 {
+		opts = append(opts, AnthropicMaxTokens(gc.MaxTokens))
+	}
+	return NewAnthropicGenerator(gc.Model, opts...)
+}
+
+// ollamaOptions converts the Ollama settings into options.
+func (gc *GeneratorConfig) ollamaOptions() ([]OllamaOption, error) {
+	if gc == nil {
+		return nil, nil
+	}
+	var opts []OllamaOption
+	switch gc.Mode {
+	case "", "generate":
+	case "chat":
+		opts = append(opts, OllamaChat())
+	default:
+		return nil, fmt.Errorf("unknown ollama mode %q", gc.Mode)
+	}
+	if gc.Temperature != nil {
+		opts = append(opts, OllamaTemperature(*gc.Temperature))
+	}
+	if gc.Seed != nil {
+		opts = append(opts, OllamaSeed(*gc.Seed))
+	}
+	if gc.NumCtx > 0 {
+		opts = append(opts, OllamaNumCtx(gc.NumCtx))
+	}
+	if gc.KeepAlive != "" {
+		opts = append(opts, OllamaKeepAlive(gc.KeepAlive))
+	}
+	return opts, nil
+}
+
+// RouteConfig declares a single mocked route.
+type RouteConfig struct {
+	// Method is the HTTP method, or "ANY". It may be omitted when Pattern is
+	// written in ServeMux form ("GET /users/{id}").
+	Method string `json:"method,omitempty"`
+	// Pattern is a path prefix or a ServeMux-style pattern, see Register.
+	Pattern string `json:"pattern"`
+	// Example is a sample response body describing the shape.
+	Example any `json:"example,omitempty"`
+	// JSONSchema describes the shape as a JSON Schema instead of an example.
+	JSONSchema map[string]any `json:"json_schema,omitempty"`
+	// Status is the response status code; defaults to 200.
+	Status int `json:"status,omitempty"`
+	// Headers are extra response headers.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Latency is an artificial delay such as "300ms" or "2s".
+	Latency string `json:"latency,omitempty"`
+	// Generator is "default" (the instance generator), "static" or "ollama".
+	Generator string `json:"generator,omitempty"`
+	// Template is a text/template rendered as the response, see TemplateGenerator.
+	Template string `json:"template,omitempty"`
+	// Fixture is a JSON file served as the response, relative to the config file.
+	Fixture string `json:"fixture,omitempty"`
+	// Instructions are per-field generation instructions keyed by JSON path.
+	Instructions map[string]string `json:"instructions,omitempty"`
+	// Request declares what clients must send, see RequestSpec.
+	Request *RequestConfig `json:"request,omitempty"`
+	// Examples are few-shot request/response pairs for generators.
+	Examples []Example `json:"examples,omitempty"`
+	// ExamplesFile loads more examples from a JSON file, relative to the
+	// config file. See LoadExamplesFile.
+	ExamplesFile string `json:"examples_file,omitempty"`
+	// Prompt customizes LLM prompts for this route.
+	Prompt *PromptFileConfig `json:"prompt,omitempty"`
+	// Stream answers with text/event-stream; the example or schema then
+	// describes each event's data. See RouteStream.
+	Stream bool `json:"stream,omitempty"`
+	// StreamDelay is the delay between events, such as "200ms".
+	StreamDelay string `json:"stream_delay,omitempty"`
+	// Formats are the response formats: "json", "xml", "text", "csv" or
+	// "form". The first is the default; see RouteFormat.
+	Formats []string `json:"formats,omitempty"`
+	// Download serves a file instead of a generated body, see Download.
+	Download *DownloadConfig `json:"download,omitempty"`
+	// GraphQLSchema mocks a GraphQL endpoint from schema SDL, see GraphQL.
+	GraphQLSchema string `json:"graphql_schema,omitempty"`
+	// GraphQLSchemaFile loads the SDL from a file, relative to the config file.
+	GraphQLSchemaFile string `json:"graphql_schema_file,omitempty"`
+	// RPC mocks a JSON-RPC 2.0 service, keyed by method. See JSONRPC.
+	RPC map[string]RPCMethodConfig `json:"rpc,omitempty"`
+	// Collection serves pages of a list; the example or schema then
+	// describes one item. See Collection.
+	Collection *CollectionConfig `json:"collection,omitempty"`
+	// Webhooks are callbacks sent after responding, see RouteWebhook.
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+	// GRPCDescriptorSet mocks the gRPC services of a FileDescriptorSet
+	// file, relative to the config file. See GRPC.
+	GRPCDescriptorSet string `json:"grpc_descriptor_set,omitempty"`
+}
+
+// WebhookConfig is the file form of Webhook. Example or JSONSchema
+// describes the payload; without either the response is sent:
+//
+//	webhooks:
+//	  - name: charge.succeeded
+//	    url: http://localhost:8080/webhooks/payments
+//	    delay: 2s
+//	    secret: whsec_test
+//	    when: {status: succeeded}
+//	    example: {type: charge.succeeded, transaction_id: ""}
+type WebhookConfig struct {
+	Name            string            `json:"name,omitempty"`
+	URL             string            `json:"url"`
+	Example         any               `json:"example,omitempty"`
+	JSONSchema      map[string]any    `json:"json_schema,omitempty"`
+	Delay           string            `json:"delay,omitempty"`
+	Secret          string            `json:"secret,omitempty"`
+	SignatureHeader string            `json:"signature_header,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	MaxAttempts     int               `json:"max_attempts,omitempty"`
+	// Backoff is the wait before the first retry, such as "500ms".
+	Backoff string            `json:"backoff,omitempty"`
+	When    map[string]string `json:"when,omitempty"`
+	Manual  bool              `json:"manual,omitempty"`
+}
+
+// build returns the configured Webhook.
+func (wc WebhookConfig) build() (Webhook, error) {
+	hook := Webhook{
+		Name:            wc.Name,
+		URL:             wc.URL,
+		Schema:          wc.Example,
+		Secret:          wc.Secret,
+		SignatureHeader: wc.SignatureHeader,
+		Headers:         wc.Headers,
+		MaxAttempts:     wc.MaxAttempts,
+		When:            wc.When,
+		Manual:          wc.Manual,
+	}
+	if hook.URL == "" {
+		return Webhook{}, fmt.Errorf("webhook has no url")
+	}
+	if wc.JSONSchema != nil {
+		hook.Schema = JSONSchema(wc.JSONSchema)
+	}
+	if wc.Delay != "" {
+		d, err := time.ParseDuration(wc.Delay)
+		if err != nil {
+			return Webhook{}, fmt.Errorf("invalid delay: %w", err)
+		}
+		hook.Delay = d
+	}
+	if wc.Backoff != "" {
+		d, err := time.ParseDuration(wc.Backoff)
+		if err != nil {
+			return Webhook{}, fmt.Errorf("invalid backoff: %w", err)
+		}
+		hook.Backoff = d
+	}
+	return hook, nil
+}
+
+// CollectionConfig is the file form of CollectionSpec. Items or ItemsFile fix
+// the backing list; otherwise Size items are generated:
+//
+//	collection:
+//	  size: 42
+//	  page_size: 20
+//	  filters: [status]
+type CollectionConfig struct {
+	Size        int      `json:"size,omitempty"`
+	PageSize    int      `json:"page_size,omitempty"`
+	MaxPageSize int      `json:"max_page_size,omitempty"`
+	Filters     []string `json:"filters,omitempty"`
+	Bare        bool     `json:"bare,omitempty"`
+	Items       []any    `json:"items,omitempty"`
+	// ItemsFile is a JSON array of items, relative to the config file.
+	ItemsFile string `json:"items_file,omitempty"`
+}
+
+// build returns the configured CollectionSpec.
+func (cc CollectionConfig) build(baseDir string) (CollectionSpec, error) {
+	c := CollectionSpec{
+		Size:        cc.Size,
+		PageSize:    cc.PageSize,
+		MaxPageSize: cc.MaxPageSize,
+		Filters:     cc.Filters,
+		Bare:        cc.Bare,
+	}
+	if cc.Items != nil {
+		c.Items = cc.Items
+	}
+	if cc.ItemsFile != "" {
+		data, err := os.ReadFile(resolvePath(baseDir, cc.ItemsFile))
+		if err != nil {
+			return CollectionSpec{}, fmt.Errorf("failed to read collection items: %w", err)
+		}
+		if _, err := fixedItems(json.RawMessage(data)); err != nil {
+			return CollectionSpec{}, err
+		}
+		c.Items = json.RawMessage(data)
+	}
+	return c, nil
+}
+
+// RPCMethodConfig is the file form of RPCMethod. Example or JSONSchema
+// describes the result:
+//
+//	rpc:
+//	  eth_blockNumber:
+//	    example: "0x10d4f"
+//	  eth_sendTransaction:
+//	    error: {code: -32000, message: insufficient funds}
+type RPCMethodConfig struct {
+	Example    any            `json:"example,omitempty"`
+	JSONSchema map[string]any `json:"json_schema,omitempty"`
+	// Params is a JSON Schema validating named params.
+	Params map[string]any `json:"params,omitempty"`
+	Error  *RPCError      `json:"error,omitempty"`
+}
+
+// PromptFileConfig is the file form of PromptConfig.
+//
+//	prompt:
+//	  context: This is a Brazilian payments API. Amounts are in BRL.
+//	  template_file: prompts/payments.tmpl
+type PromptFileConfig struct {
+	// Template is a prompt template, see TemplatePromptBuilder.
+	Template string `json:"template,omitempty"`
+	// TemplateFile loads the template from a file, relative to the config file.
+	TemplateFile string `json:"template_file,omitempty"`
+	// Context is domain context included in the prompt.
+	Context string `json:"context,omitempty"`
+	// State is made available to prompt templates as .State.
+	State map[string]any `json:"state,omitempty"`
+}
+
+// DownloadConfig is the file form of a download route's Artifact. Dir
+// serves fixture files; otherwise Placeholder picks a generated file:
+//
+//	download:
+//	  placeholder: png
+//	  width: 64
+//	  height: 64
+type DownloadConfig struct {
+	// Dir is a fixtures directory, relative to the config file. See FixtureFiles.
+	Dir     string `json:"dir,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Default string `json:"default,omitempty"`
+	// Placeholder is "png", "pdf" or "zip".
+	Placeholder string            `json:"placeholder,omitempty"`
+	Width       int               `json:"width,omitempty"`
+	Height      int               `json:"height,omitempty"`
+	Color       string            `json:"color,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Text        string            `json:"text,omitempty"`
+	Files       map[string]string `json:"files,omitempty"`
+}
+
+// build returns the configured Artifact.
+func (dc DownloadConfig) build(baseDir string) (Artifact, error) {
+	if dc.Dir != "" {
+		return FixtureFiles{Dir: resolvePath(baseDir, dc.Dir), Name: dc.Name, Default: dc.Default}, nil
+	}
+	switch dc.Placeholder {
+	case "png":
+		return PlaceholderPNG{Width: dc.Width, Height: dc.Height, Color: dc.Color}, nil
+	case "pdf":
+		return PlaceholderPDF{Title: dc.Title, Text: dc.Text}, nil
+	case "zip":
+		return PlaceholderZip{Files: dc.Files}, nil
+	}
+	return nil, fmt.Errorf("download needs a dir or a placeholder of png, pdf or zip, got %q", dc.Placeholder)
+}
+
+// RequestConfig is the file form of RequestSpec.
+type RequestConfig struct {
+	JSONSchema   map[string]any `json:"json_schema,omitempty"`
+	BodyRequired bool           `json:"body_required,omitempty"`
+	Query        []Param        `json:"query,omitempty"`
+	Headers      []Param        `json:"headers,omitempty"`
+	PassThrough  bool           `json:"pass_through,omitempty"`
+}
+
+// ParseFileConfig decodes a YAML or JSON mock configuration.
+func ParseFileConfig(data []byte) (*FileConfig, error) {
+	var cfg FileConfig
+	if err := decodeYAMLOrJSON(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// decodeYAMLOrJSON decodes a YAML or JSON document into v. YAML is a superset
+// of JSON, so the document is decoded generically and funneled through
+// encoding/json to honor a single set of struct tags.
+func decodeYAMLOrJSON(data []byte, v any) error {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonBytes, v)
+}
+
+// LoadConfigFile reads the mock configuration at path and applies it,
+// replacing any routes loaded from a previous version of the file. Routes
+// registered in code are left untouched. On error the current configuration
+// stays in effect.
+func (g *Gobo) LoadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg, err := ParseFileConfig(data)
+	if err != nil {
+		return err
+	}
+	return g.ApplyFileConfig(cfg, filepath.Dir(path))
+}
+
+// ApplyFileConfig applies a parsed configuration. Relative fixture paths are
+// resolved against baseDir.
+func (g *Gobo) ApplyFileConfig(cfg *FileConfig, baseDir string) error {
+	g.mu.RLock()
+	ollamaURL, model := g.config.OllamaURL, g.config.Model
+	g.mu.RUnlock()
+
+	ollamaOpts, err := cfg.Generator.ollamaOptions()
+	if err != nil {
+		return err
+	}
+
+	var client Generator
+	if gc := cfg.Generator; gc != nil {
+		// The URL and model also apply to routes using the "ollama" generator
+		if gc.Type != "anthropic" {
+			if gc.URL != "" {
+				ollamaURL = gc.URL
+			}
+			if gc.Model != "" {
+				model = gc.Model
+			}
+		}
+		switch gc.Type {
+		case "ollama":
+			client = NewOllamaGenerator(defaultOllamaURL(ollamaURL), model, ollamaOpts...)
+		case "anthropic":
+			if gc.Model == "" {
+				return fmt.Errorf("anthropic generator requires a model")
+			}
+			client = gc.anthropic()
+		case "static":
+			client = StaticGenerator{}
+		default:
+			return fmt.Errorf("unknown generator type %q", gc.Type)
+		}
+	}
+
+	var prompt PromptConfig
+	if cfg.Prompt != nil {
+		p, err := cfg.Prompt.build(baseDir)
+		if err != nil {
+			return fmt.Errorf("prompt: %w", err)
+		}
+		prompt = *p
+	}
+
+	routes := make([]*routeSchema, 0, len(cfg.Routes))
+	for i, rc := range cfg.Routes {
+		route, err := rc.build(baseDir, defaultOllamaURL(ollamaURL), model, ollamaOpts)
+		if err != nil {
+			return fmt.Errorf("route %d (%s): %w", i, rc.Pattern, err)
+		}
+		routes = append(routes, route)
+	}
+
+	g.mu.Lock()
+	g.fileRoutes = routes
+	g.filePrompt = prompt
+	g.fileDebug = cfg.Debug
+	g.fileClient = client
+	g.mu.Unlock()
+
+	for _, route := range routes {
+		g.prefetch.warm(route)
+	}
+	g.logf("Loaded %d routes from config", len(routes))
+	return nil
+}
+
+// build converts a RouteConfig into a routeSchema. Routes using the "ollama"
+// generator share the file's Ollama settings.
+func (rc RouteConfig) build(baseDir, ollamaURL, model string, ollamaOpts []OllamaOption) (*routeSchema, error) {
+	method, pattern := rc.Method, rc.Pattern
+	if m, p, ok := strings.Cut(pattern, " "); ok {
+		method, pattern = m, strings.TrimSpace(p)
+	}
+	if method == "" {
+		method = "ANY"
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "/" + pattern
+	}
+
+	var schema any = rc.Example
+	if rc.JSONSchema != nil {
+		schema = JSONSchema(rc.JSONSchema)
+	}
+	if len(rc.Instructions) > 0 {
+		schema = Annotated{Schema: schema, Instructions: rc.Instructions}
+	}
+
+	route := &routeSchema{
+		Method:         strings.ToUpper(method),
+		PathPrefix:     pattern,
+		ResponseSchema: schema,
+		Status:         rc.Status,
+		Headers:        rc.Headers,
+		Examples:       rc.Examples,
+	}
+
+	for _, name := range rc.Formats {
+		format, ok := formatsByName[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown format %q", name)
+		}
+		route.Formats = append(route.Formats, format)
+	}
+
+	if rc.Download != nil {
+		artifact, err := rc.Download.build(baseDir)
+		if err != nil {
+			return nil, err
+		}
+		// An example or schema fills the PDF with generated fields
+		if pdf, ok := artifact.(PlaceholderPDF); ok && (rc.Example != nil || rc.JSONSchema != nil) {
+			pdf.Schema = schema
+			artifact = pdf
+		}
+		route.Artifact = artifact
+	}
+
+	if sdl := rc.GraphQLSchema; sdl != "" || rc.GraphQLSchemaFile != "" {
+		if rc.GraphQLSchemaFile != "" {
+			data, err := os.ReadFile(resolvePath(baseDir, rc.GraphQLSchemaFile))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read graphql schema: %w", err)
+			}
+			sdl = string(data)
+		}
+		gql, err := parseGraphQLSchema(sdl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse graphql schema: %w", err)
+		}
+		route.GraphQL = gql
+	}
+
+	if rc.Collection != nil {
+		c, err := rc.Collection.build(baseDir)
+		if err != nil {
+			return nil, err
+		}
+		RouteCollection(c)(route)
+	}
+
+	if rc.GRPCDescriptorSet != "" {
+		data, err := os.ReadFile(resolvePath(baseDir, rc.GRPCDescriptorSet))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read grpc descriptor set: %w", err)
+		}
+		svc, err := parseDescriptorSet(data)
+		if err != nil {
+			return nil, err
+		}
+		route.GRPC = svc
+	}
+
+	for i, wc := range rc.Webhooks {
+		hook, err := wc.build()
+		if err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+		route.Webhooks = append(route.Webhooks, hook)
+	}
+
+	if len(rc.RPC) > 0 {
+		route.RPC = make(map[string]RPCMethod, len(rc.RPC))
+		for name, mc := range rc.RPC {
+			method := RPCMethod{Result: mc.Example, Error: mc.Error}
+			if mc.JSONSchema != nil {
+				method.Result = JSONSchema(mc.JSONSchema)
+			}
+			if mc.Params != nil {
+				method.Params = JSONSchema(mc.Params)
+			}
+			route.RPC[name] = method
+		}
+	}
+
+	if rc.ExamplesFile != "" {
+		examples, err := LoadExamplesFile(resolvePath(baseDir, rc.ExamplesFile))
+		if err != nil {
+			return nil, err
+		}
+		route.Examples = append(route.Examples, examples...)
+	}
+
+	if rc.Prompt != nil {
+		prompt, err := rc.Prompt.build(baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("prompt: %w", err)
+		}
+		route.Prompt = prompt
+	}
+
+	if req := rc.Request; req != nil {
+		route.Request = &RequestSpec{
+			BodyRequired: req.BodyRequired,
+			Query:        req.Query,
+			Headers:      req.Headers,
+			PassThrough:  req.PassThrough,
+		}
+		if req.JSONSchema != nil {
+			route.Request.Body = JSONSchema(req.JSONSchema)
+		}
+	}
+
+	if rc.Latency != "" {
+		d, err := time.ParseDuration(rc.Latency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency: %w", err)
+		}
+		route.Latency = d
+	}
+
+	if rc.Stream {
+		route.Stream = true
+		if rc.StreamDelay != "" {
+			d, err := time.ParseDuration(rc.StreamDelay)
+			if err != nil {
+				return nil, fmt.Errorf("invalid stream_delay: %w", err)
+			}
+			route.StreamDelay = d
+		}
+	}
+
+	switch {
+	case rc.Template != "":
+		tg, err := NewTemplateGenerator(rc.Template)
+		if err != nil {
+			return nil, err
+		}
+		route.Generator = tg
+	case rc.Fixture != "":
+		route.Generator = FixtureGenerator{Path: resolvePath(baseDir, rc.Fixture)}
+	default:
+		switch rc.Generator {
+		case "", "default":
+		case "static":
+			route.Generator = StaticGenerator{}
+		case "ollama":
+			route.Generator = NewOllamaGenerator(ollamaURL, model, ollamaOpts...)
+		default:
+			return nil, fmt.Errorf("unknown generator %q", rc.Generator)
+		}
+	}
+
+	return route, nil
+}
+
+// build converts a PromptFileConfig into a PromptConfig.
+func (pc PromptFileConfig) build(baseDir string) (*PromptConfig, error) {
+	prompt := &PromptConfig{Context: pc.Context, State: pc.State}
+
+	text := pc.Template
+	if pc.TemplateFile != "" {
+		data, err := os.ReadFile(resolvePath(baseDir, pc.TemplateFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		text = string(data)
+	}
+	if text != "" {
+		builder, err := NewTemplatePromptBuilder(text)
+		if err != nil {
+			return nil, err
+		}
+		prompt.Builder = builder
+	}
+	return prompt, nil
+}
+
+// WatchConfigFile reloads the configuration at path whenever its modification
+// time or size changes, polling at the given interval until ctx is done.
+// Reload errors are logged and leave the previous configuration active.
+func (g *Gobo) WatchConfigFile(ctx context.Context, path string, interval time.Duration) {
+	var lastMod time.Time
+	var lastSize int64
+	if info, err := os.Stat(path); err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || (info.ModTime().Equal(lastMod) && info.Size() == lastSize) {
+			continue
+		}
+		lastMod, lastSize = info.ModTime(), info.Size()
+
+		if err := g.LoadConfigFile(path); err != nil {
+			log.Printf("[gobo] config reload failed, keeping previous config: %v", err)
+			continue
+		}
+		log.Printf("[gobo] reloaded %s", path)
+	}
+}
+
+// resolvePath resolves a path from the config file against its directory.
+func resolvePath(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// defaultOllamaURL returns url, or the standard local Ollama address when empty.
+func defaultOllamaURL(url string) string {
+	if url == "" {
+		return "http://localhost:11434"
+	}
+	return url
+}
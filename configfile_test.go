@@ -0,0 +1,255 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfigYAML = `
+routes:
+  - pattern: GET /users/{id}
+    status: 201
+    headers:
+      X-Mocked: "yes"
+    template: '{"id": {{json .Request.URL}}}'
+  - method: post
+    pattern: /orders
+    generator: static
+    example:
+      order_id: ORD-1
+      total: 10
+    instructions:
+      order_id: An order number like ORD-1234
+  - method: GET
+    pattern: /fixture
+    fixture: fixture.json
+`
+
+func writeConfig(t *testing.T, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, "gobo.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigFile_Routes(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, testConfigYAML)
+	if err := os.WriteFile(filepath.Join(dir, "fixture.json"), []byte(`{"from":"fixture"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := New()
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	handler := g.Middleware(http.NotFoundHandler())
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/users/42", nil))
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected 201, got %d", rr.Code)
+	}
+	if rr.Header().Get("X-Mocked") != "yes" {
+		t.Errorf("Expected X-Mocked header, got %q", rr.Header().Get("X-Mocked"))
+	}
+	if rr.Body.String() != `{"id": "/users/42"}` {
+		t.Errorf("Unexpected template output: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/orders", nil))
+	var order map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &order); err != nil || order["order_id"] != "ORD-1" {
+		t.Errorf("Expected static example, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/fixture", nil))
+	if rr.Body.String() != `{"from":"fixture"}` {
+		t.Errorf("Expected fixture body, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/users", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected unmatched route to pass through, got %d", rr.Code)
+	}
+
+	fields := reflectSchema(g.matchFile(httptest.NewRequest("POST", "/orders", nil)).ResponseSchema)
+	if len(fields) != 2 || fields[0].JSONName != "order_id" || fields[0].Interpreter != "An order number like ORD-1234" {
+		t.Errorf("Expected config instructions on reflected fields, got %+v", fields)
+	}
+}
+
+func TestLoadConfigFile_OverridesStub(t *testing.T) {
+	g := New()
+	cfg, err := ParseFileConfig([]byte(`{"routes":[{"pattern":"GET /health","status":503,"example":{"status":"down"}}]}`))
+	if err != nil {
+		t.Fatalf("ParseFileConfig failed: %v", err)
+	}
+	if err := g.ApplyFileConfig(cfg, "."); err != nil {
+		t.Fatalf("ApplyFileConfig failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	g.Stub(map[string]string{"status": "ok"}).ServeHTTP(rr, httptest.NewRequest("GET", "/health", nil))
+	if rr.Code != http.StatusServiceUnavailable || rr.Body.String() != "{\"status\":\"down\"}\n" {
+		t.Errorf("Expected config route to override Stub, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestLoadConfigFile_InvalidKeepsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, testConfigYAML)
+
+	g := New()
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	writeConfig(t, dir, "routes:\n  - pattern: /x\n    latency: soon\n")
+	if err := g.LoadConfigFile(path); err == nil {
+		t.Fatal("Expected invalid latency to fail")
+	}
+	if g.matchFile(httptest.NewRequest("POST", "/orders", nil)) == nil {
+		t.Error("Expected previous routes to remain after a failed reload")
+	}
+}
+
+func TestApplyFileConfig_ReloadResetsSettings(t *testing.T) {
+	code := &mockGenerator{Response: []byte(`{}`)}
+	g := New(WithGenerator(code))
+	withFile, err := ParseFileConfig([]byte("debug: true\ngenerator:\n  type: static\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyFileConfig(withFile, "."); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.generator(nil).(StaticGenerator); !ok || !g.fileDebug {
+		t.Fatalf("Expected the file's settings, got %T", g.generator(nil))
+	}
+
+	without, _ := ParseFileConfig([]byte("routes: []\n"))
+	if err := g.ApplyFileConfig(without, "."); err != nil {
+		t.Fatal(err)
+	}
+	if g.generator(nil) != code || g.fileDebug {
+		t.Errorf("Expected the settings from code after the reload, got %T", g.generator(nil))
+	}
+}
+
+func TestWatchConfigFile_Reloads(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "routes: []\n")
+
+	g := New()
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go g.WatchConfigFile(ctx, path, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond) // let the watcher record the initial state
+
+	writeConfig(t, dir, "routes:\n  - pattern: GET /reloaded\n    generator: static\n    example: {ok: true}\n")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if g.matchFile(httptest.NewRequest("GET", "/reloaded", nil)) != nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for config reload")
+}
+
+func TestLoadConfigFile_Collection(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "users.json"), []byte(`[{"id":1,"role":"admin"},{"id":2,"role":"member"},{"id":3,"role":"admin"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeConfig(t, dir, `
+routes:
+  - pattern: GET /users
+    example: {id: 1, role: admin}
+    collection:
+      items_file: users.json
+      page_size: 1
+`)
+	g := New()
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	g.Middleware(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/users?role=admin&page=2", nil))
+	var page collectionPageBody
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Invalid page %q", rr.Body.String())
+	}
+	if page.Total != 2 || len(page.Items) != 1 || string(page.Items[0]) != `{"id":3,"role":"admin"}` {
+		t.Errorf("Unexpected page %s", rr.Body.String())
+	}
+}
+
+func TestLoadConfigFile_Webhooks(t *testing.T) {
+	cfg, err := ParseFileConfig([]byte(`
+routes:
+  - pattern: POST /charge
+    example: {status: succeeded}
+    webhooks:
+      - name: charge.succeeded
+        url: http://localhost:8080/webhooks
+        delay: 2s
+        backoff: 500ms
+        when: {status: succeeded}
+        example: {type: charge.succeeded}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New()
+	if err := g.ApplyFileConfig(cfg, ""); err != nil {
+		t.Fatalf("ApplyFileConfig failed: %v", err)
+	}
+	hook, ok := g.WebhookNamed("charge.succeeded")
+	if !ok || hook.Delay != 2*time.Second || hook.Backoff != 500*time.Millisecond || hook.When["status"] != "succeeded" || hook.Schema == nil {
+		t.Errorf("Unexpected webhook %+v", hook)
+	}
+
+	cfg, _ = ParseFileConfig([]byte(`{"routes":[{"pattern":"/x","webhooks":[{"name":"nourl"}]}]}`))
+	if err := g.ApplyFileConfig(cfg, ""); err == nil {
+		t.Error("Expected an error for a webhook without url")
+	}
+}
+
+func TestLoadConfigFile_GRPC(t *testing.T) {
+	dir := t.TempDir()
+	set, _ := testDescriptorSet(t)
+	if err := os.WriteFile(filepath.Join(dir, "users.pb"), set, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeConfig(t, dir, `
+routes:
+  - pattern: POST /users.v1.UserService/
+    grpc_descriptor_set: users.pb
+`)
+	g := New()
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	route := g.matchFile(httptest.NewRequest("POST", "/users.v1.UserService/GetUser", nil))
+	if route == nil || route.GRPC == nil || route.GRPC.methods["/users.v1.UserService/GetUser"] == nil {
+		t.Errorf("Expected the gRPC service on the route, got %+v", route)
+	}
+}
@@ -1,10 +1,12 @@
 package gobo
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"sync"
+	"time"
 )
 
 var (
@@ -33,6 +35,13 @@ func Enabled() bool {
 // the MCP server on stdio so an AI agent can fulfill intercepted requests.
 // When GOBO is not set, Start is a no-op and all Stub/Intercept calls pass through.
 //
+// Generators implementing HealthChecker, including route generators and
+// generators wrapped by others, are checked once; failures are logged,
+// since the backend may still come up later.
+//
+// If GOBO_CONFIG names a YAML or JSON mock configuration file (see FileConfig),
+// it is loaded before the generator is chosen and reloaded whenever it changes.
+//
 // Call this once at the top of main():
 //
 //	func main() {
@@ -52,11 +61,33 @@ func Start(opts ...Option) {
 			opt(defaultInstance)
 		}
 
+		if path := os.Getenv(ConfigEnvVar); path != "" {
+			if err := defaultInstance.LoadConfigFile(path); err != nil {
+				log.Printf("[gobo] failed to load %s: %v", path, err)
+			}
+			go defaultInstance.WatchConfigFile(context.Background(), path, time.Second)
+		}
+
 		// If no generator was provided, set up the AsyncBroker + MCP server
-		if defaultInstance.client == nil && mcpStarter != nil {
+		gen := defaultInstance.generator(nil)
+		if gen == nil && mcpStarter != nil {
 			mcpStarter(defaultInstance)
 		}
 
+		// Surface a missing model now rather than on the first request.
+		// Wrapped generators are listed too, so only leaves are checked.
+		for _, gen := range defaultInstance.generators() {
+			hc, ok := gen.(HealthChecker)
+			if _, wraps := gen.(GeneratorWrapper); !ok || wraps {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := hc.HealthCheck(ctx); err != nil {
+				log.Printf("[gobo] generator health check failed: %v", err)
+			}
+			cancel()
+		}
+
 		log.Println("[gobo] enabled — intercepting HTTP requests")
 	})
 }
@@ -68,8 +99,45 @@ func Start(opts ...Option) {
 // Usage:
 //
 //	mux.Handle("GET /health", gobo.Stub(HealthResponse{Status: "ok"}))
-func Stub(schema any) http.Handler {
-	return defaultInstance.Stub(schema)
+func Stub(schema any, opts ...RouteOption) http.Handler {
+	if !enabled {
+		// Route generators stay inactive too; only static JSON is served
+		opts = append(opts, RouteGenerator(nil))
+	}
+	return defaultInstance.Stub(schema, opts...)
+}
+
+// WebSocket returns a WebSocket stub on the default instance. When Gobo is
+// disabled, client messages are answered with the schema as static JSON.
+//
+// Usage:
+//
+//	mux.Handle("GET /ws/prices", gobo.WebSocket(PriceTick{}))
+func WebSocket(schema any, opts ...RouteOption) http.Handler {
+	if !enabled {
+		opts = append(opts, RouteGenerator(nil))
+	}
+	return defaultInstance.WebSocket(schema, opts...)
+}
+
+// Download returns a file download stub on the default instance, see
+// Gobo.Download.
+//
+// Usage:
+//
+//	mux.Handle("GET /invoices/{id}", gobo.Download(gobo.PlaceholderPDF{Title: "Invoice {id}"}))
+func Download(a Artifact, opts ...RouteOption) http.Handler {
+	return defaultInstance.Download(a, opts...)
+}
+
+// Collection returns a paginated list stub on the default instance, see
+// Gobo.Collection.
+//
+// Usage:
+//
+//	mux.Handle("GET /users", gobo.Collection(User{}, gobo.CollectionSpec{Size: 42}))
+func Collection(item any, c CollectionSpec, opts ...RouteOption) http.Handler {
+	return defaultInstance.Collection(item, c, opts...)
 }
 
 // Intercept wraps a real http.Handler. When Gobo is enabled and a generator
@@ -79,8 +147,11 @@ func Stub(schema any) http.Handler {
 // Usage:
 //
 //	mux.Handle("POST /users", gobo.Intercept(realHandler, UserResponse{}))
-func Intercept(handler http.Handler, schema any) http.Handler {
-	return defaultInstance.Intercept(handler, schema)
+func Intercept(handler http.Handler, schema any, opts ...RouteOption) http.Handler {
+	if !enabled {
+		return handler
+	}
+	return defaultInstance.Intercept(handler, schema, opts...)
 }
 
 // InterceptFunc is a convenience wrapper around Intercept for http.HandlerFunc.
@@ -88,8 +159,55 @@ func Intercept(handler http.Handler, schema any) http.Handler {
 // Usage:
 //
 //	mux.Handle("POST /users", gobo.InterceptFunc(handleCreateUser, UserResponse{}))
-func InterceptFunc(handler http.HandlerFunc, schema any) http.Handler {
-	return defaultInstance.Intercept(handler, schema)
+func InterceptFunc(handler http.HandlerFunc, schema any, opts ...RouteOption) http.Handler {
+	return Intercept(handler, schema, opts...)
+}
+
+// Handle mounts h on mux under pattern using the default instance, so Gobo
+// handlers are described by the OpenAPI export before they serve traffic.
+// See Gobo.Handle.
+//
+// Usage:
+//
+//	gobo.Handle(mux, "GET /users/{id}", gobo.Stub(User{}))
+func Handle(mux Mux, pattern string, h http.Handler) {
+	defaultInstance.Handle(mux, pattern, h)
+}
+
+// AdminHandler returns the default instance's admin endpoints, such as the
+// OpenAPI export. Mount it under AdminPrefix:
+//
+//	mux.Handle(gobo.AdminPrefix, gobo.AdminHandler())
+func AdminHandler() http.Handler {
+	return defaultInstance.AdminHandler()
+}
+
+// Shadow wraps a real handler and, when Gobo is enabled, compares its JSON
+// responses against schema and reports drift. The real response is always
+// served. When disabled, it returns handler unchanged.
+//
+// Usage:
+//
+//	mux.Handle("GET /users/{id}", gobo.Shadow(realHandler, UserResponse{}))
+func Shadow(handler http.Handler, schema any) http.Handler {
+	if !enabled {
+		return handler
+	}
+	return defaultInstance.Shadow(handler, schema)
+}
+
+// Validate wraps a handler so that, when Gobo is enabled, requests are
+// checked against spec before it runs, using the default instance. See
+// Gobo.Validate. When disabled, it returns next unchanged.
+//
+// Usage:
+//
+//	mux.Handle("POST /users", gobo.Validate(gobo.RequestSpec{Body: CreateUser{}}, gobo.Stub(User{})))
+func Validate(spec RequestSpec, next http.Handler) http.Handler {
+	if !enabled {
+		return next
+	}
+	return defaultInstance.Validate(spec, next)
 }
 
 // RegisterMCPStarter is called by the gobo/mcp package's init() to register
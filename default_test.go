@@ -54,3 +54,35 @@ func TestEnabled_DefaultFalse(t *testing.T) {
 		t.Error("Expected Enabled() to be false by default")
 	}
 }
+
+func TestPackageLevel_DisabledIgnoresRouteGenerator(t *testing.T) {
+	gen := &mockGenerator{Response: []byte(`{"mocked":true}`)}
+	real := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("real"))
+	})
+
+	rr := httptest.NewRecorder()
+	Intercept(real, struct{}{}, RouteGenerator(gen)).ServeHTTP(rr, httptest.NewRequest("GET", "/users", nil))
+	if rr.Body.String() != "real" {
+		t.Errorf("Expected pass-through when disabled, got %q", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	Stub(map[string]bool{"mocked": false}, RouteGenerator(gen)).ServeHTTP(rr, httptest.NewRequest("GET", "/users", nil))
+	if rr.Body.String() != "{\"mocked\":false}\n" {
+		t.Errorf("Expected static JSON when disabled, got %q", rr.Body.String())
+	}
+}
+
+func TestPackageLevelValidate_Disabled(t *testing.T) {
+	live := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("real"))
+	})
+	spec := RequestSpec{Headers: []Param{{Name: "X-Tenant", Required: true}}}
+
+	rr := httptest.NewRecorder()
+	Validate(spec, live).ServeHTTP(rr, httptest.NewRequest("POST", "/users", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "real" {
+		t.Errorf("Expected pass-through when disabled, got %d %q", rr.Code, rr.Body.String())
+	}
+}
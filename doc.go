@@ -53,6 +53,13 @@ Use the "gobo" struct tag to give field-level instructions to the AI:
 	    Amount        int    `json:"amount" gobo:"Amount in cents, between 100 and 99999"`
 	}
 
+# Configuration File
+
+Set GOBO_CONFIG to a YAML or JSON file to declare routes without recompiling.
+Each route sets a method and pattern, an example body or JSON Schema, and
+optionally a status, headers, latency, generator, template, fixture and
+per-field instructions. See [FileConfig]. The file is reloaded on change.
+
 # Instance API
 
 For advanced use cases, create your own [Gobo] instance:
@@ -0,0 +1,125 @@
+package gobo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultExampleBudget is the default token budget for examples per request.
+const defaultExampleBudget = 2000
+
+// AddExamples attaches example request/response pairs to a route, identified
+// by method and pattern exactly as it was registered ("GET", "/users/{id}").
+// For Stub and Intercept handlers use their ServeMux pattern, with an empty
+// method for patterns without one ("", "/users"). Examples are passed to
+// generators in RequestContext.Examples, included in LLM prompts and shown
+// to agents in PendingRequest.
+func (g *Gobo) AddExamples(method, pattern string, examples ...Example) {
+	key := patternKey(method, pattern)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.examples[key] = append(g.examples[key], examples...)
+}
+
+// patternKey is the routeKey of a route given by method and pattern: a
+// ServeMux pattern without a method is its own key.
+func patternKey(method, pattern string) string {
+	if method == "" {
+		return pattern
+	}
+	return strings.ToUpper(method) + " " + pattern
+}
+
+// LoadExamplesFile reads examples from a JSON file holding either an array
+// of Example values or a recording file written by WithRecording.
+func LoadExamplesFile(path string) ([]Example, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read examples: %w", err)
+	}
+
+	var examples []Example
+	if err := json.Unmarshal(data, &examples); err == nil {
+		return examples, nil
+	}
+	var file recordingFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode examples: %w", err)
+	}
+	return file.Examples, nil
+}
+
+// WithExampleBudget caps the approximate number of tokens spent on examples
+// per request (default 2000). Examples are kept in order — the route's own,
+// then those added with AddExamples, then recordings — until the budget is
+// spent; larger ones are skipped. Zero or less disables examples.
+func WithExampleBudget(tokens int) Option {
+	return func(g *Gobo) {
+		g.exampleBudget = tokens
+	}
+}
+
+// examplesFor collects the examples for a route within the token budget.
+func (g *Gobo) examplesFor(key string, route *routeSchema) []Example {
+	g.mu.RLock()
+	all := make([]Example, 0, len(route.Examples)+len(g.examples[key]))
+	all = append(all, route.Examples...)
+	all = append(all, g.examples[key]...)
+	budget := g.exampleBudget
+	g.mu.RUnlock()
+
+	all = append(all, g.recorder.examples(key)...)
+	return trimExamples(all, budget)
+}
+
+// trimExamples keeps examples, in order, while their estimated size fits
+// within budget tokens.
+func trimExamples(examples []Example, budget int) []Example {
+	var kept []Example
+	for _, ex := range examples {
+		cost := estimateTokens(formatExample(ex))
+		if cost > budget {
+			continue
+		}
+		budget -= cost
+		kept = append(kept, ex)
+	}
+	return kept
+}
+
+// estimateTokens approximates the token count of text at four characters
+// per token, which is close enough for budgeting prompts.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// formatExamples renders examples for an LLM prompt.
+func formatExamples(examples []Example) string {
+	if len(examples) == 0 {
+		return "No examples available."
+	}
+
+	var sb strings.Builder
+	for i, ex := range examples {
+		fmt.Fprintf(&sb, "Example %d:\n%s\n", i+1, formatExample(ex))
+	}
+	return sb.String()
+}
+
+// formatExample renders a single example as request line, optional body and
+// response.
+func formatExample(ex Example) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Request: %s %s\n", ex.Request.Method, ex.Request.URL)
+	if ex.Request.Body != "" {
+		fmt.Fprintf(&sb, "Request Body: %s\n", ex.Request.Body)
+	}
+	status := ex.Status
+	if status == 0 {
+		status = 200
+	}
+	fmt.Fprintf(&sb, "Response (%d): %s\n", status, string(ex.Response))
+	return sb.String()
+}
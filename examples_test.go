@@ -0,0 +1,94 @@
+package gobo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func example(response string) Example {
+	return Example{Request: RequestContext{Method: "GET", URL: "/users/1"}, Response: json.RawMessage(response)}
+}
+
+func TestAddExamples_ReachGenerator(t *testing.T) {
+	gen := &captureGenerator{}
+	g := New(WithGenerator(gen))
+	g.AddExamples("get", "/users/{id}", example(`{"id":"u_1"}`), example(`{"id":"u_2"}`))
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", g.Stub(map[string]any{"id": ""}))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/9", nil))
+
+	if len(gen.reqCtx.Examples) != 2 || string(gen.reqCtx.Examples[1].Response) != `{"id":"u_2"}` {
+		t.Errorf("Expected both examples, got %+v", gen.reqCtx.Examples)
+	}
+}
+
+func TestAddExamples_MethodlessPattern(t *testing.T) {
+	gen := &captureGenerator{}
+	g := New(WithGenerator(gen))
+	g.AddExamples("", "/users", example(`{"id":"u_1"}`))
+	g.SetPrompt("", "/users", PromptConfig{Context: "Use realistic names"})
+
+	mux := http.NewServeMux()
+	mux.Handle("/users", g.Stub(map[string]any{"id": ""}))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+
+	if len(gen.reqCtx.Examples) != 1 {
+		t.Errorf("Expected the example for the method-less pattern, got %+v", gen.reqCtx.Examples)
+	}
+	if gen.reqCtx.Prompt == nil || gen.reqCtx.Prompt.Context != "Use realistic names" {
+		t.Errorf("Expected the prompt for the method-less pattern, got %+v", gen.reqCtx.Prompt)
+	}
+}
+
+func TestTrimExamples(t *testing.T) {
+	small := example(`{"id":"a"}`)
+	large := example(`{"blob":"` + strings.Repeat("x", 400) + `"}`)
+	cost := estimateTokens(formatExample(small))
+
+	kept := trimExamples([]Example{small, large, small, small}, 2*cost)
+	if len(kept) != 2 {
+		t.Errorf("Expected two small examples within budget, got %d", len(kept))
+	}
+	if kept := trimExamples([]Example{small}, 0); len(kept) != 0 {
+		t.Errorf("Expected no examples with zero budget, got %d", len(kept))
+	}
+}
+
+func TestBuildPrompt_IncludesExamples(t *testing.T) {
+	c := NewOllamaGenerator("http://localhost:11434", "llama3")
+	prompt, err := c.buildPrompt(RequestContext{Method: "GET", URL: "/users/2", Examples: []Example{example(`{"id":"u_1"}`)}}, map[string]string{"id": ""})
+	if err != nil {
+		t.Fatalf("buildPrompt failed: %v", err)
+	}
+	if !strings.Contains(prompt, "=== Examples ===") || !strings.Contains(prompt, `Response (200): {"id":"u_1"}`) {
+		t.Errorf("Expected examples section in prompt, got:\n%s", prompt)
+	}
+	if strings.Count(prompt, "u_1") != 1 {
+		t.Error("Expected examples to be excluded from the request context dump")
+	}
+}
+
+func TestLoadExamplesFile_FromConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "users.json"), []byte(`[{"request":{"method":"GET","url":"/users/1"},"response":{"id":"from_file"}}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeConfig(t, dir, "routes:\n  - pattern: GET /users/{id}\n    examples_file: users.json\n    examples:\n      - request: {method: GET, url: /users/0}\n        response: {id: inline}\n")
+
+	gen := &captureGenerator{}
+	g := New(WithGenerator(gen))
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	g.Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/5", nil))
+
+	if len(gen.reqCtx.Examples) != 2 || string(gen.reqCtx.Examples[1].Response) != `{"id":"from_file"}` {
+		t.Errorf("Expected inline and file examples, got %+v", gen.reqCtx.Examples)
+	}
+}
@@ -0,0 +1,282 @@
+package gobo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BackendHeader is the response header naming the FallbackGenerator backend
+// that produced a response.
+const BackendHeader = "X-Gobo-Backend"
+
+const (
+	defaultFallbackFailures = 3
+	defaultFallbackCooldown = 30 * time.Second
+)
+
+// FallbackBackend is one generator in a FallbackGenerator chain.
+type FallbackBackend struct {
+	// Name identifies the backend in logs and the BackendHeader.
+	Name      string
+	Generator Generator
+	// Timeout bounds each call; zero leaves it to the request context.
+	Timeout time.Duration
+}
+
+// FallbackGenerator tries its backends in order and returns the first
+// successful response, so a mock keeps answering when a backend is down:
+//
+//	gen := gobo.NewFallbackGenerator([]gobo.FallbackBackend{
+//	    {Name: "agent", Generator: broker, Timeout: 10 * time.Second},
+//	    {Name: "ollama", Generator: gobo.NewOllamaGenerator(url, "llama3"), Timeout: 30 * time.Second},
+//	    {Name: "static", Generator: gobo.StaticGenerator{}},
+//	})
+//
+// Each backend has a circuit breaker: after a number of consecutive failures
+// it is skipped for a cooldown period, then given one trial request. The
+// backend that answered is reported in the BackendHeader response header.
+type FallbackGenerator struct {
+	backends []FallbackBackend
+	failures int
+	cooldown time.Duration
+
+	mu       sync.Mutex
+	breakers []breaker
+}
+
+// breaker is the circuit state of a single backend.
+type breaker struct {
+	failures  int       // consecutive failures
+	openUntil time.Time // skipped until then
+	trial     bool      // a trial request is in flight after the cooldown
+}
+
+// FallbackOption configures a FallbackGenerator.
+type FallbackOption func(*FallbackGenerator)
+
+// FallbackFailures sets how many consecutive failures open a backend's
+// circuit (default 3).
+func FallbackFailures(n int) FallbackOption {
+	return func(f *FallbackGenerator) {
+		f.failures = n
+	}
+}
+
+// FallbackCooldown sets how long an open circuit skips its backend
+// (default 30s).
+func FallbackCooldown(d time.Duration) FallbackOption {
+	return func(f *FallbackGenerator) {
+		f.cooldown = d
+	}
+}
+
+// NewFallbackGenerator chains backends, tried in the order given.
+func NewFallbackGenerator(backends []FallbackBackend, opts ...FallbackOption) *FallbackGenerator {
+	f := &FallbackGenerator{
+		backends: backends,
+		failures: defaultFallbackFailures,
+		cooldown: defaultFallbackCooldown,
+		breakers: make([]breaker, len(backends)),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// GenerateResponse implements the Generator interface.
+func (f *FallbackGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	var errs []error
+	for i, b := range f.backends {
+		if !f.allow(i) {
+			errs = append(errs, fmt.Errorf("%s: circuit open", b.Name))
+			continue
+		}
+
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if b.Timeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, b.Timeout)
+		}
+		out, err := b.Generator.GenerateResponse(callCtx, reqCtx, schema)
+		cancel()
+
+		if err == nil {
+			f.record(i, true)
+			setBackend(ctx, b.Name)
+			return out, nil
+		}
+		if ctx.Err() != nil {
+			// The client went away; that says nothing about the backend
+			f.release(i)
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrOverloaded) {
+			// Shed by a limiter in front of a healthy backend
+			f.release(i)
+		} else {
+			f.record(i, false)
+		}
+		if errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", b.Timeout)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+	}
+	return nil, &fallbackError{errs}
+}
+
+// GenerateStream implements the StreamGenerator interface. Backends are
+// tried in order until one starts streaming; once an event was sent the
+// stream stays with that backend. Timeouts bound the wait for the first
+// event.
+func (f *FallbackGenerator) GenerateStream(ctx context.Context, reqCtx RequestContext, schema any, send func(Event) error) error {
+	var errs []error
+	for i, b := range f.backends {
+		if !f.allow(i) {
+			errs = append(errs, fmt.Errorf("%s: circuit open", b.Name))
+			continue
+		}
+
+		callCtx, cancel := context.WithCancel(ctx)
+		var timedOut atomic.Bool
+		stop := func() bool { return false }
+		if b.Timeout > 0 {
+			stop = time.AfterFunc(b.Timeout, func() {
+				timedOut.Store(true)
+				cancel()
+			}).Stop
+		}
+		started := false
+		err := streamFrom(callCtx, b.Generator, reqCtx, schema, func(e Event) error {
+			if !started {
+				started = true
+				stop()
+			}
+			return send(e)
+		})
+		stop()
+		cancel()
+
+		if err == nil || started {
+			f.record(i, true)
+			return err
+		}
+		if ctx.Err() != nil {
+			f.release(i)
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrOverloaded) {
+			f.release(i)
+		} else {
+			f.record(i, false)
+		}
+		if timedOut.Load() {
+			err = fmt.Errorf("timed out after %s", b.Timeout)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+	}
+	return &fallbackError{errs}
+}
+
+// fallbackError reports the failure of every backend. errors.Is sees each
+// backend's error, so a response shed by every backend is still
+// ErrOverloaded.
+type fallbackError struct {
+	errs []error
+}
+
+func (e *fallbackError) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return "all backends failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *fallbackError) Unwrap() []error {
+	return e.errs
+}
+
+// Unwrap returns the backend generators, see GeneratorWrapper.
+func (f *FallbackGenerator) Unwrap() []Generator {
+	gens := make([]Generator, len(f.backends))
+	for i, b := range f.backends {
+		gens[i] = b.Generator
+	}
+	return gens
+}
+
+// HealthCheck checks every backend, naming the ones that fail, see
+// HealthChecker.
+func (f *FallbackGenerator) HealthCheck(ctx context.Context) error {
+	var errs []error
+	for _, b := range f.backends {
+		if err := checkWrapped(ctx, b.Generator); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// allow reports whether backend i may be called, claiming the trial request
+// when its cooldown has passed.
+func (f *FallbackGenerator) allow(i int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b := &f.breakers[i]
+	if b.failures < f.failures {
+		return true
+	}
+	if b.trial || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record updates backend i's circuit after a call.
+func (f *FallbackGenerator) record(i int, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b := &f.breakers[i]
+	b.trial = false
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= f.failures {
+		b.openUntil = time.Now().Add(f.cooldown)
+	}
+}
+
+// release gives up a trial claimed by allow without recording an outcome.
+func (f *FallbackGenerator) release(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.breakers[i].trial = false
+}
+
+// backendKey carries the name of the backend that produced a response from
+// a FallbackGenerator back to the handler.
+type backendKey struct{}
+
+// withBackend returns a context in which setBackend can report a backend.
+func withBackend(ctx context.Context) (context.Context, *string) {
+	name := new(string)
+	return context.WithValue(ctx, backendKey{}, name), name
+}
+
+// setBackend reports the backend that produced the response. With nested
+// fallbacks the innermost backend wins.
+func setBackend(ctx context.Context, name string) {
+	if p, ok := ctx.Value(backendKey{}).(*string); ok && *p == "" {
+		*p = name
+	}
+}
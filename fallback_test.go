@@ -0,0 +1,155 @@
+package gobo
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyGenerator fails while down is set, counting calls.
+type flakyGenerator struct {
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+func (f *flakyGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	f.calls.Add(1)
+	if f.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+	return []byte(`{"from":"flaky"}`), nil
+}
+
+// slowGenerator blocks until its context is done.
+type slowGenerator struct{}
+
+func (slowGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestFallbackGenerator_Order(t *testing.T) {
+	flaky := &flakyGenerator{}
+	flaky.down.Store(true)
+	f := NewFallbackGenerator([]FallbackBackend{
+		{Name: "slow", Generator: slowGenerator{}, Timeout: 10 * time.Millisecond},
+		{Name: "flaky", Generator: flaky},
+		{Name: "static", Generator: StaticGenerator{}},
+	})
+
+	g := New(WithGenerator(f))
+	rec := httptest.NewRecorder()
+	g.Stub(map[string]any{"from": "static"}).ServeHTTP(rec, httptest.NewRequest("GET", "/x", nil))
+
+	if rec.Body.String() != `{"from":"static"}` {
+		t.Errorf("Expected static fallback, got %q", rec.Body.String())
+	}
+	if got := rec.Header().Get(BackendHeader); got != "static" {
+		t.Errorf("Expected backend header static, got %q", got)
+	}
+}
+
+func TestFallbackGenerator_CircuitBreaker(t *testing.T) {
+	flaky := &flakyGenerator{}
+	flaky.down.Store(true)
+	f := NewFallbackGenerator([]FallbackBackend{
+		{Name: "flaky", Generator: flaky},
+		{Name: "static", Generator: StaticGenerator{}},
+	}, FallbackFailures(2), FallbackCooldown(50*time.Millisecond))
+
+	ctx := context.Background()
+	for range 5 {
+		if _, err := f.GenerateResponse(ctx, RequestContext{}, map[string]any{}); err != nil {
+			t.Fatalf("GenerateResponse failed: %v", err)
+		}
+	}
+	if n := flaky.calls.Load(); n != 2 {
+		t.Errorf("Expected the circuit to open after 2 failures, got %d calls", n)
+	}
+
+	// After the cooldown a trial request goes through and closes the circuit
+	flaky.down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	ctx, backend := withBackend(context.Background())
+	out, err := f.GenerateResponse(ctx, RequestContext{}, map[string]any{})
+	if err != nil || string(out) != `{"from":"flaky"}` || *backend != "flaky" {
+		t.Errorf("Expected flaky to recover, got %s, %q, %v", out, *backend, err)
+	}
+}
+
+func TestFallbackGenerator_AllFail(t *testing.T) {
+	flaky := &flakyGenerator{}
+	flaky.down.Store(true)
+	f := NewFallbackGenerator([]FallbackBackend{
+		{Name: "slow", Generator: slowGenerator{}, Timeout: time.Millisecond},
+		{Name: "flaky", Generator: flaky},
+	})
+
+	_, err := f.GenerateResponse(context.Background(), RequestContext{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "slow: timed out") || !strings.Contains(err.Error(), "flaky: connection refused") {
+		t.Errorf("Expected errors from every backend, got %v", err)
+	}
+}
+
+func TestFallbackGenerator_ClientCancel(t *testing.T) {
+	f := NewFallbackGenerator([]FallbackBackend{
+		{Name: "slow", Generator: slowGenerator{}},
+		{Name: "static", Generator: StaticGenerator{}},
+	}, FallbackFailures(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.GenerateResponse(ctx, RequestContext{}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if !f.allow(0) {
+		t.Error("Expected a client cancellation not to open the circuit")
+	}
+}
+
+// unhealthyGenerator fails its health check.
+type unhealthyGenerator struct{ mockGenerator }
+
+func (unhealthyGenerator) HealthCheck(ctx context.Context) error {
+	return errors.New("model not pulled")
+}
+
+func TestWrappers_HealthCheck(t *testing.T) {
+	gen := NewCachingGenerator(NewFallbackGenerator([]FallbackBackend{
+		{Name: "ollama", Generator: NewLimitingGenerator(&unhealthyGenerator{}, LimitConfig{})},
+		{Name: "static", Generator: StaticGenerator{}},
+	}), CacheConfig{})
+	err := gen.HealthCheck(context.Background())
+	if err == nil || err.Error() != "ollama: model not pulled" {
+		t.Errorf("Expected the wrapped backend to be checked, got %v", err)
+	}
+	if err := NewCachingGenerator(StaticGenerator{}, CacheConfig{}).HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected no error without checkers, got %v", err)
+	}
+}
+
+// sheddingGenerator rejects every request like a full LimitingGenerator.
+type sheddingGenerator struct{}
+
+func (sheddingGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	return nil, ErrOverloaded
+}
+
+func TestFallback_Overloaded(t *testing.T) {
+	f := NewFallbackGenerator([]FallbackBackend{{Name: "ollama", Generator: sheddingGenerator{}}}, FallbackFailures(1))
+	g := New(WithGenerator(f))
+	for range 2 {
+		rec := httptest.NewRecorder()
+		g.Stub(map[string]any{}).ServeHTTP(rec, httptest.NewRequest("GET", "/x", nil))
+		if rec.Code != 503 || rec.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected 503 with Retry-After, got %d", rec.Code)
+		}
+	}
+	if _, err := f.GenerateResponse(context.Background(), RequestContext{}, nil); strings.Contains(err.Error(), "circuit open") {
+		t.Errorf("Expected shedding not to open the circuit, got %v", err)
+	}
+}
@@ -0,0 +1,441 @@
+package gobo
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Format encodes generated responses for a content type. Generators keep
+// producing JSON shaped by the route schema; Encode turns it into the wire
+// format. Output that is not JSON (say, XML submitted by an agent) is
+// validated for the format and passed through.
+type Format struct {
+	// Name identifies the format in configuration files, such as "xml".
+	Name string
+	// ContentType is sent as the Content-Type header and matched against
+	// the request's Accept header.
+	ContentType string
+	// Encode converts the generated output for schema into the format.
+	Encode func(generated []byte, schema any) ([]byte, error)
+}
+
+// Built-in formats.
+var (
+	// FormatJSON is the default: generated JSON is served as-is.
+	FormatJSON = Format{Name: "json", ContentType: "application/json", Encode: encodeJSON}
+	// FormatXML re-encodes generated JSON as XML, honoring the xml tags of
+	// struct schemas. Other schemas are encoded under a <response> root.
+	FormatXML = Format{Name: "xml", ContentType: "application/xml", Encode: encodeXML}
+	// FormatText serves text; a generated JSON string is unwrapped.
+	FormatText = Format{Name: "text", ContentType: "text/plain; charset=utf-8", Encode: encodeText}
+	// FormatCSV writes a generated JSON array of objects as CSV with a
+	// header row, in struct field order for struct schemas.
+	FormatCSV = Format{Name: "csv", ContentType: "text/csv; charset=utf-8", Encode: encodeCSV}
+	// FormatForm writes a generated JSON object as
+	// application/x-www-form-urlencoded.
+	FormatForm = Format{Name: "form", ContentType: "application/x-www-form-urlencoded", Encode: encodeForm}
+)
+
+// formatsByName maps configuration file names to built-in formats.
+var formatsByName = map[string]Format{
+	"json": FormatJSON,
+	"xml":  FormatXML,
+	"text": FormatText,
+	"csv":  FormatCSV,
+	"form": FormatForm,
+}
+
+// formatKey carries the format a response is generated for, so generators
+// serving raw files know whether the output must be JSON.
+type formatKey struct{}
+
+// withFormat returns a context generating a response for format.
+func withFormat(ctx context.Context, format Format) context.Context {
+	return context.WithValue(ctx, formatKey{}, format)
+}
+
+// wantsJSON reports whether output generated in ctx must be JSON: always,
+// except for a response in another format. Streams, WebSocket messages,
+// caches and prefetch pools all expect JSON.
+func wantsJSON(ctx context.Context) bool {
+	format, ok := ctx.Value(formatKey{}).(Format)
+	return !ok || format.Name == FormatJSON.Name
+}
+
+// RouteFormat sets the formats a route can answer with. The first is the
+// default; when there are several, the request's Accept header picks one.
+//
+//	mux.Handle("GET /rates", g.Stub([]Rate{}, gobo.RouteFormat(gobo.FormatCSV, gobo.FormatJSON)))
+func RouteFormat(formats ...Format) RouteOption {
+	return func(r *routeSchema) {
+		r.Formats = formats
+	}
+}
+
+// negotiateFormat picks the route format the client accepts most, by
+// q-value and then route order. It falls back to the first format when
+// nothing matches, and to JSON when the route lists none.
+func negotiateFormat(formats []Format, accept string) Format {
+	if len(formats) == 0 {
+		return FormatJSON
+	}
+	if len(formats) == 1 || accept == "" {
+		return formats[0]
+	}
+
+	best, bestQ := 0, -1.0
+	for i, f := range formats {
+		want, _, _ := mime.ParseMediaType(f.ContentType)
+		if q := acceptQuality(accept, want); q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	if bestQ <= 0 {
+		return formats[0]
+	}
+	return formats[best]
+}
+
+// acceptQuality returns the q-value the Accept header gives mediaType, from
+// its most specific matching range, or 0 when none matches.
+func acceptQuality(accept, mediaType string) float64 {
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		rng, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		s := -1
+		switch {
+		case rng == mediaType:
+			s = 2
+		case strings.HasSuffix(rng, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(rng, "*")):
+			s = 1
+		case rng == "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, q = s, 1
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+	return q
+}
+
+func encodeJSON(generated []byte, schema any) ([]byte, error) {
+	if !json.Valid(generated) {
+		return nil, errors.New("response is not valid JSON")
+	}
+	return generated, nil
+}
+
+func encodeText(generated []byte, schema any) ([]byte, error) {
+	var s string
+	if json.Unmarshal(generated, &s) == nil {
+		return []byte(s), nil
+	}
+	if !utf8.Valid(generated) {
+		return nil, errors.New("response is not valid UTF-8 text")
+	}
+	return generated, nil
+}
+
+func encodeXML(generated []byte, schema any) ([]byte, error) {
+	if !json.Valid(generated) {
+		if err := checkXML(generated); err != nil {
+			return nil, fmt.Errorf("response is neither JSON nor well-formed XML: %w", err)
+		}
+		return generated, nil
+	}
+
+	schema = bareSchema(schema)
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if structType(schema) != nil {
+		target := reflect.New(reflect.TypeOf(schema))
+		if err := json.Unmarshal(generated, target.Interface()); err != nil {
+			return nil, fmt.Errorf("failed to decode generated response: %w", err)
+		}
+		out, err := xml.Marshal(target.Elem().Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode xml: %w", err)
+		}
+		if reflect.TypeOf(schema).Kind() == reflect.Slice {
+			// A bare list of elements has no root; give it one
+			buf.WriteString("<response>")
+			buf.Write(out)
+			buf.WriteString("</response>")
+		} else {
+			buf.Write(out)
+		}
+		return buf.Bytes(), nil
+	}
+
+	var value any
+	if err := json.Unmarshal(generated, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode generated response: %w", err)
+	}
+	enc := xml.NewEncoder(&buf)
+	if err := writeXMLValue(enc, "response", value); err != nil {
+		return nil, fmt.Errorf("failed to encode xml: %w", err)
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeXMLValue encodes a decoded JSON value as an element. Object keys
+// become child elements in sorted order and array entries repeat <item>.
+func writeXMLValue(enc *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := writeXMLValue(enc, k, v[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := writeXMLValue(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(scalarText(v))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlName turns a JSON key into a valid XML element name.
+func xmlName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		valid := unicode.IsLetter(r) || r == '_' || (i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'))
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// checkXML reports whether data is a well-formed XML document.
+func checkXML(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	root := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			root = true
+		}
+	}
+	if !root {
+		return errors.New("no root element")
+	}
+	return nil
+}
+
+func encodeCSV(generated []byte, schema any) ([]byte, error) {
+	var value any
+	if err := json.Unmarshal(generated, &value); err != nil {
+		// Raw CSV, say from an agent: check it parses with consistent rows
+		if _, err := csv.NewReader(bytes.NewReader(generated)).ReadAll(); err != nil {
+			return nil, fmt.Errorf("response is neither JSON nor valid CSV: %w", err)
+		}
+		return generated, nil
+	}
+
+	var rows []any
+	switch v := value.(type) {
+	case []any:
+		rows = v
+	case map[string]any:
+		rows = []any{v}
+	default:
+		return nil, fmt.Errorf("csv responses must be a JSON array, got %T", value)
+	}
+
+	var records [][]string
+	if len(rows) > 0 {
+		if _, isList := rows[0].([]any); isList {
+			for _, row := range rows {
+				cells, _ := row.([]any)
+				record := make([]string, len(cells))
+				for i, c := range cells {
+					record[i] = scalarText(c)
+				}
+				records = append(records, record)
+			}
+			return writeCSV(records)
+		}
+	}
+
+	columns := jsonFieldNames(structType(bareSchema(schema)))
+	if columns == nil {
+		seen := map[string]bool{}
+		for _, row := range rows {
+			obj, _ := row.(map[string]any)
+			for k := range obj {
+				if !seen[k] {
+					seen[k] = true
+					columns = append(columns, k)
+				}
+			}
+		}
+		sort.Strings(columns)
+	}
+	records = append(records, columns)
+	for _, row := range rows {
+		obj, ok := row.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("csv rows must be JSON objects, got %T", row)
+		}
+		record := make([]string, len(columns))
+		for i, col := range columns {
+			record[i] = scalarText(obj[col])
+		}
+		records = append(records, record)
+	}
+	return writeCSV(records)
+}
+
+func writeCSV(records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to encode csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeForm(generated []byte, schema any) ([]byte, error) {
+	var value any
+	if err := json.Unmarshal(generated, &value); err != nil {
+		if _, err := url.ParseQuery(string(generated)); err != nil {
+			return nil, fmt.Errorf("response is neither JSON nor form-encoded: %w", err)
+		}
+		return generated, nil
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("form responses must be a JSON object, got %T", value)
+	}
+	values := url.Values{}
+	for k, v := range obj {
+		if list, isList := v.([]any); isList {
+			for _, item := range list {
+				values.Add(k, scalarText(item))
+			}
+			continue
+		}
+		values.Set(k, scalarText(v))
+	}
+	return []byte(values.Encode()), nil
+}
+
+// scalarText renders a decoded JSON value as text; objects and arrays stay
+// JSON.
+func scalarText(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(s)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+// bareSchema unwraps an Annotated schema.
+func bareSchema(schema any) any {
+	if a, ok := schema.(Annotated); ok {
+		return a.Schema
+	}
+	return schema
+}
+
+// structType returns the struct type behind a schema, looking through
+// pointers and slices, or nil when the schema is not a Go struct.
+func structType(schema any) reflect.Type {
+	if schema == nil {
+		return nil
+	}
+	t := reflect.TypeOf(schema)
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// jsonFieldNames lists the JSON names of a struct's exported fields in
+// declaration order.
+func jsonFieldNames(t reflect.Type) []string {
+	if t == nil {
+		return nil
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
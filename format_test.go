@@ -0,0 +1,159 @@
+package gobo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type xmlRate struct {
+	Currency string  `json:"currency" xml:"currency,attr"`
+	Rate     float64 `json:"rate" xml:"value"`
+}
+
+func TestFormat_XMLFromStruct(t *testing.T) {
+	g := New(WithGenerator(&mockGenerator{Response: []byte(`{"currency":"EUR","rate":1.1}`)}))
+	rec := httptest.NewRecorder()
+	g.Stub(xmlRate{}, RouteFormat(FormatXML)).ServeHTTP(rec, httptest.NewRequest("GET", "/rate", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Expected application/xml, got %q", ct)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<xmlRate currency="EUR"><value>1.1</value></xmlRate>`
+	if rec.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, rec.Body.String())
+	}
+}
+
+func TestFormat_XMLFromSlice(t *testing.T) {
+	g := New(WithGenerator(&mockGenerator{Response: []byte(`[{"currency":"EUR","rate":1.1},{"currency":"GBP","rate":0.9}]`)}))
+	rec := httptest.NewRecorder()
+	g.Stub([]xmlRate{}, RouteFormat(FormatXML)).ServeHTTP(rec, httptest.NewRequest("GET", "/rates", nil))
+
+	want := `<response><xmlRate currency="EUR"><value>1.1</value></xmlRate><xmlRate currency="GBP"><value>0.9</value></xmlRate></response>`
+	if !strings.HasSuffix(rec.Body.String(), want) {
+		t.Errorf("Expected %q, got %q", want, rec.Body.String())
+	}
+}
+
+func TestFormat_XMLGeneric(t *testing.T) {
+	out, err := FormatXML.Encode([]byte(`{"b":[1,2],"a":{"x y":"<hi>"}}`), JSONSchema{"type": "object"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<response><a><x_y>&lt;hi&gt;</x_y></a><b><item>1</item><item>2</item></b></response>`
+	if !strings.HasSuffix(string(out), want) {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+
+	if _, err := FormatXML.Encode([]byte(`<ok/>`), nil); err != nil {
+		t.Errorf("Expected raw XML to pass through, got %v", err)
+	}
+	if _, err := FormatXML.Encode([]byte(`<broken>`), nil); err == nil {
+		t.Error("Expected malformed XML to be rejected")
+	}
+}
+
+func TestFormat_CSV(t *testing.T) {
+	out, err := FormatCSV.Encode([]byte(`[{"rate":1.5,"currency":"EUR"},{"currency":"a,b","rate":2}]`), []xmlRate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "currency,rate\nEUR,1.5\n\"a,b\",2\n"; string(out) != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+
+	if _, err := FormatCSV.Encode([]byte("a,b\n1,2\n"), nil); err != nil {
+		t.Errorf("Expected raw CSV to pass through, got %v", err)
+	}
+	if _, err := FormatCSV.Encode([]byte("a,b\n1\n"), nil); err == nil {
+		t.Error("Expected ragged CSV to be rejected")
+	}
+}
+
+func TestFormat_TextAndForm(t *testing.T) {
+	if out, _ := FormatText.Encode([]byte(`"hello"`), nil); string(out) != "hello" {
+		t.Errorf("Expected the JSON string to be unwrapped, got %q", out)
+	}
+	out, err := FormatForm.Encode([]byte(`{"b":"x y","a":[1,2]}`), nil)
+	if err != nil || string(out) != "a=1&a=2&b=x+y" {
+		t.Errorf("Unexpected form encoding %q (%v)", out, err)
+	}
+	if _, err := FormatForm.Encode([]byte("a=%zz"), nil); err == nil {
+		t.Error("Expected invalid form data to be rejected")
+	}
+}
+
+func TestFormat_Negotiation(t *testing.T) {
+	g := New()
+	stub := g.Stub([]xmlRate{{Currency: "EUR", Rate: 1}}, RouteFormat(FormatJSON, FormatCSV, FormatXML))
+
+	tests := []struct {
+		accept, want string
+	}{
+		{"", "application/json"},
+		{"text/csv", "text/csv; charset=utf-8"},
+		{"application/xml;q=0.9, text/csv;q=0.5", "application/xml"},
+		{"text/*", "text/csv; charset=utf-8"},
+		{"image/png", "application/json"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/rates", nil)
+		req.Header.Set("Accept", tt.accept)
+		rec := httptest.NewRecorder()
+		stub.ServeHTTP(rec, req)
+		if ct := rec.Header().Get("Content-Type"); ct != tt.want {
+			t.Errorf("Accept %q: expected %q, got %q", tt.accept, tt.want, ct)
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: expected Vary: Accept", tt.accept)
+		}
+	}
+}
+
+func TestFormat_InvalidOutput(t *testing.T) {
+	g := New(WithGenerator(&mockGenerator{Response: []byte(`not json`)}))
+	rec := httptest.NewRecorder()
+	g.Stub(map[string]any{}).ServeHTTP(rec, httptest.NewRequest("GET", "/x", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for invalid JSON, got %d", rec.Code)
+	}
+}
+
+func TestFormat_RawFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.xml")
+	if err := os.WriteFile(path, []byte(`<rates/>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := New(WithGenerator(FixtureGenerator{Path: path}))
+	rec := httptest.NewRecorder()
+	g.Stub(nil, RouteFormat(FormatXML)).ServeHTTP(rec, httptest.NewRequest("GET", "/rates", nil))
+	if !strings.HasSuffix(rec.Body.String(), `<rates/>`) {
+		t.Errorf("Expected the XML fixture, got %d %q", rec.Code, rec.Body.String())
+	}
+	if _, err := (FixtureGenerator{Path: path}).GenerateResponse(context.Background(), RequestContext{}, nil); err == nil {
+		t.Error("Expected the fixture to be rejected outside an XML response")
+	}
+}
+
+func TestFormat_ConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gobo.yaml")
+	config := "routes:\n  - pattern: GET /report\n    formats: [csv]\n    template: \"id,name\\n1,Ada\\n\"\n"
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := New()
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	g.Middleware(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "/report", nil))
+	if rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" || rec.Body.String() != "id,name\n1,Ada\n" {
+		t.Errorf("Unexpected response %q %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
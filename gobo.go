@@ -1,11 +1,13 @@
 package gobo
 
 import (
-	"bytes"
 	"context"
-	"io"
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 )
 
 // Generator defines the interface for an AI model that can generate fake data from a schema.
@@ -28,6 +30,47 @@ type Config struct {
 	Debug bool
 }
 
+// HealthChecker is implemented by generators that can verify their backend
+// is ready, such as OllamaGenerator checking that its model is pulled.
+// Wrapping generators delegate to the generators they wrap.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// GeneratorWrapper is implemented by generators that delegate to others,
+// such as CachingGenerator, so Gobo can reach the generators they wrap:
+// a wrapped AsyncBroker is never prefetched, for instance.
+type GeneratorWrapper interface {
+	Unwrap() []Generator
+}
+
+// leafGenerators returns the generators gen delegates to through any
+// GeneratorWrapper, or gen itself.
+func leafGenerators(gen Generator) []Generator {
+	w, ok := gen.(GeneratorWrapper)
+	if !ok {
+		return []Generator{gen}
+	}
+	var leaves []Generator
+	for _, inner := range w.Unwrap() {
+		if inner != nil {
+			leaves = append(leaves, leafGenerators(inner)...)
+		}
+	}
+	return leaves
+}
+
+// checkWrapped runs the health checks of the generators a wrapper wraps.
+func checkWrapped(ctx context.Context, gens ...Generator) error {
+	var errs []error
+	for _, gen := range gens {
+		if hc, ok := gen.(HealthChecker); ok {
+			errs = append(errs, hc.HealthCheck(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Option is a functional option for configuring a Gobo instance.
 type Option func(*Gobo)
 
@@ -47,11 +90,13 @@ func WithConfig(cfg Config) Option {
 }
 
 // WithOllama configures Gobo to use a local Ollama instance.
-func WithOllama(url, model string) Option {
+//
+//	gobo.WithOllama("http://localhost:11434", "llama3", gobo.OllamaChat(), gobo.OllamaSeed(42))
+func WithOllama(url, model string, opts ...OllamaOption) Option {
 	return func(g *Gobo) {
 		g.config.OllamaURL = url
 		g.config.Model = model
-		g.client = NewOllamaGenerator(url, model)
+		g.client = NewOllamaGenerator(url, model, opts...)
 	}
 }
 
@@ -72,22 +117,94 @@ func WithGenerator(gen Generator) Option {
 
 // Gobo is the core struct that holds the configuration and registered schemas.
 type Gobo struct {
+	mu     sync.RWMutex // guards config, client and fileRoutes against config file reloads
 	config Config
 	routes []*routeSchema
 	client Generator
+
+	// fileRoutes are the routes declared in the mock configuration file.
+	// They are replaced wholesale on every reload and take precedence over routes.
+	fileRoutes []*routeSchema
+	// fileClient and fileDebug are the generator and debug setting of the
+	// configuration file. They are kept apart from client and config, so a
+	// reload without them restores the settings made in code.
+	fileClient Generator
+	fileDebug  bool
+
+	// handlerRoutes records Stub and Intercept handlers by their ServeMux
+	// pattern, from Handle or the first time each handler serves a request.
+	handlerRoutes map[string]*routeSchema
+
+	errorEnvelope ErrorEnvelope // renders validation errors; nil uses DefaultErrorEnvelope
+
+	drift *driftTracker // schema drift observed by Shadow
+
+	recorder *recorder // record-from-upstream mode; nil when disabled
+
+	examples      map[string][]Example // added with AddExamples, keyed like routeKey
+	exampleBudget int                  // approximate token budget for examples per request
+
+	prefetch *prefetcher // pools of pre-generated responses, see WithPrefetch
+
+	prompt     PromptConfig            // instance-wide prompt customizations
+	filePrompt PromptConfig            // top-level prompt from the config file
+	prompts    map[string]PromptConfig // set with SetPrompt, keyed like routeKey
+
+	sockets *socketHub // open WebSocket connections, see WebSocket
+
+	webhooks *webhookLog // recent webhook deliveries, see RouteWebhook
+
+	maxBody int64 // request body capture limit, see WithMaxBodyCapture
 }
 
 // routeSchema stores an expected schema for a specific HTTP method and path pattern.
 type routeSchema struct {
 	Method         string
-	PathPrefix     string // simple prefix matching for now
+	PathPrefix     string // prefix match, or a ServeMux-style pattern when it contains wildcards
 	ResponseSchema any    // raw Go struct to be marshaled into a JSON schema
+
+	Status    int               // response status code; 0 means 200
+	Headers   map[string]string // extra response headers
+	Latency   time.Duration     // artificial delay before the response is written
+	Generator Generator         // route-specific generator; nil falls back to the instance generator
+
+	Request *RequestSpec // validates incoming requests when set
+
+	Examples []Example     // few-shot examples for generators
+	Prompt   *PromptConfig // prompt customizations for LLM generators
+
+	Stream      bool          // answer with text/event-stream, see RouteStream
+	StreamDelay time.Duration // default delay between streamed events
+
+	Script []ScriptedMessage // server-initiated WebSocket messages, see RouteScript
+
+	Formats []Format // response formats, negotiated by Accept; nil means JSON
+
+	Artifact Artifact // serves a file instead of a generated body, see Download
+
+	GraphQL *gqlSchema // answers GraphQL operations, see GraphQL
+
+	RPC map[string]RPCMethod // answers JSON-RPC calls by method, see JSONRPC
+
+	Collection *collection // serves pages of a list of items, see Collection
+
+	Webhooks []Webhook // callbacks sent after responding, see RouteWebhook
+
+	GRPC *grpcService // answers gRPC unary calls, see GRPC
 }
 
 // New creates a new Gobo instance with functional options.
 func New(opts ...Option) *Gobo {
 	g := &Gobo{
-		routes: make([]*routeSchema, 0),
+		routes:        make([]*routeSchema, 0),
+		handlerRoutes: make(map[string]*routeSchema),
+		drift:         newDriftTracker(),
+		examples:      make(map[string][]Example),
+		exampleBudget: defaultExampleBudget,
+		prompts:       make(map[string]PromptConfig),
+		sockets:       newSocketHub(),
+		webhooks:      newWebhookLog(),
+		maxBody:       defaultMaxBodyCapture,
 	}
 
 	for _, opt := range opts {
@@ -97,34 +214,63 @@ func New(opts ...Option) *Gobo {
 	return g
 }
 
-// SetGenerator replaces the current generator. This is used by external
+// SetGenerator replaces the current generator, including one set by the
+// configuration file until the file is reloaded. This is used by external
 // packages (like gobo/mcp) that need to wire in a generator after construction.
 func (g *Gobo) SetGenerator(gen Generator) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	g.config.Generator = gen
 	g.client = gen
+	g.fileClient = nil
 }
 
-// logf logs messages if Debug is enabled.
+// generator returns the generator that should answer the given route: the
+// route's own generator when set, otherwise the instance generator, which
+// the configuration file may override.
+func (g *Gobo) generator(route *routeSchema) Generator {
+	if route != nil && route.Generator != nil {
+		return route.Generator
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.fileClient != nil {
+		return g.fileClient
+	}
+	return g.client
+}
+
+// logf logs messages if Debug is enabled in code or the configuration file.
 func (g *Gobo) logf(format string, args ...any) {
-	if g.config.Debug {
+	g.mu.RLock()
+	debug := g.config.Debug || g.fileDebug
+	g.mu.RUnlock()
+	if debug {
 		log.Printf("[Gobo] "+format, args...)
 	}
 }
 
 // Middleware returns a standard net/http middleware that intercepts requests
 // matching registered schemas via Register(). Unmatched routes pass through.
+// Requests under AdminPrefix are answered by AdminHandler.
 func (g *Gobo) Middleware(next http.Handler) http.Handler {
+	admin := g.AdminHandler()
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		schema := g.match(r)
+		if isAdminPath(r) {
+			admin.ServeHTTP(w, r)
+			return
+		}
 
-		if schema == nil {
+		route := g.match(r)
+
+		if route == nil {
 			g.logf("No schema matched for %s %s. Passing to next handler.", r.Method, r.URL.Path)
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		g.logf("Intercepted %s %s (matched %s)", r.Method, r.URL.Path, schema.PathPrefix)
-		g.generateAndWrite(w, r, schema.ResponseSchema)
+		g.logf("Intercepted %s %s (matched %s)", r.Method, r.URL.Path, route.PathPrefix)
+		g.generateAndWrite(w, r, route, next)
 	})
 }
 
@@ -134,23 +280,43 @@ type RequestContext struct {
 	URL     string              `json:"url"`
 	Headers map[string][]string `json:"headers"`
 	Body    string              `json:"body,omitempty"`
+	// Validation is the outcome of checking the request against the route's
+	// RequestSpec, when it has one.
+	Validation *ValidationResult `json:"validation,omitempty"`
+	// Examples are request/response pairs for the route, from code, files
+	// or recorded traffic, for grounding generated responses. They are
+	// trimmed to the instance's example token budget.
+	Examples []Example `json:"-"`
+	// Prompt carries the instance and route prompt customizations for LLM
+	// generators; nil means the default prompt.
+	Prompt *PromptConfig `json:"-"`
+	// Socket is the id of the WebSocket a message arrived on, for sending
+	// follow-up messages with SendSocketMessage.
+	Socket string `json:"socket,omitempty"`
+	// Form holds the fields of urlencoded and multipart bodies.
+	Form map[string][]string `json:"form,omitempty"`
+	// Files summarizes multipart file uploads; their contents are not
+	// captured in Body.
+	Files []FileSummary `json:"files,omitempty"`
+	// BodyTruncated reports that Body (or Form) was cut at the capture
+	// limit, see WithMaxBodyCapture.
+	BodyTruncated bool `json:"body_truncated,omitempty"`
+	// GraphQL is the operation of a request to a GraphQL route.
+	GraphQL *GraphQLRequest `json:"graphql,omitempty"`
+	// RPC is the call of a request to a JSON-RPC route.
+	RPC *RPCCall `json:"rpc,omitempty"`
+	// Item is set while generating the items of a collection route.
+	Item *CollectionItem `json:"item,omitempty"`
+	// Webhook is set while generating the payload of a webhook.
+	Webhook *WebhookEvent `json:"webhook,omitempty"`
+	// GRPC is the call of a request to a gRPC route; Body then holds the
+	// request message as JSON.
+	GRPC *GRPCCall `json:"grpc,omitempty"`
 }
 
-// extractRequestContext pulls relevant info from an http.Request.
-func extractRequestContext(r *http.Request) RequestContext {
-	ctx := RequestContext{
-		Method:  r.Method,
-		URL:     r.URL.String(),
-		Headers: r.Header,
-	}
-
-	if r.Body != nil {
-		bodyBytes, err := io.ReadAll(r.Body)
-		if err == nil && len(bodyBytes) > 0 {
-			ctx.Body = string(bodyBytes)
-		}
-		// restore body so it could potentially be read downstream if needed, though Gobo ends the request.
-		r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-	}
-	return ctx
+// Example is a request/response pair illustrating what a route returns.
+type Example struct {
+	Request  RequestContext  `json:"request"`
+	Status   int             `json:"status,omitempty"`
+	Response json.RawMessage `json:"response"`
 }
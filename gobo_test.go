@@ -62,7 +62,7 @@ func TestExtractRequestContext(t *testing.T) {
 	req := httptest.NewRequest("POST", "/test?query=1", strings.NewReader(`{"hello":"world"}`))
 	req.Header.Set("Content-Type", "application/json")
 
-	ctx := extractRequestContext(req)
+	ctx := extractRequestContext(req, defaultMaxBodyCapture)
 
 	if ctx.Method != "POST" {
 		t.Errorf("Expected method POST, got %s", ctx.Method)
@@ -86,3 +86,27 @@ func TestExtractRequestContext(t *testing.T) {
 		t.Errorf("Restored body mismatched: %s", string(bodyBytes))
 	}
 }
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/users", "/users/123", true},
+		{"/users/{id}", "/users/123", true},
+		{"/users/{id}", "/users/123/posts", false},
+		{"/users/{id}", "/users/", false},
+		{"/users/{id}/posts", "/users/7/posts", true},
+		{"/files/{path...}", "/files/a/b/c", true},
+		{"/files/{path...}", "/other/a", false},
+		{"/users/{$}", "/users", true},
+		{"/users/{$}", "/users/", true},
+		{"/users/{$}", "/users/1", false},
+	}
+
+	for _, c := range cases {
+		if got := matchPath(c.pattern, c.path); got != c.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
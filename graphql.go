@@ -0,0 +1,580 @@
+package gobo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GraphQLRequest describes the GraphQL operation behind a request, see
+// RequestContext.GraphQL.
+type GraphQLRequest struct {
+	OperationType string         `json:"operation_type"`
+	OperationName string         `json:"operation_name,omitempty"`
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// GraphQL returns an http.Handler mocking a GraphQL endpoint described by
+// sdl, a schema in the GraphQL schema definition language. Queries are
+// parsed and checked against the schema, and the generator is asked for
+// the "data" object shaped exactly like the selection set: aliases,
+// fragments, nullability and __typename included. Field descriptions become
+// field instructions. Generated data that does not match the selection set
+// is answered with an error in the "errors" envelope.
+//
+//	h, err := g.GraphQL(sdl)
+//	mux.Handle("/graphql", h)
+func (g *Gobo) GraphQL(sdl string, opts ...RouteOption) (http.Handler, error) {
+	schema, err := parseGraphQLSchema(sdl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse graphql schema: %w", err)
+	}
+	return g.Stub(nil, append(opts, routeGraphQL(schema))...), nil
+}
+
+func routeGraphQL(schema *gqlSchema) RouteOption {
+	return func(r *routeSchema) {
+		r.GraphQL = schema
+	}
+}
+
+// graphQLBody is the JSON body of a GraphQL POST request.
+type graphQLBody struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// writeGraphQL answers a GraphQL request for a route with a GraphQL schema.
+func (g *Gobo) writeGraphQL(w http.ResponseWriter, r *http.Request, route *routeSchema, reqCtx RequestContext) {
+	req, err := readGraphQLRequest(r, reqCtx)
+	if err != nil {
+		writeGraphQLErrors(w, http.StatusBadRequest, GraphQLError{Message: err.Error()})
+		return
+	}
+	doc, err := parseGraphQLQuery(req.Query)
+	if err != nil {
+		writeGraphQLErrors(w, http.StatusBadRequest, asGraphQLError(err))
+		return
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		writeGraphQLErrors(w, http.StatusBadRequest, asGraphQLError(err))
+		return
+	}
+	shape, errs := route.GraphQL.shape(doc, op, req.Variables)
+	if len(errs) > 0 {
+		writeGraphQLErrors(w, http.StatusBadRequest, errs...)
+		return
+	}
+
+	req.OperationType, req.OperationName = op.Type, op.Name
+	reqCtx.GraphQL = &req
+	key := routeKey(r, route)
+	reqCtx.Examples = g.examplesFor(key, route)
+	reqCtx.Prompt = g.promptFor(key, route)
+
+	gen := g.generator(route)
+	if gen == nil {
+		gen = StaticGenerator{}
+	}
+	genCtx, backend := withBackend(r.Context())
+	out, err := gen.GenerateResponse(genCtx, reqCtx, shape)
+	if errors.Is(err, ErrOverloaded) {
+		w.Header().Set("Retry-After", "1")
+		writeGraphQLErrors(w, http.StatusServiceUnavailable, GraphQLError{Message: "Gobo Mock Generation Overloaded: " + err.Error()})
+		return
+	}
+	if err != nil {
+		g.logf("Error generating response: %v", err)
+		writeGraphQLErrors(w, http.StatusInternalServerError, GraphQLError{Message: "Gobo Mock Generation Failed: " + err.Error()})
+		return
+	}
+
+	resp, err := graphQLResponse(out, shape)
+	if err != nil {
+		g.logf("GraphQL mock for %s does not match the query: %v", op.Name, err)
+		resp = map[string]any{"data": nil, "errors": []GraphQLError{{
+			Message:    "Gobo mock response does not match the query: " + err.Error(),
+			Extensions: map[string]any{"code": "MOCK_SHAPE_MISMATCH"},
+		}}}
+	}
+
+	if route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if *backend != "" {
+		w.Header().Set(BackendHeader, *backend)
+	}
+	for k, v := range route.Headers {
+		w.Header().Set(k, v)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// readGraphQLRequest reads the query, operation name and variables from a
+// GET query string, a JSON body or an application/graphql body.
+func readGraphQLRequest(r *http.Request, reqCtx RequestContext) (GraphQLRequest, error) {
+	var req GraphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, fmt.Errorf("variables must be a JSON object: %w", err)
+			}
+		}
+	case http.MethodPost:
+		if reqCtx.BodyTruncated {
+			return req, errors.New("request body exceeds the capture limit")
+		}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/graphql") {
+			req.Query = reqCtx.Body
+			break
+		}
+		var body graphQLBody
+		if err := json.Unmarshal([]byte(reqCtx.Body), &body); err != nil {
+			return req, fmt.Errorf("request body must be a JSON object with a query: %w", err)
+		}
+		req.Query, req.OperationName, req.Variables = body.Query, body.OperationName, body.Variables
+	default:
+		return req, fmt.Errorf("GraphQL requests must use GET or POST, got %s", r.Method)
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return req, errors.New("request has no query")
+	}
+	return req, nil
+}
+
+// graphQLResponse builds the response envelope from generated output. The
+// output is the "data" object, or a whole {"data", "errors"} envelope when
+// an agent wants to answer with errors. Data is checked against shape.
+func graphQLResponse(out []byte, shape JSONSchema) (map[string]any, error) {
+	var value any
+	if err := json.Unmarshal(out, &value); err != nil {
+		return nil, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	resp := map[string]any{"data": value}
+	props, _ := shape["properties"].(map[string]any)
+	if obj, ok := value.(map[string]any); ok && isGraphQLEnvelope(obj, props) {
+		resp = obj
+	}
+	if data := resp["data"]; data != nil || resp["errors"] == nil {
+		if violations := validateSchema(shape, data, "$"); len(violations) > 0 {
+			msgs := make([]string, 0, len(violations))
+			for _, v := range violations {
+				msgs = append(msgs, v.String())
+			}
+			return nil, errors.New(strings.Join(msgs, "; "))
+		}
+	}
+	return resp, nil
+}
+
+// isGraphQLEnvelope reports whether obj is a {"data", "errors"} envelope
+// rather than data whose selection happens to use those names.
+func isGraphQLEnvelope(obj map[string]any, props map[string]any) bool {
+	if len(obj) == 0 {
+		return false
+	}
+	for k := range obj {
+		if k != "data" && k != "errors" && k != "extensions" {
+			return false
+		}
+		if _, selected := props[k]; selected {
+			return false
+		}
+	}
+	return true
+}
+
+func writeGraphQLErrors(w http.ResponseWriter, status int, errs ...GraphQLError) {
+	writeJSON(w, status, map[string]any{"errors": errs})
+}
+
+func asGraphQLError(err error) GraphQLError {
+	var gqlErr GraphQLError
+	if errors.As(err, &gqlErr) {
+		return gqlErr
+	}
+	return GraphQLError{Message: err.Error()}
+}
+
+// operation picks the operation to run.
+func (d *gqlDocument) operation(name string) (*gqlOperation, error) {
+	if name == "" {
+		if len(d.operations) != 1 {
+			return nil, GraphQLError{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, GraphQLError{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+// gqlShaper builds the JSON Schema of an operation's result while checking
+// the operation against the schema.
+type gqlShaper struct {
+	schema *gqlSchema
+	doc    *gqlDocument
+	vars   map[string]any
+	defs   map[string]gqlVarDef
+	errs   []GraphQLError
+	spread []string // fragments being expanded, to stop cycles
+}
+
+// shape returns the JSON Schema of the operation's "data", or the errors
+// that make the operation invalid.
+func (s *gqlSchema) shape(doc *gqlDocument, op *gqlOperation, vars map[string]any) (JSONSchema, []GraphQLError) {
+	sh := &gqlShaper{schema: s, doc: doc, vars: vars, defs: map[string]gqlVarDef{}}
+	for _, def := range op.Vars {
+		sh.defs[def.Name] = def
+		if _, given := vars[def.Name]; !given && def.Type.NonNull && !def.HasDefault {
+			sh.errorf(op.tok, "Variable \"$%s\" of required type %q was not provided.", def.Name, def.Type.String())
+		}
+	}
+
+	root := s.query
+	switch op.Type {
+	case "mutation":
+		root = s.mutation
+	case "subscription":
+		return nil, []GraphQLError{op.tok.errorf("Subscriptions are not supported by the Gobo mock.")}
+	}
+	typ := s.types[root]
+	if typ == nil {
+		return nil, []GraphQLError{op.tok.errorf("Schema is not configured for %ss.", op.Type)}
+	}
+
+	shape := sh.object(typ, op.Selections)
+	if len(sh.errs) > 0 {
+		return nil, sh.errs
+	}
+	return JSONSchema(shape), nil
+}
+
+func (sh *gqlShaper) errorf(tok gqlToken, format string, args ...any) {
+	sh.errs = append(sh.errs, tok.errorf(format, args...))
+}
+
+// object returns the schema of an object, interface or union value with
+// the given selections.
+func (sh *gqlShaper) object(typ *gqlType, sels []*gqlSelection) map[string]any {
+	props := map[string]any{}
+	required := map[string]bool{}
+	sh.collect(typ, typ, sels, true, props, required)
+
+	names := make([]string, 0, len(required))
+	for name, req := range required {
+		if req {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	req := make([]any, len(names))
+	for i, n := range names {
+		req[i] = n
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             req,
+		"additionalProperties": false,
+	}
+}
+
+// collect adds the fields selected on parent to props. within is the type
+// the fields are looked up on: parent itself, or the type condition of a
+// fragment. Fields of fragments that only apply to some possible types are
+// not required.
+func (sh *gqlShaper) collect(parent, within *gqlType, sels []*gqlSelection, always bool, props map[string]any, required map[string]bool) {
+	for _, sel := range sels {
+		if !sh.included(sel.Directives) {
+			continue
+		}
+		switch {
+		case sel.Spread != "":
+			frag := sh.doc.fragments[sel.Spread]
+			if frag == nil {
+				sh.errorf(sel.tok, "Unknown fragment %q.", sel.Spread)
+				continue
+			}
+			if slices.Contains(sh.spread, frag.Name) {
+				sh.errorf(sel.tok, "Cannot spread fragment %q within itself.", frag.Name)
+				continue
+			}
+			sh.spread = append(sh.spread, frag.Name)
+			sh.fragment(parent, within, frag.TypeCond, frag.Selections, sel.tok, always, props, required)
+			sh.spread = sh.spread[:len(sh.spread)-1]
+		case sel.Inline:
+			sh.fragment(parent, within, sel.TypeCond, sel.Selections, sel.tok, always, props, required)
+		default:
+			key := sel.Name
+			if sel.Alias != "" {
+				key = sel.Alias
+			}
+			node := sh.field(within, sel)
+			if node == nil {
+				continue
+			}
+			if existing := asSchemaNode(props[key]); existing != nil {
+				node = mergeShapes(existing, node)
+			}
+			props[key] = node
+			required[key] = required[key] || always
+		}
+	}
+}
+
+// fragment applies a fragment's selections when its type condition can
+// match values of within.
+func (sh *gqlShaper) fragment(parent, within *gqlType, cond string, sels []*gqlSelection, tok gqlToken, always bool, props map[string]any, required map[string]bool) {
+	if cond == "" || cond == within.Name {
+		sh.collect(parent, within, sels, always, props, required)
+		return
+	}
+	condType := sh.schema.types[cond]
+	if condType == nil {
+		sh.errorf(tok, "Unknown type %q.", cond)
+		return
+	}
+	if !sh.overlaps(within, condType) {
+		sh.errorf(tok, "Fragment cannot be spread here as objects of type %q can never be of type %q.", within.Name, cond)
+		return
+	}
+	// Every value of within is a cond (an object implementing the
+	// interface), so its fields are always present
+	sh.collect(parent, condType, sels, always && sh.covers(condType, within), props, required)
+}
+
+// possible returns the object types a value of typ can have.
+func (sh *gqlShaper) possible(typ *gqlType) []string {
+	switch typ.Kind {
+	case "OBJECT":
+		return []string{typ.Name}
+	case "UNION":
+		return typ.Members
+	case "INTERFACE":
+		var out []string
+		for _, t := range sh.schema.types {
+			if t.Kind == "OBJECT" && slices.Contains(t.Interfaces, typ.Name) {
+				out = append(out, t.Name)
+			}
+		}
+		sort.Strings(out)
+		return out
+	}
+	return nil
+}
+
+func (sh *gqlShaper) overlaps(a, b *gqlType) bool {
+	for _, name := range sh.possible(a) {
+		if slices.Contains(sh.possible(b), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether every possible type of sub is a possible type of super.
+func (sh *gqlShaper) covers(super, sub *gqlType) bool {
+	for _, name := range sh.possible(sub) {
+		if !slices.Contains(sh.possible(super), name) {
+			return false
+		}
+	}
+	return true
+}
+
+// field returns the schema of a selected field, or nil when it is invalid.
+func (sh *gqlShaper) field(within *gqlType, sel *gqlSelection) map[string]any {
+	if sel.Name == "__typename" {
+		enum := []any{}
+		for _, name := range sh.possible(within) {
+			enum = append(enum, name)
+		}
+		return map[string]any{"type": "string", "enum": enum}
+	}
+	def := within.Fields[sel.Name]
+	if def == nil {
+		sh.errorf(sel.tok, "Cannot query field %q on type %q.", sel.Name, within.Name)
+		return nil
+	}
+	sh.checkArgs(within, def, sel)
+	node := sh.typeShape(def.Type, sel, def)
+	if node != nil && def.Description != "" {
+		node["description"] = def.Description
+	}
+	return node
+}
+
+// checkArgs checks a field's arguments and the variables they use.
+func (sh *gqlShaper) checkArgs(within *gqlType, def *gqlField, sel *gqlSelection) {
+	names := make([]string, 0, len(sel.Args))
+	for name := range sel.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if def.Args[name] == nil {
+			sh.errorf(sel.tok, "Unknown argument %q on field \"%s.%s\".", name, within.Name, def.Name)
+			continue
+		}
+		sh.checkVariables(sel.tok, sel.Args[name])
+	}
+	argNames := make([]string, 0, len(def.Args))
+	for name := range def.Args {
+		argNames = append(argNames, name)
+	}
+	sort.Strings(argNames)
+	for _, name := range argNames {
+		arg := def.Args[name]
+		if _, given := sel.Args[name]; !given && arg.Type.NonNull && !arg.HasDefault {
+			sh.errorf(sel.tok, "Field %q argument %q of type %q is required, but it was not provided.", def.Name, name, arg.Type.String())
+		}
+	}
+}
+
+func (sh *gqlShaper) checkVariables(tok gqlToken, value any) {
+	switch v := value.(type) {
+	case gqlVariable:
+		if _, ok := sh.defs[v.Name]; !ok {
+			sh.errorf(v.tok, "Variable \"$%s\" is not defined.", v.Name)
+		}
+	case []any:
+		for _, item := range v {
+			sh.checkVariables(tok, item)
+		}
+	case map[string]any:
+		for _, item := range v {
+			sh.checkVariables(tok, item)
+		}
+	}
+}
+
+// typeShape returns the schema of a value of type ref.
+func (sh *gqlShaper) typeShape(ref *gqlTypeRef, sel *gqlSelection, def *gqlField) map[string]any {
+	var node map[string]any
+	if ref.Elem != nil {
+		items := sh.typeShape(ref.Elem, sel, def)
+		if items == nil {
+			return nil
+		}
+		node = map[string]any{"type": "array", "items": items}
+	} else {
+		typ := sh.schema.types[ref.Name]
+		leaf := typ.Kind == "SCALAR" || typ.Kind == "ENUM"
+		switch {
+		case leaf && sel.Selections != nil:
+			sh.errorf(sel.tok, "Field %q must not have a selection since type %q has no subfields.", sel.Name, ref.Name)
+			return nil
+		case !leaf && sel.Selections == nil:
+			sh.errorf(sel.tok, "Field %q of type %q must have a selection of subfields.", sel.Name, ref.String())
+			return nil
+		}
+		switch typ.Kind {
+		case "SCALAR":
+			node = scalarShape(typ.Name)
+		case "ENUM":
+			enum := make([]any, len(typ.Values))
+			for i, v := range typ.Values {
+				enum[i] = v
+			}
+			node = map[string]any{"type": "string", "enum": enum}
+		default:
+			node = sh.object(typ, sel.Selections)
+		}
+	}
+	if !ref.NonNull {
+		if t, ok := node["type"].(string); ok {
+			node["type"] = []any{t, "null"}
+		}
+	}
+	return node
+}
+
+// scalarShape maps a scalar to JSON Schema. Custom scalars accept any
+// value.
+func scalarShape(name string) map[string]any {
+	switch name {
+	case "Int":
+		return map[string]any{"type": "integer"}
+	case "Float":
+		return map[string]any{"type": "number"}
+	case "String", "ID":
+		return map[string]any{"type": "string"}
+	case "Boolean":
+		return map[string]any{"type": "boolean"}
+	}
+	return map[string]any{"description": "GraphQL scalar " + name}
+}
+
+// mergeShapes merges two selections of the same response key, such as a
+// field selected both directly and in a fragment.
+func mergeShapes(a, b map[string]any) map[string]any {
+	if items := asSchemaNode(a["items"]); items != nil {
+		if other := asSchemaNode(b["items"]); other != nil {
+			out := deepCopySchema(a)
+			out["items"] = mergeShapes(items, other)
+			return out
+		}
+	}
+	aProps, aOK := a["properties"].(map[string]any)
+	bProps, bOK := b["properties"].(map[string]any)
+	if !aOK || !bOK {
+		return a
+	}
+	out := deepCopySchema(a)
+	props := out["properties"].(map[string]any)
+	for k, v := range bProps {
+		if existing := asSchemaNode(aProps[k]); existing != nil {
+			props[k] = mergeShapes(existing, asSchemaNode(v))
+		} else {
+			props[k] = v
+		}
+	}
+	required, _ := out["required"].([]any)
+	bRequired, _ := b["required"].([]any)
+	for _, name := range bRequired {
+		if !slices.Contains(required, name) {
+			required = append(required, name)
+		}
+	}
+	out["required"] = required
+	return out
+}
+
+// included evaluates @skip and @include.
+func (sh *gqlShaper) included(directives []gqlDirective) bool {
+	for _, d := range directives {
+		cond := d.Args["if"]
+		if v, ok := cond.(gqlVariable); ok {
+			cond = sh.vars[v.Name]
+			if cond == nil {
+				cond = sh.defs[v.Name].Default
+			}
+		}
+		switch {
+		case d.Name == "skip" && cond == true:
+			return false
+		case d.Name == "include" && cond != true:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,941 @@
+package gobo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file holds a small GraphQL lexer and parser covering the schema
+// definition language and executable documents, enough to mock endpoints.
+
+// GraphQLError is an entry of a GraphQL response's "errors" list.
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Path       []any             `json:"path,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation points into a GraphQL document.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e GraphQLError) Error() string {
+	if len(e.Locations) > 0 {
+		return fmt.Sprintf("%s (line %d, column %d)", e.Message, e.Locations[0].Line, e.Locations[0].Column)
+	}
+	return e.Message
+}
+
+const (
+	gqlEOF = iota
+	gqlPunct
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind  int
+	value string
+	line  int
+	col   int
+}
+
+func (t gqlToken) errorf(format string, args ...any) GraphQLError {
+	return GraphQLError{
+		Message:   fmt.Sprintf(format, args...),
+		Locations: []GraphQLLocation{{Line: t.line, Column: t.col}},
+	}
+}
+
+func (t gqlToken) String() string {
+	if t.kind == gqlEOF {
+		return "<EOF>"
+	}
+	return strconv.Quote(t.value)
+}
+
+// lexGraphQL splits a document into tokens, skipping whitespace, commas and
+// comments.
+func lexGraphQL(src string) ([]gqlToken, error) {
+	var toks []gqlToken
+	line, lineStart := 1, 0
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		col := i - lineStart + 1
+		switch {
+		case c == '\n':
+			line++
+			i++
+			lineStart = i
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "\uFEFF"):
+			i += len("\uFEFF")
+		case strings.HasPrefix(src[i:], "..."):
+			toks = append(toks, gqlToken{gqlPunct, "...", line, col})
+			i += 3
+		case strings.ContainsRune("!$&()|:=@[]{}", rune(c)):
+			toks = append(toks, gqlToken{gqlPunct, string(c), line, col})
+			i++
+		case c == '_' || isASCIILetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isASCIILetter(src[i]) || isASCIIDigit(src[i])) {
+				i++
+			}
+			toks = append(toks, gqlToken{gqlName, src[start:i], line, col})
+		case c == '-' || isASCIIDigit(c):
+			start := i
+			kind := gqlInt
+			i++
+			for i < len(src) && isASCIIDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = gqlFloat
+				i++
+				for i < len(src) && isASCIIDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = gqlFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isASCIIDigit(src[i]) {
+					i++
+				}
+			}
+			toks = append(toks, gqlToken{kind, src[start:i], line, col})
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return nil, gqlToken{line: line, col: col}.errorf("Unterminated string.")
+			}
+			raw := src[i+3 : i+3+end]
+			toks = append(toks, gqlToken{gqlString, blockString(raw), line, col})
+			line += strings.Count(raw, "\n")
+			if n := strings.LastIndex(raw, "\n"); n >= 0 {
+				lineStart = i + 3 + n + 1
+			}
+			i += 3 + end + 3
+		case c == '"':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(src) || src[i] == '\n' {
+					return nil, gqlToken{line: line, col: col}.errorf("Unterminated string.")
+				}
+				if src[i] == '"' {
+					i++
+					break
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					switch esc := src[i+1]; esc {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					case 'r':
+						b.WriteByte('\r')
+					case 'b':
+						b.WriteByte('\b')
+					case 'f':
+						b.WriteByte('\f')
+					case 'u':
+						if i+6 <= len(src) {
+							if r, err := strconv.ParseUint(src[i+2:i+6], 16, 32); err == nil {
+								b.WriteRune(rune(r))
+								i += 6
+								continue
+							}
+						}
+						return nil, gqlToken{line: line, col: col}.errorf("Invalid unicode escape.")
+					default:
+						b.WriteByte(esc)
+					}
+					i += 2
+					continue
+				}
+				b.WriteByte(src[i])
+				i++
+			}
+			toks = append(toks, gqlToken{gqlString, b.String(), line, col})
+		default:
+			return nil, gqlToken{line: line, col: col}.errorf("Unexpected character %q.", c)
+		}
+	}
+	return append(toks, gqlToken{kind: gqlEOF, line: line, col: i - lineStart + 1}), nil
+}
+
+// blockString removes the common indentation of a """block string""".
+func blockString(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, `\"""`, `"""`), "\n")
+	indent := -1
+	for _, l := range lines[1:] {
+		trimmed := strings.TrimLeft(l, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(l) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isASCIILetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isASCIIDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// gqlParser walks a token list.
+type gqlParser struct {
+	toks []gqlToken
+	pos  int
+}
+
+func newGQLParser(src string) (*gqlParser, error) {
+	toks, err := lexGraphQL(src)
+	if err != nil {
+		return nil, err
+	}
+	return &gqlParser{toks: toks}, nil
+}
+
+func (p *gqlParser) peek() gqlToken { return p.toks[p.pos] }
+
+func (p *gqlParser) next() gqlToken {
+	t := p.toks[p.pos]
+	if t.kind != gqlEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports whether the next token is the punctuator or keyword s.
+func (p *gqlParser) is(s string) bool {
+	t := p.peek()
+	return (t.kind == gqlPunct || t.kind == gqlName) && t.value == s
+}
+
+// skip consumes the next token if it is s.
+func (p *gqlParser) skip(s string) bool {
+	if p.is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *gqlParser) expect(s string) error {
+	if !p.skip(s) {
+		t := p.peek()
+		return t.errorf("Syntax Error: Expected %q, found %s.", s, t)
+	}
+	return nil
+}
+
+func (p *gqlParser) name() (string, error) {
+	t := p.next()
+	if t.kind != gqlName {
+		return "", t.errorf("Syntax Error: Expected Name, found %s.", t)
+	}
+	return t.value, nil
+}
+
+// description consumes an optional description string.
+func (p *gqlParser) description() string {
+	if p.peek().kind == gqlString {
+		return p.next().value
+	}
+	return ""
+}
+
+// gqlTypeRef is a type reference such as [User!]!.
+type gqlTypeRef struct {
+	Name    string
+	Elem    *gqlTypeRef // list element, when this is a list
+	NonNull bool
+}
+
+func (t *gqlTypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// named returns the innermost named type.
+func (t *gqlTypeRef) named() string {
+	for t.Elem != nil {
+		t = t.Elem
+	}
+	return t.Name
+}
+
+func (p *gqlParser) typeRef() (*gqlTypeRef, error) {
+	var ref *gqlTypeRef
+	if p.skip("[") {
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		ref = &gqlTypeRef{Elem: elem}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		ref = &gqlTypeRef{Name: name}
+	}
+	ref.NonNull = p.skip("!")
+	return ref, nil
+}
+
+// gqlVariable is a variable reference inside a value.
+type gqlVariable struct {
+	Name string
+	tok  gqlToken
+}
+
+// gqlEnum is an enum value literal.
+type gqlEnum string
+
+// value parses a literal, list, object or variable.
+func (p *gqlParser) value() (any, error) {
+	t := p.next()
+	switch t.kind {
+	case gqlInt:
+		n, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, t.errorf("Syntax Error: Invalid number %s.", t.value)
+		}
+		return n, nil
+	case gqlFloat:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, t.errorf("Syntax Error: Invalid number %s.", t.value)
+		}
+		return f, nil
+	case gqlString:
+		return t.value, nil
+	case gqlName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return gqlEnum(t.value), nil
+	case gqlPunct:
+		switch t.value {
+		case "$":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return gqlVariable{Name: name, tok: t}, nil
+		case "[":
+			list := []any{}
+			for !p.skip("]") {
+				if p.peek().kind == gqlEOF {
+					return nil, p.peek().errorf("Syntax Error: Expected \"]\", found <EOF>.")
+				}
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, nil
+		case "{":
+			obj := map[string]any{}
+			for !p.skip("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				obj[name] = v
+			}
+			return obj, nil
+		}
+	}
+	return nil, t.errorf("Syntax Error: Unexpected %s.", t)
+}
+
+// gqlDirective is an applied directive such as @include(if: $flag).
+type gqlDirective struct {
+	Name string
+	Args map[string]any
+}
+
+func (p *gqlParser) directives() ([]gqlDirective, error) {
+	var out []gqlDirective
+	for p.skip("@") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, gqlDirective{Name: name, Args: args})
+	}
+	return out, nil
+}
+
+// arguments parses an optional (name: value ...) list.
+func (p *gqlParser) arguments() (map[string]any, error) {
+	if !p.skip("(") {
+		return nil, nil
+	}
+	args := map[string]any{}
+	for !p.skip(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		args[name] = v
+	}
+	return args, nil
+}
+
+// gqlSchema is a parsed schema definition.
+type gqlSchema struct {
+	types    map[string]*gqlType
+	query    string
+	mutation string
+}
+
+// gqlType is a named type of the schema.
+type gqlType struct {
+	Name       string
+	Kind       string // OBJECT, INTERFACE, UNION, ENUM, SCALAR or INPUT_OBJECT
+	Fields     map[string]*gqlField
+	Interfaces []string
+	Members    []string // union members
+	Values     []string // enum values
+}
+
+// gqlField is a field of an object or interface type.
+type gqlField struct {
+	Name        string
+	Description string
+	Type        *gqlTypeRef
+	Args        map[string]*gqlArgDef
+}
+
+// gqlArgDef is an argument or input field definition.
+type gqlArgDef struct {
+	Type       *gqlTypeRef
+	HasDefault bool
+}
+
+var builtinScalars = []string{"Int", "Float", "String", "Boolean", "ID"}
+
+// parseGraphQLSchema parses a schema in the GraphQL schema definition
+// language.
+func parseGraphQLSchema(sdl string) (*gqlSchema, error) {
+	p, err := newGQLParser(sdl)
+	if err != nil {
+		return nil, err
+	}
+	s := &gqlSchema{types: map[string]*gqlType{}}
+	for _, name := range builtinScalars {
+		s.types[name] = &gqlType{Name: name, Kind: "SCALAR"}
+	}
+
+	for p.peek().kind != gqlEOF {
+		p.description()
+		extend := p.skip("extend")
+		t := p.next()
+		if t.kind != gqlName {
+			return nil, t.errorf("Syntax Error: Unexpected %s.", t)
+		}
+		switch t.value {
+		case "schema":
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			for !p.skip("}") {
+				op, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				switch op {
+				case "query":
+					s.query = name
+				case "mutation":
+					s.mutation = name
+				}
+			}
+		case "scalar":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			s.define(&gqlType{Name: name, Kind: "SCALAR"}, extend)
+		case "type", "interface", "input":
+			if err := s.parseFieldsType(p, t.value, extend); err != nil {
+				return nil, err
+			}
+		case "union":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			typ := &gqlType{Name: name, Kind: "UNION"}
+			if p.skip("=") {
+				p.skip("|")
+				for {
+					member, err := p.name()
+					if err != nil {
+						return nil, err
+					}
+					typ.Members = append(typ.Members, member)
+					if !p.skip("|") {
+						break
+					}
+				}
+			}
+			s.define(typ, extend)
+		case "enum":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			typ := &gqlType{Name: name, Kind: "ENUM"}
+			if p.skip("{") {
+				for !p.skip("}") {
+					p.description()
+					value, err := p.name()
+					if err != nil {
+						return nil, err
+					}
+					if _, err := p.directives(); err != nil {
+						return nil, err
+					}
+					typ.Values = append(typ.Values, value)
+				}
+			}
+			s.define(typ, extend)
+		case "directive":
+			// directive @name(args) repeatable on LOCATION | ...
+			if err := p.expect("@"); err != nil {
+				return nil, err
+			}
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+			if p.is("(") {
+				if _, err := p.argumentDefs(); err != nil {
+					return nil, err
+				}
+			}
+			p.skip("repeatable")
+			if err := p.expect("on"); err != nil {
+				return nil, err
+			}
+			p.skip("|")
+			for {
+				if _, err := p.name(); err != nil {
+					return nil, err
+				}
+				if !p.skip("|") {
+					break
+				}
+			}
+		default:
+			return nil, t.errorf("Syntax Error: Unexpected Name %q.", t.value)
+		}
+	}
+
+	if s.query == "" {
+		s.query = "Query"
+	}
+	if s.mutation == "" {
+		s.mutation = "Mutation"
+	}
+	if typ := s.types[s.query]; typ == nil || typ.Kind != "OBJECT" {
+		return nil, GraphQLError{Message: fmt.Sprintf("Query root type %q is not defined.", s.query)}
+	}
+	for _, typ := range s.types {
+		for _, f := range typ.Fields {
+			if s.types[f.Type.named()] == nil {
+				return nil, GraphQLError{Message: fmt.Sprintf("Unknown type %q for field %s.%s.", f.Type.named(), typ.Name, f.Name)}
+			}
+		}
+	}
+	return s, nil
+}
+
+// define adds a type, or merges an extension into an existing one.
+func (s *gqlSchema) define(typ *gqlType, extend bool) {
+	existing := s.types[typ.Name]
+	if !extend || existing == nil {
+		s.types[typ.Name] = typ
+		return
+	}
+	for name, f := range typ.Fields {
+		if existing.Fields == nil {
+			existing.Fields = map[string]*gqlField{}
+		}
+		existing.Fields[name] = f
+	}
+	existing.Interfaces = append(existing.Interfaces, typ.Interfaces...)
+	existing.Members = append(existing.Members, typ.Members...)
+	existing.Values = append(existing.Values, typ.Values...)
+}
+
+// parseFieldsType parses a type, interface or input definition.
+func (s *gqlSchema) parseFieldsType(p *gqlParser, keyword string, extend bool) error {
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	kind := map[string]string{"type": "OBJECT", "interface": "INTERFACE", "input": "INPUT_OBJECT"}[keyword]
+	typ := &gqlType{Name: name, Kind: kind, Fields: map[string]*gqlField{}}
+	if p.skip("implements") {
+		p.skip("&")
+		for {
+			iface, err := p.name()
+			if err != nil {
+				return err
+			}
+			typ.Interfaces = append(typ.Interfaces, iface)
+			if !p.skip("&") {
+				break
+			}
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return err
+	}
+	if p.skip("{") {
+		for !p.skip("}") {
+			desc := p.description()
+			fieldName, err := p.name()
+			if err != nil {
+				return err
+			}
+			field := &gqlField{Name: fieldName, Description: desc}
+			if p.is("(") {
+				if field.Args, err = p.argumentDefs(); err != nil {
+					return err
+				}
+			}
+			if err := p.expect(":"); err != nil {
+				return err
+			}
+			if field.Type, err = p.typeRef(); err != nil {
+				return err
+			}
+			if p.skip("=") {
+				if _, err := p.value(); err != nil {
+					return err
+				}
+			}
+			if _, err := p.directives(); err != nil {
+				return err
+			}
+			typ.Fields[fieldName] = field
+		}
+	}
+	s.define(typ, extend)
+	return nil
+}
+
+// argumentDefs parses (name: Type = default ...) definitions.
+func (p *gqlParser) argumentDefs() (map[string]*gqlArgDef, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := map[string]*gqlArgDef{}
+	for !p.skip(")") {
+		p.description()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		ref, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		def := &gqlArgDef{Type: ref}
+		if p.skip("=") {
+			if _, err := p.value(); err != nil {
+				return nil, err
+			}
+			def.HasDefault = true
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		args[name] = def
+	}
+	return args, nil
+}
+
+// gqlDocument is a parsed executable document.
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+// gqlOperation is a query or mutation.
+type gqlOperation struct {
+	Type       string
+	Name       string
+	Vars       []gqlVarDef
+	Directives []gqlDirective
+	Selections []*gqlSelection
+	tok        gqlToken
+}
+
+// gqlVarDef is a variable definition such as ($id: ID! = "1").
+type gqlVarDef struct {
+	Name       string
+	Type       *gqlTypeRef
+	HasDefault bool
+	Default    any
+}
+
+// gqlFragment is a named fragment definition.
+type gqlFragment struct {
+	Name       string
+	TypeCond   string
+	Selections []*gqlSelection
+	tok        gqlToken
+}
+
+// gqlSelection is a field, fragment spread or inline fragment.
+type gqlSelection struct {
+	Alias      string
+	Name       string // field name; empty for fragments
+	Args       map[string]any
+	Directives []gqlDirective
+	Selections []*gqlSelection
+
+	Spread   string // fragment spread name
+	Inline   bool   // inline fragment
+	TypeCond string // inline fragment type condition, optional
+
+	tok gqlToken
+}
+
+// parseGraphQLQuery parses an executable document.
+func parseGraphQLQuery(query string) (*gqlDocument, error) {
+	p, err := newGQLParser(query)
+	if err != nil {
+		return nil, err
+	}
+	doc := &gqlDocument{fragments: map[string]*gqlFragment{}}
+	if p.peek().kind == gqlEOF {
+		return nil, p.peek().errorf("Syntax Error: Unexpected <EOF>.")
+	}
+	for p.peek().kind != gqlEOF {
+		t := p.peek()
+		switch {
+		case p.is("{"):
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &gqlOperation{Type: "query", Selections: sels, tok: t})
+		case p.is("query") || p.is("mutation") || p.is("subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.is("fragment"):
+			p.next()
+			frag := &gqlFragment{tok: t}
+			if frag.Name, err = p.name(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("on"); err != nil {
+				return nil, err
+			}
+			if frag.TypeCond, err = p.name(); err != nil {
+				return nil, err
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			if frag.Selections, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+			doc.fragments[frag.Name] = frag
+		default:
+			return nil, t.errorf("Syntax Error: Unexpected %s.", t)
+		}
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) operation() (*gqlOperation, error) {
+	t := p.next()
+	op := &gqlOperation{Type: t.value, tok: t}
+	var err error
+	if p.peek().kind == gqlName {
+		op.Name = p.next().value
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			v := gqlVarDef{}
+			if v.Name, err = p.name(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if v.Type, err = p.typeRef(); err != nil {
+				return nil, err
+			}
+			if p.skip("=") {
+				if v.Default, err = p.value(); err != nil {
+					return nil, err
+				}
+				v.HasDefault = true
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			op.Vars = append(op.Vars, v)
+		}
+	}
+	if op.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if op.Selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []*gqlSelection
+	for !p.skip("}") {
+		t := p.peek()
+		if t.kind == gqlEOF {
+			return nil, t.errorf("Syntax Error: Expected Name, found <EOF>.")
+		}
+		sel := &gqlSelection{tok: t}
+		var err error
+		if p.skip("...") {
+			switch {
+			case p.peek().kind == gqlName && p.peek().value != "on":
+				sel.Spread = p.next().value
+			default:
+				sel.Inline = true
+				if p.skip("on") {
+					if sel.TypeCond, err = p.name(); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if sel.Directives, err = p.directives(); err != nil {
+				return nil, err
+			}
+			if sel.Inline {
+				if sel.Selections, err = p.selectionSet(); err != nil {
+					return nil, err
+				}
+			}
+			sels = append(sels, sel)
+			continue
+		}
+
+		if sel.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if p.skip(":") {
+			sel.Alias = sel.Name
+			if sel.Name, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if sel.Args, err = p.arguments(); err != nil {
+			return nil, err
+		}
+		if sel.Directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if p.is("{") {
+			if sel.Selections, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+		}
+		sels = append(sels, sel)
+	}
+	return sels, nil
+}
@@ -0,0 +1,197 @@
+package gobo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSDL = `
+"""A person using the app"""
+type User implements Node {
+  id: ID!
+  "Display name, first and last"
+  name: String!
+  email: String
+  role: Role!
+  posts(first: Int = 10): [Post!]!
+}
+
+type Post implements Node {
+  id: ID!
+  title: String!
+}
+
+interface Node { id: ID! }
+
+union SearchResult = User | Post
+
+enum Role { ADMIN MEMBER }
+
+type Query {
+  user(id: ID!): User
+  search(term: String!): [SearchResult!]!
+}
+
+type Mutation {
+  rename(id: ID!, name: String!): User!
+}
+`
+
+func graphQLPost(t *testing.T, h http.Handler, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid JSON response %q", rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+func TestGraphQL_ShapeFromSelection(t *testing.T) {
+	gen := &captureSchemaGenerator{}
+	g := New(WithGenerator(gen))
+	h, err := g.GraphQL(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := `query GetUser($id: ID!) {
+		person: user(id: $id) { __typename name email ...RoleFields posts { title } }
+	}
+	fragment RoleFields on User { role }`
+	graphQLPost(t, h, `{"query": `+quoteJSON(query)+`, "variables": {"id": "1"}}`)
+
+	shape := gen.schema.(JSONSchema)
+	person := asSchemaNode(shape["properties"].(map[string]any)["person"])
+	if person["type"].([]any)[1] != "null" {
+		t.Errorf("Expected a nullable user, got %v", person["type"])
+	}
+	props := person["properties"].(map[string]any)
+	for _, key := range []string{"__typename", "name", "email", "role", "posts"} {
+		if props[key] == nil {
+			t.Errorf("Expected %s in the shape", key)
+		}
+	}
+	if len(props) != 5 {
+		t.Errorf("Expected only the selected fields, got %v", props)
+	}
+	if asSchemaNode(props["name"])["description"] != "Display name, first and last" {
+		t.Errorf("Expected the field description, got %v", props["name"])
+	}
+	if asSchemaNode(props["role"])["enum"] == nil {
+		t.Error("Expected the enum values for role")
+	}
+}
+
+func TestGraphQL_StaticAndValidatedResponses(t *testing.T) {
+	g := New()
+	h, _ := g.GraphQL(testSDL)
+	code, resp := graphQLPost(t, h, `{"query": "{ user(id: \"1\") { id role } }"}`)
+	want := `{"data":{"user":{"id":"","role":"ADMIN"}}}`
+	if got, _ := json.Marshal(resp); code != 200 || string(got) != want {
+		t.Errorf("Expected %s, got %d %s", want, code, got)
+	}
+
+	g = New(WithGenerator(&mockGenerator{Response: []byte(`{"user":{"id":1,"extra":true}}`)}))
+	h, _ = g.GraphQL(testSDL)
+	_, resp = graphQLPost(t, h, `{"query": "{ user(id: \"1\") { id } }"}`)
+	errs, _ := resp["errors"].([]any)
+	if resp["data"] != nil || len(errs) != 1 || !strings.Contains(errs[0].(map[string]any)["message"].(string), "$.user.id") {
+		t.Errorf("Expected a shape mismatch error, got %v", resp)
+	}
+
+	// Agents may answer with a whole envelope, errors included
+	g = New(WithGenerator(&mockGenerator{Response: []byte(`{"data":null,"errors":[{"message":"not found"}]}`)}))
+	h, _ = g.GraphQL(testSDL)
+	_, resp = graphQLPost(t, h, `{"query": "{ user(id: \"1\") { id } }"}`)
+	if errs, _ := resp["errors"].([]any); len(errs) != 1 || errs[0].(map[string]any)["message"] != "not found" {
+		t.Errorf("Expected the agent's errors, got %v", resp)
+	}
+}
+
+func TestGraphQL_AbstractTypes(t *testing.T) {
+	gen := &captureSchemaGenerator{}
+	g := New(WithGenerator(gen))
+	h, _ := g.GraphQL(testSDL)
+	graphQLPost(t, h, `{"query": "{ search(term: \"x\") { __typename ... on Node { id } ... on User { name } ... on Post { title } } }"}`)
+
+	items := asSchemaNode(asSchemaNode(gen.schema.(JSONSchema)["properties"].(map[string]any)["search"])["items"])
+	required := items["required"].([]any)
+	if len(required) != 2 || required[0] != "__typename" || required[1] != "id" {
+		t.Errorf("Expected only __typename and id to be required, got %v", required)
+	}
+	typename := asSchemaNode(items["properties"].(map[string]any)["__typename"])
+	if len(typename["enum"].([]any)) != 2 {
+		t.Errorf("Expected both union members as typenames, got %v", typename["enum"])
+	}
+}
+
+func TestGraphQL_Errors(t *testing.T) {
+	g := New()
+	h, _ := g.GraphQL(testSDL)
+
+	tests := []struct {
+		body, want string
+	}{
+		{`{"query": "{ user(id: \"1\") { nope } }"}`, `Cannot query field "nope" on type "User".`},
+		{`{"query": "{ user { id } }"}`, `argument "id" of type "ID!" is required`},
+		{`{"query": "{ user(id: \"1\") }"}`, `must have a selection of subfields`},
+		{`{"query": "query Q($id: ID!) { user(id: $id) { id } }"}`, `Variable "$id" of required type "ID!" was not provided.`},
+		{`{"query": "{ user(id: \"1\") { id "}`, `Syntax Error`},
+		{`{"query": "query A { user(id: \"1\") { id } } query B { user(id: \"1\") { id } }"}`, `Must provide operation name`},
+		{`{}`, `request has no query`},
+	}
+	for _, tt := range tests {
+		code, resp := graphQLPost(t, h, tt.body)
+		errs, _ := resp["errors"].([]any)
+		if code != http.StatusBadRequest || len(errs) == 0 || !strings.Contains(errs[0].(map[string]any)["message"].(string), tt.want) {
+			t.Errorf("%s: expected 400 with %q, got %d %v", tt.body, tt.want, code, resp)
+		}
+	}
+
+	if _, err := g.GraphQL(`type Query { user: Missing }`); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
+
+func TestGraphQL_BrokerOperation(t *testing.T) {
+	broker := NewAsyncBroker()
+	g := New(WithGenerator(broker))
+	h, _ := g.GraphQL(testSDL)
+
+	done := make(chan map[string]any)
+	go func() {
+		_, resp := graphQLPost(t, h, `{"query": "mutation Rename { rename(id: \"1\", name: \"Ada\") { name } }"}`)
+		done <- resp
+	}()
+
+	var pending []PendingRequest
+	for deadline := time.Now().Add(time.Second); len(pending) == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		pending = broker.GetPendingRequests()
+	}
+	if len(pending) != 1 || pending[0].Operation != "mutation Rename" {
+		t.Fatalf("Expected the operation on the pending request, got %+v", pending)
+	}
+	if pending[0].Shape["type"] != "object" {
+		t.Errorf("Expected the JSON Schema shape, got %v", pending[0].Shape)
+	}
+	if pending[0].Context.GraphQL == nil || !strings.Contains(pending[0].Context.GraphQL.Query, "rename") {
+		t.Errorf("Expected the query in the request context")
+	}
+	_ = broker.SubmitResponse(pending[0].ID, []byte(`{"rename":{"name":"Ada"}}`))
+	if resp := <-done; resp["data"].(map[string]any)["rename"].(map[string]any)["name"] != "Ada" {
+		t.Errorf("Unexpected response %v", resp)
+	}
+}
+
+func quoteJSON(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,568 @@
+package gobo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// gRPC status codes used by the mock, see
+// https://grpc.github.io/grpc/core/md_doc_statuscodes.html.
+const (
+	grpcOK                = 0
+	grpcInvalidArgument   = 3
+	grpcDeadlineExceeded  = 4
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+	grpcInternal          = 13
+	grpcUnavailable       = 14
+)
+
+const (
+	grpcMaxMessageSize    = 4 << 20 // the gRPC default receive limit
+	grpcMessageDepthLimit = 8       // nesting depth of generated schemas
+	grpcStatusKey         = "grpc-status"
+	grpcMessageKey        = "grpc-message"
+)
+
+// GRPCCall describes the gRPC call behind a request, see RequestContext.GRPC.
+type GRPCCall struct {
+	Service string `json:"service"` // full service name, such as "users.v1.UserService"
+	Method  string `json:"method"`
+}
+
+// grpcService holds the methods of the services in a FileDescriptorSet,
+// keyed by their HTTP/2 path ("/users.v1.UserService/GetUser").
+type grpcService struct {
+	methods map[string]protoreflect.MethodDescriptor
+	types   *dynamicpb.Types
+}
+
+// GRPC returns an http.Handler mocking the unary methods of every service
+// in a FileDescriptorSet, as written by
+//
+//	protoc --include_imports --include_source_info --descriptor_set_out=api.pb api.proto
+//
+// Request messages are decoded to JSON for RequestContext.Body, the
+// generator produces the response message as JSON from a schema derived
+// from the descriptor (proto comments become field descriptions), and the
+// result is encoded back to protobuf with gRPC framing and trailers. No
+// generated stubs are needed. gRPC clients speak HTTP/2, so serve the
+// handler with GRPCServer or an http.Server allowing unencrypted HTTP/2:
+//
+//	h, err := g.GRPC(descriptorSet)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Fatal(gobo.GRPCServer(":50051", h).ListenAndServe())
+//
+// Generated output may be {"grpc-status": 5, "grpc-message": "not found"}
+// to answer with an error status instead. Streaming methods are answered
+// with UNIMPLEMENTED.
+func (g *Gobo) GRPC(descriptorSet []byte, opts ...RouteOption) (http.Handler, error) {
+	svc, err := parseDescriptorSet(descriptorSet)
+	if err != nil {
+		return nil, err
+	}
+	return g.Stub(nil, append(opts, routeGRPC(svc))...), nil
+}
+
+func routeGRPC(svc *grpcService) RouteOption {
+	return func(r *routeSchema) {
+		r.GRPC = svc
+	}
+}
+
+// GRPCServer returns an http.Server for h that accepts HTTP/1 and
+// unencrypted HTTP/2 (h2c), as gRPC clients connecting without TLS expect.
+func GRPCServer(addr string, h http.Handler) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Server{Addr: addr, Handler: h, Protocols: protocols}
+}
+
+// parseDescriptorSet loads the services of a serialized FileDescriptorSet.
+// Imports missing from the set, such as well-known types, are looked up in
+// the files linked into the binary.
+func parseDescriptorSet(data []byte) (*grpcService, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set: %w", err)
+	}
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(set.File))
+	for _, fd := range set.File {
+		byName[fd.GetName()] = fd
+	}
+
+	files := new(protoregistry.Files)
+	resolver := fallbackResolver{files}
+	var register func(name string, seen map[string]bool) error
+	register = func(name string, seen map[string]bool) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		fd, ok := byName[name]
+		if !ok {
+			// Left to the fallback resolver
+			return nil
+		}
+		if seen[name] {
+			return fmt.Errorf("import cycle through %s", name)
+		}
+		seen[name] = true
+		for _, dep := range fd.GetDependency() {
+			if err := register(dep, seen); err != nil {
+				return err
+			}
+		}
+		file, err := protodesc.NewFile(fd, resolver)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", name, err)
+		}
+		return files.RegisterFile(file)
+	}
+	for _, fd := range set.File {
+		if err := register(fd.GetName(), map[string]bool{}); err != nil {
+			return nil, fmt.Errorf("failed to parse descriptor set: %w", err)
+		}
+	}
+
+	svc := &grpcService{methods: make(map[string]protoreflect.MethodDescriptor), types: dynamicpb.NewTypes(files)}
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				m := methods.Get(j)
+				svc.methods["/"+string(services.Get(i).FullName())+"/"+string(m.Name())] = m
+			}
+		}
+		return true
+	})
+	if len(svc.methods) == 0 {
+		return nil, errors.New("descriptor set declares no services")
+	}
+	return svc, nil
+}
+
+// fallbackResolver resolves descriptors from the set first, then from the
+// files linked into the binary.
+type fallbackResolver struct {
+	files *protoregistry.Files
+}
+
+func (r fallbackResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r fallbackResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// grpcError is a gRPC status to answer with.
+type grpcError struct {
+	code    int
+	message string
+}
+
+func (e *grpcError) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", e.code, e.message)
+}
+
+// writeGRPC answers a gRPC unary call.
+func (g *Gobo) writeGRPC(w http.ResponseWriter, r *http.Request, route *routeSchema, reqCtx RequestContext) {
+	contentType := r.Header.Get("Content-Type")
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	if mediaType = strings.TrimSpace(mediaType); r.Method != http.MethodPost || (mediaType != "application/grpc" && mediaType != "application/grpc+proto") {
+		http.Error(w, "Gobo gRPC: expected a POST with content-type application/grpc", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	ctx := r.Context()
+	if timeout, ok := parseGRPCTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	out, backend, err := g.grpcCall(ctx, r, route, reqCtx)
+	if err == nil && route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = &grpcError{code: grpcDeadlineExceeded, message: "deadline exceeded"}
+	}
+	if backend != "" {
+		w.Header().Set(BackendHeader, backend)
+	}
+	for k, v := range route.Headers {
+		w.Header().Set(k, v)
+	}
+
+	var status *grpcError
+	if err != nil {
+		if !errors.As(err, &status) {
+			status = &grpcError{code: grpcInternal, message: err.Error()}
+		}
+		// Trailers-Only: the status goes in the headers of a bodyless response
+		w.Header().Set(grpcStatusKey, strconv.Itoa(status.code))
+		w.Header().Set(grpcMessageKey, encodeGRPCMessage(status.message))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Trailer", grpcStatusKey+", "+grpcMessageKey)
+	w.WriteHeader(http.StatusOK)
+	frame := make([]byte, 5, 5+len(out))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(out)))
+	_, _ = w.Write(append(frame, out...))
+	w.Header().Set(grpcStatusKey, strconv.Itoa(grpcOK))
+}
+
+// grpcCall decodes the request message, generates the response and
+// encodes it.
+func (g *Gobo) grpcCall(ctx context.Context, r *http.Request, route *routeSchema, reqCtx RequestContext) ([]byte, string, error) {
+	svc := route.GRPC
+	method, ok := svc.methods[grpcPath(r.URL.Path)]
+	if !ok {
+		return nil, "", &grpcError{code: grpcUnimplemented, message: "unknown method " + r.URL.Path}
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, "", &grpcError{code: grpcUnimplemented, message: "gobo mocks unary methods only"}
+	}
+
+	payload, err := readGRPCMessage(r)
+	if err != nil {
+		return nil, "", err
+	}
+	in := dynamicpb.NewMessage(method.Input())
+	if err := proto.Unmarshal(payload, in); err != nil {
+		return nil, "", &grpcError{code: grpcInvalidArgument, message: "failed to decode request: " + err.Error()}
+	}
+	body, err := protojson.MarshalOptions{Resolver: svc.types}.Marshal(in)
+	if err != nil {
+		return nil, "", &grpcError{code: grpcInternal, message: "failed to encode request as JSON: " + err.Error()}
+	}
+
+	service := method.Parent().(protoreflect.ServiceDescriptor)
+	reqCtx.Body, reqCtx.Form, reqCtx.Files, reqCtx.BodyTruncated = string(body), nil, nil, false
+	reqCtx.GRPC = &GRPCCall{Service: string(service.FullName()), Method: string(method.Name())}
+	key := routeKey(r, route)
+	reqCtx.Examples = g.examplesFor(key, route)
+	reqCtx.Prompt = g.promptFor(key, route)
+	gen := g.generator(route)
+	if gen == nil {
+		gen = StaticGenerator{}
+	}
+
+	genCtx, backend := withBackend(ctx)
+	generated, err := gen.GenerateResponse(genCtx, reqCtx, messageSchema(method.Output()))
+	if errors.Is(err, ErrOverloaded) {
+		return nil, *backend, &grpcError{code: grpcUnavailable, message: "Gobo Mock Generation Overloaded: " + err.Error()}
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, *backend, ctx.Err()
+	}
+	if err != nil {
+		g.logf("Error generating response: %v", err)
+		return nil, *backend, &grpcError{code: grpcInternal, message: "Gobo Mock Generation Failed: " + err.Error()}
+	}
+	if status := generatedGRPCStatus(generated); status != nil {
+		return nil, *backend, status
+	}
+
+	out := dynamicpb.NewMessage(method.Output())
+	opts := protojson.UnmarshalOptions{DiscardUnknown: true, Resolver: svc.types}
+	if err := opts.Unmarshal(pruneOneofs(generated, method.Output()), out); err != nil {
+		return nil, *backend, &grpcError{code: grpcInternal, message: "Gobo Mock Generation Failed: response does not match " + string(method.Output().FullName()) + ": " + err.Error()}
+	}
+	encoded, err := proto.Marshal(out)
+	if err != nil {
+		return nil, *backend, &grpcError{code: grpcInternal, message: "failed to encode response: " + err.Error()}
+	}
+	return encoded, *backend, nil
+}
+
+// grpcPath returns the "/package.Service/Method" tail of a request path,
+// so the handler may be mounted under a prefix.
+func grpcPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 {
+		return path
+	}
+	return "/" + parts[len(parts)-2] + "/" + parts[len(parts)-1]
+}
+
+// readGRPCMessage reads the single length-prefixed message of a unary call.
+func readGRPCMessage(r *http.Request) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r.Body, header[:]); err != nil {
+		return nil, &grpcError{code: grpcInvalidArgument, message: "missing request message"}
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > grpcMaxMessageSize {
+		return nil, &grpcError{code: grpcResourceExhausted, message: fmt.Sprintf("request message larger than %d bytes", grpcMaxMessageSize)}
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r.Body, payload); err != nil {
+		return nil, &grpcError{code: grpcInvalidArgument, message: "truncated request message"}
+	}
+	if header[0] == 0 {
+		return payload, nil
+	}
+	if enc := r.Header.Get("Grpc-Encoding"); enc != "gzip" {
+		return nil, &grpcError{code: grpcUnimplemented, message: fmt.Sprintf("unsupported grpc-encoding %q", enc)}
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, &grpcError{code: grpcInvalidArgument, message: "invalid gzip message"}
+	}
+	defer zr.Close()
+	payload, err = io.ReadAll(io.LimitReader(zr, grpcMaxMessageSize+1))
+	if err != nil {
+		return nil, &grpcError{code: grpcInvalidArgument, message: "invalid gzip message"}
+	}
+	if len(payload) > grpcMaxMessageSize {
+		return nil, &grpcError{code: grpcResourceExhausted, message: fmt.Sprintf("request message larger than %d bytes", grpcMaxMessageSize)}
+	}
+	return payload, nil
+}
+
+// generatedGRPCStatus returns the error status of generated output of the
+// form {"grpc-status": 5, "grpc-message": "..."}, if it is one.
+func generatedGRPCStatus(generated []byte) *grpcError {
+	var status struct {
+		Code    *int   `json:"grpc-status"`
+		Message string `json:"grpc-message"`
+	}
+	if json.Unmarshal(generated, &status) != nil || status.Code == nil || *status.Code == grpcOK {
+		return nil
+	}
+	return &grpcError{code: *status.Code, message: status.Message}
+}
+
+// parseGRPCTimeout parses a grpc-timeout header such as "250m".
+func parseGRPCTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second, 'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond}
+	unit, ok := units[v[len(v)-1]]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// encodeGRPCMessage percent-encodes a grpc-message value.
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// messageSchema describes the protojson form of a message as a JSON Schema.
+func messageSchema(md protoreflect.MessageDescriptor) JSONSchema {
+	return JSONSchema(messageNode(md, 0))
+}
+
+func messageNode(md protoreflect.MessageDescriptor, depth int) map[string]any {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration":
+		return map[string]any{"type": "string", "description": `A duration in seconds, such as "1.5s"`}
+	case "google.protobuf.FieldMask":
+		return map[string]any{"type": "string", "description": "Comma-separated field paths"}
+	case "google.protobuf.Struct":
+		return map[string]any{"type": "object"}
+	case "google.protobuf.Value":
+		return map[string]any{}
+	case "google.protobuf.ListValue":
+		return map[string]any{"type": "array"}
+	case "google.protobuf.Empty":
+		return map[string]any{"type": "object", "properties": map[string]any{}}
+	case "google.protobuf.Any":
+		return map[string]any{"type": "object", "properties": map[string]any{"@type": map[string]any{"type": "string"}}, "required": []any{"@type"}}
+	}
+	if strings.HasPrefix(string(md.FullName()), "google.protobuf.") && strings.HasSuffix(string(md.Name()), "Value") {
+		// Wrappers are their bare value
+		return fieldNode(md.Fields().ByName("value"), depth)
+	}
+	if depth >= grpcMessageDepthLimit {
+		return map[string]any{"type": "object"}
+	}
+
+	props := make(map[string]any)
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		var node map[string]any
+		switch {
+		case fd.IsMap():
+			node = map[string]any{"type": "object", "additionalProperties": fieldNode(fd.MapValue(), depth+1)}
+		case fd.IsList():
+			node = map[string]any{"type": "array", "items": fieldNode(fd, depth+1)}
+		default:
+			node = fieldNode(fd, depth+1)
+		}
+		if desc := protoComment(fd); desc != "" {
+			node["description"] = desc
+		}
+		if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			note := "Set at most one field of the " + string(oneof.Name()) + " group"
+			if desc, ok := node["description"].(string); ok {
+				note = desc + ". " + note
+			}
+			node["description"] = note
+		}
+		props[fd.JSONName()] = node
+	}
+	node := map[string]any{"type": "object", "properties": props}
+	if desc := protoComment(md); desc != "" {
+		node["description"] = desc
+	}
+	return node
+}
+
+// fieldNode describes a single value of a field.
+func fieldNode(fd protoreflect.FieldDescriptor, depth int) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]any{"type": "integer"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return map[string]any{"type": "number"}
+	case protoreflect.StringKind:
+		return map[string]any{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]any, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageNode(fd.Message(), depth)
+	}
+	return map[string]any{}
+}
+
+// protoComment returns the leading comment of a descriptor, available when
+// the set was built with --include_source_info.
+func protoComment(d protoreflect.Descriptor) string {
+	loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+	return strings.TrimSpace(loc.LeadingComments)
+}
+
+// pruneOneofs drops all but the first field set in each oneof group, as
+// generators may fill every field of a group and protojson rejects that.
+func pruneOneofs(generated []byte, md protoreflect.MessageDescriptor) []byte {
+	var value any
+	if json.Unmarshal(generated, &value) != nil {
+		return generated
+	}
+	if !pruneMessage(value, md) {
+		return generated
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return generated
+	}
+	return out
+}
+
+// pruneMessage prunes a decoded message in place and reports whether it
+// changed anything.
+func pruneMessage(value any, md protoreflect.MessageDescriptor) bool {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	changed := false
+	oneofs := md.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		oneof := oneofs.Get(i)
+		if oneof.IsSynthetic() {
+			continue
+		}
+		set := false
+		fields := oneof.Fields()
+		for j := 0; j < fields.Len(); j++ {
+			name := fields.Get(j).JSONName()
+			if v, ok := obj[name]; ok && v != nil {
+				if set {
+					delete(obj, name)
+					changed = true
+				}
+				set = true
+			}
+		}
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Message() == nil || fd.IsMap() {
+			continue
+		}
+		v, ok := obj[fd.JSONName()]
+		if !ok {
+			continue
+		}
+		if list, isList := v.([]any); isList && fd.IsList() {
+			for _, item := range list {
+				changed = pruneMessage(item, fd.Message()) || changed
+			}
+		} else {
+			changed = pruneMessage(v, fd.Message()) || changed
+		}
+	}
+	return changed
+}
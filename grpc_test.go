@@ -0,0 +1,263 @@
+package gobo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// testDescriptorSet describes:
+//
+//	package users.v1;
+//	enum Role { ROLE_UNSPECIFIED = 0; ROLE_ADMIN = 1; }
+//	message GetUserRequest { string id = 1; }
+//	message User {
+//	  string id = 1; string name = 2; int64 age = 3; repeated string tags = 4; Role role = 5;
+//	  oneof contact { string email = 6; string phone = 7; }
+//	}
+//	service UserService {
+//	  rpc GetUser(GetUserRequest) returns (User);
+//	  rpc WatchUsers(GetUserRequest) returns (stream User);
+//	}
+func testDescriptorSet(t *testing.T) ([]byte, protoreflect.FileDescriptor) {
+	t.Helper()
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(num), Type: typ.Enum(), Label: label.Enum(), JsonName: proto.String(name)}
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	role := field("role", 5, descriptorpb.FieldDescriptorProto_TYPE_ENUM, optional)
+	role.TypeName = proto.String(".users.v1.Role")
+	email, phone := field("email", 6, str, optional), field("phone", 7, str, optional)
+	email.OneofIndex, phone.OneofIndex = proto.Int32(0), proto.Int32(0)
+
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("users/v1/users.proto"),
+		Package: proto.String("users.v1"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Role"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("ROLE_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("ROLE_ADMIN"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetUserRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, str, optional)}},
+			{
+				Name: proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, str, optional),
+					field("name", 2, str, optional),
+					field("age", 3, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional),
+					field("tags", 4, str, descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+					role, email, phone,
+				},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("contact")}},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("UserService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("GetUser"), InputType: proto.String(".users.v1.GetUserRequest"), OutputType: proto.String(".users.v1.User")},
+				{Name: proto.String("WatchUsers"), InputType: proto.String(".users.v1.GetUserRequest"), OutputType: proto.String(".users.v1.User"), ServerStreaming: proto.Bool(true)},
+			},
+		}},
+	}
+	file, err := protodesc.NewFile(fd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, file
+}
+
+// grpcServer serves h over h2c and returns an h2c client for it.
+func grpcServer(t *testing.T, h http.Handler) (*httptest.Server, *http.Client) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config.Protocols = GRPCServer("", h).Protocols
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	return srv, &http.Client{Transport: &http.Transport{Protocols: protocols}}
+}
+
+// grpcInvoke makes a unary call and returns the response message bytes
+// and the grpc-status and grpc-message.
+func grpcInvoke(t *testing.T, client *http.Client, url string, msg proto.Message, header http.Header) ([]byte, string, string) {
+	t.Helper()
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(append(frame, payload...)))
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	status, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		// Trailers-Only response
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if len(body) < 5 {
+		return nil, status, message
+	}
+	return body[5:], status, message
+}
+
+func TestGRPC_Unary(t *testing.T) {
+	set, file := testDescriptorSet(t)
+	gen := &scriptedGenerator{out: `{"id":"u1","name":"Ada","age":36,"tags":["x"],"role":"ROLE_ADMIN","email":"a@b.c","phone":"123"}`}
+	h, err := New(WithGenerator(gen)).GRPC(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, client := grpcServer(t, h)
+
+	in := dynamicpb.NewMessage(file.Messages().ByName("GetUserRequest"))
+	in.Set(in.Descriptor().Fields().ByName("id"), protoreflect.ValueOfString("u1"))
+	body, status, message := grpcInvoke(t, client, srv.URL+"/users.v1.UserService/GetUser", in, nil)
+	if status != "0" {
+		t.Fatalf("Expected OK, got %s %s", status, message)
+	}
+
+	out := dynamicpb.NewMessage(file.Messages().ByName("User"))
+	if err := proto.Unmarshal(body, out); err != nil {
+		t.Fatal(err)
+	}
+	fields := out.Descriptor().Fields()
+	if out.Get(fields.ByName("name")).String() != "Ada" || out.Get(fields.ByName("age")).Int() != 36 || out.Get(fields.ByName("role")).Enum() != 1 {
+		t.Errorf("Unexpected response %v", out)
+	}
+	if out.Get(fields.ByName("email")).String() != "a@b.c" || out.Has(fields.ByName("phone")) {
+		t.Errorf("Expected only the first oneof field, got %v", out)
+	}
+
+	if gen.reqCtx.GRPC == nil || gen.reqCtx.GRPC.Service != "users.v1.UserService" || gen.reqCtx.GRPC.Method != "GetUser" {
+		t.Errorf("Unexpected call %+v", gen.reqCtx.GRPC)
+	}
+	if gen.reqCtx.Body != `{"id":"u1"}` {
+		t.Errorf("Expected the request as JSON, got %s", gen.reqCtx.Body)
+	}
+}
+
+func TestGRPC_Errors(t *testing.T) {
+	set, file := testDescriptorSet(t)
+	gen := &scriptedGenerator{out: `{"grpc-status": 5, "grpc-message": "user not found: 100%"}`}
+	h, err := New(WithGenerator(gen)).GRPC(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, client := grpcServer(t, h)
+	in := dynamicpb.NewMessage(file.Messages().ByName("GetUserRequest"))
+
+	tests := []struct {
+		name    string
+		path    string
+		out     string
+		status  string
+		message string
+	}{
+		{"generated status", "/users.v1.UserService/GetUser", gen.out, "5", "user not found: 100%25"},
+		{"shape mismatch", "/users.v1.UserService/GetUser", `{"age":"old"}`, "13", ""},
+		{"unknown method", "/users.v1.UserService/DeleteUser", "{}", "12", ""},
+		{"streaming", "/users.v1.UserService/WatchUsers", "{}", "12", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen.out = tt.out
+			_, status, message := grpcInvoke(t, client, srv.URL+tt.path, in, nil)
+			if status != tt.status || (tt.message != "" && message != tt.message) {
+				t.Errorf("Expected status %s %q, got %s %q", tt.status, tt.message, status, message)
+			}
+		})
+	}
+
+	slow := New(WithGenerator(&scriptedGenerator{out: "{}"}))
+	h, _ = slow.GRPC(set, RouteLatency(time.Second))
+	srv, client = grpcServer(t, h)
+	_, status, _ := grpcInvoke(t, client, srv.URL+"/users.v1.UserService/GetUser", in, http.Header{"Grpc-Timeout": {"20m"}})
+	if status != "4" {
+		t.Errorf("Expected DEADLINE_EXCEEDED, got %s", status)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/users.v1.UserService/GetUser", strings.NewReader("{}")))
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415 for a non-gRPC request, got %d", rec.Code)
+	}
+}
+
+func TestGRPC_SchemaAndBroker(t *testing.T) {
+	set, file := testDescriptorSet(t)
+	broker := NewAsyncBroker()
+	h, _ := New(WithGenerator(broker)).GRPC(set)
+	srv, client := grpcServer(t, h)
+
+	done := make(chan string)
+	go func() {
+		in := dynamicpb.NewMessage(file.Messages().ByName("GetUserRequest"))
+		_, status, _ := grpcInvoke(t, client, srv.URL+"/users.v1.UserService/GetUser", in, nil)
+		done <- status
+	}()
+
+	var pending []PendingRequest
+	for deadline := time.Now().Add(time.Second); len(pending) == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		pending = broker.GetPendingRequests()
+	}
+	if len(pending) != 1 || pending[0].Operation != "rpc users.v1.UserService/GetUser" {
+		t.Fatalf("Expected the method as the operation, got %+v", pending)
+	}
+	props := pending[0].Shape["properties"].(map[string]any)
+	if props["age"].(map[string]any)["type"] != "integer" || props["tags"].(map[string]any)["type"] != "array" {
+		t.Errorf("Unexpected shape %v", props)
+	}
+	if enum := props["role"].(map[string]any)["enum"].([]any); len(enum) != 2 || enum[1] != "ROLE_ADMIN" {
+		t.Errorf("Expected the enum values, got %v", enum)
+	}
+	_ = broker.SubmitResponse(pending[0].ID, []byte(`{"name":"Grace"}`))
+	if status := <-done; status != "0" {
+		t.Errorf("Expected OK, got %s", status)
+	}
+}
+
+func TestParseDescriptorSet_Invalid(t *testing.T) {
+	if _, err := New().GRPC([]byte("not a descriptor set")); err == nil {
+		t.Error("Expected an error for garbage")
+	}
+	empty, _ := proto.Marshal(&descriptorpb.FileDescriptorSet{})
+	if _, err := New().GRPC(empty); err == nil {
+		t.Error("Expected an error without services")
+	}
+}
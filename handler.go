@@ -2,58 +2,262 @@ package gobo
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // Stub returns an http.Handler for routes that have no real backend.
 // When a generator is configured, it uses the generator to produce a response
 // based on the request context and schema. When no generator is set, it
-// marshals the schema struct as-is (static stub fallback).
-func (g *Gobo) Stub(schema any) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+// marshals the schema struct as-is (static stub fallback). Route options
+// customize the generator, status, latency and more, see RouteOption.
+func (g *Gobo) Stub(schema any, opts ...RouteOption) http.Handler {
+	route := newRoute(schema, opts)
+	return &routeHandler{route: route, serve: func(w http.ResponseWriter, r *http.Request) {
 		g.logf("Stub handling %s %s", r.Method, r.URL.Path)
-
-		if g.client != nil {
-			g.generateAndWrite(w, r, schema)
-			return
-		}
-
-		// No generator — return the schema struct as static JSON
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(schema)
-	})
+		g.observe(r, route)
+		g.generateAndWrite(w, r, g.override(r, route), nil)
+	}}
 }
 
 // Intercept wraps a real http.Handler. When a generator is active, it
 // intercepts the request and generates a response using the schema. When no
 // generator is configured, it passes through to the real handler unchanged.
-func (g *Gobo) Intercept(handler http.Handler, schema any) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if g.client != nil {
+// In recording mode (see WithRecording) the real handler's responses, or the
+// upstream's, are recorded as examples; offline, routes without a generator
+// replay their recordings and pass through when there are none. A route
+// generator set with RouteGenerator makes the route mock even without an
+// instance generator.
+func (g *Gobo) Intercept(handler http.Handler, schema any, opts ...RouteOption) http.Handler {
+	route := newRoute(schema, opts)
+	return &routeHandler{route: route, serve: func(w http.ResponseWriter, r *http.Request) {
+		g.observe(r, route)
+		active := g.override(r, route)
+		if g.generator(active) != nil || g.recorder.online() || g.replays(r, active) {
 			g.logf("Intercepting %s %s", r.Method, r.URL.Path)
-			g.generateAndWrite(w, r, schema)
+			g.generateAndWrite(w, r, active, handler)
 			return
 		}
 
 		// No generator — pass through to real handler
 		handler.ServeHTTP(w, r)
-	})
+	}}
+}
+
+// replays reports whether offline recording mode has a recording to replay
+// for the route.
+func (g *Gobo) replays(r *http.Request, route *routeSchema) bool {
+	_, ok := g.recorder.replay(routeKey(r, route))
+	return ok
+}
+
+// override returns the configuration file route matching the request, if
+// any, so QA can retarget handler-based routes without recompiling.
+// Otherwise it returns the handler's own route unchanged.
+func (g *Gobo) override(r *http.Request, route *routeSchema) *routeSchema {
+	if fileRoute := g.matchFile(r); fileRoute != nil {
+		return fileRoute
+	}
+	return route
+}
+
+// Mux is a router handlers can be mounted on, such as http.ServeMux.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Handle mounts h on mux under pattern. Stub, Intercept and other Gobo
+// handlers mounted this way are described by the OpenAPI export, and their
+// webhooks are known to WebhookNamed, from the start; handlers mounted
+// directly on the mux are only learned once they have served a request.
+//
+//	g.Handle(mux, "GET /users/{id}", g.Stub(User{}))
+func (g *Gobo) Handle(mux Mux, pattern string, h http.Handler) {
+	mux.Handle(pattern, h)
+	if rh, ok := h.(*routeHandler); ok {
+		g.describe(pattern, rh.route)
+	}
+}
+
+// routeHandler is a Gobo handler serving a route, so Handle can describe it.
+type routeHandler struct {
+	route *routeSchema
+	serve http.HandlerFunc
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r)
+}
+
+// observe records a handler's route under the ServeMux pattern that routed
+// the request, so handler-based routes show up in the OpenAPI export.
+func (g *Gobo) observe(r *http.Request, route *routeSchema) {
+	if r.Pattern == "" {
+		return
+	}
+	g.mu.RLock()
+	_, known := g.handlerRoutes[r.Pattern]
+	g.mu.RUnlock()
+	if !known {
+		g.describe(r.Pattern, route)
+	}
+}
+
+// describe records a handler's route under its ServeMux pattern.
+func (g *Gobo) describe(pattern string, route *routeSchema) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.handlerRoutes[pattern]; ok {
+		return
+	}
+
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = "ANY", pattern
+	}
+	// Patterns may carry a host ("example.com/users"); keep only the path.
+	if i := strings.Index(path, "/"); i > 0 {
+		path = path[i:]
+	}
+	observed := *route
+	observed.Method, observed.PathPrefix = method, path
+	g.handlerRoutes[pattern] = &observed
 }
 
 // generateAndWrite extracts request context, calls the generator, and writes
-// the JSON response. Shared by Stub, Intercept, and Middleware.
-func (g *Gobo) generateAndWrite(w http.ResponseWriter, r *http.Request, schema any) {
-	reqContext := extractRequestContext(r)
+// the response in the route's negotiated format (JSON by default). Shared
+// by Stub, Intercept, and Middleware. Without a generator the schema itself
+// is written as static JSON, or the latest recording is replayed in offline
+// recording mode. real is the route's real handler, if it has one, used as
+// the source while recording.
+func (g *Gobo) generateAndWrite(w http.ResponseWriter, r *http.Request, route *routeSchema, real http.Handler) {
+	reqContext := extractRequestContext(r, g.maxBody)
+	if route.Request != nil {
+		result := route.Request.validate(r, reqContext)
+		if !result.Valid && !route.Request.PassThrough {
+			g.writeValidationError(w, r, result)
+			return
+		}
+		reqContext.Validation = &result
+	}
+	if route.Artifact != nil {
+		g.writeArtifact(w, r, route, reqContext)
+		return
+	}
+	if route.GraphQL != nil {
+		g.writeGraphQL(w, r, route, reqContext)
+		return
+	}
+	if route.RPC != nil {
+		g.writeRPC(w, r, route, reqContext)
+		return
+	}
+	if route.GRPC != nil {
+		g.writeGRPC(w, r, route, reqContext)
+		return
+	}
+	if route.Collection != nil {
+		g.writeCollection(w, r, route, reqContext)
+		return
+	}
+
+	key := routeKey(r, route)
+	if g.recorder.online() {
+		if source := g.recorder.source(real); source != nil {
+			g.logf("Recording %s %s as %s", r.Method, r.URL.Path, key)
+			g.recorder.capture(w, r, key, reqContext, source)
+			return
+		}
+	}
+	reqContext.Examples = g.examplesFor(key, route)
+	reqContext.Prompt = g.promptFor(key, route)
+
+	gen := g.generator(route)
+	if latest, ok := g.recorder.replay(key); gen == nil && ok {
+		if latest.Status == 0 {
+			latest.Status = http.StatusOK
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(latest.Status)
+		_, _ = w.Write(latest.Response)
+		return
+	}
+	static := gen == nil
+	if static {
+		gen = StaticGenerator{}
+	}
+	if route.Stream {
+		g.writeStream(w, r, route, gen, reqContext)
+		return
+	}
+
+	format := negotiateFormat(route.Formats, r.Header.Get("Accept"))
+	genCtx, backend := withBackend(withFormat(r.Context(), format))
+	genCtx, triggers := withWebhookTriggers(genCtx)
+	var responseBytes []byte
+	if pooled, ok := g.prefetched(r, key, route, gen, reqContext); ok {
+		responseBytes, *backend = pooled.body, pooled.backend
+	} else {
+		var err error
+		responseBytes, err = gen.GenerateResponse(genCtx, reqContext, route.ResponseSchema)
+		if errors.Is(err, ErrOverloaded) {
+			g.logf("Shedding %s %s: %v", r.Method, r.URL.Path, err)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Gobo Mock Generation Overloaded: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			g.logf("Error generating response: %v", err)
+			http.Error(w, "Gobo Mock Generation Failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
 
-	responseBytes, err := g.client.GenerateResponse(r.Context(), reqContext, schema)
+	body, err := format.Encode(responseBytes, route.ResponseSchema)
 	if err != nil {
-		g.logf("Error generating response: %v", err)
+		g.logf("Error encoding %s response: %v", format.Name, err)
 		http.Error(w, "Gobo Mock Generation Failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if static && format.Name == FormatJSON.Name {
+		// Static stubs have always been written by a json.Encoder
+		body = append(body, '\n')
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	if len(route.Formats) > 1 {
+		w.Header().Add("Vary", "Accept")
+	}
+	if *backend != "" {
+		w.Header().Set(BackendHeader, *backend)
+	}
+	for k, v := range route.Headers {
+		w.Header().Set(k, v)
+	}
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+	g.fireWebhooks(r, route, reqContext, responseBytes, triggers)
+}
 
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(responseBytes)
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
 }
@@ -0,0 +1,309 @@
+package gobo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+	// RPCServerOverloaded is reported when a limited generator sheds a call.
+	RPCServerOverloaded = -32000
+)
+
+// RPCMethod describes a JSON-RPC method of a mocked service.
+type RPCMethod struct {
+	// Result is the schema of the method's result.
+	Result any
+	// Params, when set, validates named (object) params.
+	Params any
+	// Error makes every call fail with this error object.
+	Error *RPCError
+}
+
+// RPCError is a JSON-RPC error object.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// RPCCall describes the JSON-RPC call behind a request, see
+// RequestContext.RPC.
+type RPCCall struct {
+	Method string          `json:"method"`
+	ID     json.RawMessage `json:"id,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcRequest is a decoded JSON-RPC request object.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcResponse is a JSON-RPC response object.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// JSONRPC returns an http.Handler mocking a JSON-RPC 2.0 service. Calls are
+// dispatched on their method, and the generator produces each result from
+// the method's Result schema, wrapped in a {"jsonrpc":"2.0","id":...}
+// envelope. Batches are answered in order, notifications (calls without an
+// id) get no response, and unknown methods or malformed calls are answered
+// with the standard error objects. Every call is a separate request to the
+// generator, with RequestContext.RPC set and the call as the body.
+//
+//	mux.Handle("POST /rpc", g.JSONRPC(map[string]gobo.RPCMethod{
+//	    "eth_blockNumber": {Result: "0x10d4f"},
+//	    "eth_getBalance":  {Result: "0x0234c8a3397aab58"},
+//	}))
+func (g *Gobo) JSONRPC(methods map[string]RPCMethod, opts ...RouteOption) http.Handler {
+	return g.Stub(nil, append(opts, routeRPC(methods))...)
+}
+
+func routeRPC(methods map[string]RPCMethod) RouteOption {
+	return func(r *routeSchema) {
+		r.RPC = methods
+	}
+}
+
+// writeRPC answers a request to a JSON-RPC route.
+func (g *Gobo) writeRPC(w http.ResponseWriter, r *http.Request, route *routeSchema, reqCtx RequestContext) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Gobo JSON-RPC: requests must use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body := bytes.TrimSpace([]byte(reqCtx.Body))
+	if reqCtx.BodyTruncated {
+		writeJSON(w, http.StatusOK, rpcFailure(nil, RPCInvalidRequest, "Invalid Request: body exceeds the capture limit"))
+		return
+	}
+
+	var calls []json.RawMessage
+	batch := len(body) > 0 && body[0] == '['
+	if batch {
+		if err := json.Unmarshal(body, &calls); err != nil {
+			writeJSON(w, http.StatusOK, rpcFailure(nil, RPCParseError, "Parse error"))
+			return
+		}
+		if len(calls) == 0 {
+			writeJSON(w, http.StatusOK, rpcFailure(nil, RPCInvalidRequest, "Invalid Request: empty batch"))
+			return
+		}
+	} else {
+		if !json.Valid(body) {
+			writeJSON(w, http.StatusOK, rpcFailure(nil, RPCParseError, "Parse error"))
+			return
+		}
+		calls = []json.RawMessage{body}
+	}
+
+	key := routeKey(r, route)
+	reqCtx.Examples = g.examplesFor(key, route)
+	reqCtx.Prompt = g.promptFor(key, route)
+	gen := g.generator(route)
+	if gen == nil {
+		gen = StaticGenerator{}
+	}
+	genCtx, backend := withBackend(r.Context())
+
+	// Calls of a batch are answered by a few workers, so an agent sees
+	// several at once without limits and brokers being flooded
+	responses := make([]*rpcResponse, len(calls))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(4, len(calls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				responses[i] = g.rpcCall(genCtx, route.RPC, gen, reqCtx, calls[i])
+			}
+		}()
+	}
+	for i := range calls {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if *backend != "" {
+		w.Header().Set(BackendHeader, *backend)
+	}
+	for k, v := range route.Headers {
+		w.Header().Set(k, v)
+	}
+
+	var out []*rpcResponse
+	for _, resp := range responses {
+		if resp != nil {
+			out = append(out, resp)
+		}
+	}
+	switch {
+	case len(out) == 0:
+		// Only notifications: nothing to answer
+		w.WriteHeader(http.StatusNoContent)
+	case batch:
+		writeJSON(w, http.StatusOK, out)
+	default:
+		writeJSON(w, http.StatusOK, out[0])
+	}
+}
+
+// rpcCall answers a single call; nil means it was a notification.
+func (g *Gobo) rpcCall(ctx context.Context, methods map[string]RPCMethod, gen Generator, reqCtx RequestContext, raw json.RawMessage) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" || !validRPCID(req.ID) {
+		return rpcFailure(nil, RPCInvalidRequest, "Invalid Request")
+	}
+	notification := req.ID == nil
+	respond := func(resp *rpcResponse) *rpcResponse {
+		if notification {
+			return nil
+		}
+		resp.ID = req.ID
+		return resp
+	}
+
+	method, ok := methods[req.Method]
+	if !ok {
+		return respond(rpcFailure(nil, RPCMethodNotFound, "Method not found: "+req.Method))
+	}
+	if err := checkRPCParams(req.Params, method.Params); err != nil {
+		return respond(err)
+	}
+	if method.Error != nil {
+		return respond(&rpcResponse{JSONRPC: "2.0", Error: method.Error})
+	}
+	if notification {
+		g.logf("JSON-RPC notification %s", req.Method)
+		return nil
+	}
+
+	reqCtx.Body = string(raw)
+	reqCtx.RPC = &RPCCall{Method: req.Method, ID: req.ID, Params: req.Params}
+	out, err := gen.GenerateResponse(ctx, reqCtx, method.Result)
+	if errors.Is(err, ErrOverloaded) {
+		return respond(rpcFailure(nil, RPCServerOverloaded, "Gobo Mock Generation Overloaded: "+err.Error()))
+	}
+	if err != nil {
+		g.logf("Error generating response: %v", err)
+		return respond(rpcFailure(nil, RPCInternalError, "Gobo Mock Generation Failed: "+err.Error()))
+	}
+	return respond(rpcResult(out, method.Result))
+}
+
+// rpcResult wraps generated output. Agents may answer with {"error": {...}}
+// or {"result": ...} to pick the envelope themselves, unless the result
+// schema has a property of that name.
+func rpcResult(out []byte, schema any) *rpcResponse {
+	var parts map[string]json.RawMessage
+	if json.Unmarshal(out, &parts) == nil && len(parts) == 1 && !rpcResultField(parts, schema) {
+		if raw, ok := parts["error"]; ok {
+			var rpcErr RPCError
+			if json.Unmarshal(raw, &rpcErr) == nil && rpcErr.Message != "" {
+				return &rpcResponse{JSONRPC: "2.0", Error: &rpcErr}
+			}
+		}
+		if raw, ok := parts["result"]; ok {
+			out = raw
+		}
+	}
+	if !json.Valid(out) {
+		return rpcFailure(nil, RPCInternalError, "Gobo Mock Generation Failed: result is not valid JSON")
+	}
+	return &rpcResponse{JSONRPC: "2.0", Result: out}
+}
+
+// rpcResultField reports whether the single key of parts is a property of
+// the result schema rather than an envelope.
+func rpcResultField(parts map[string]json.RawMessage, schema any) bool {
+	if schema == nil {
+		return false
+	}
+	props, _ := jsonSchemaFor(schema)["properties"].(map[string]any)
+	for k := range parts {
+		if _, ok := props[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRPCParams checks that params are structured and, for named params,
+// match the method's schema.
+func checkRPCParams(params json.RawMessage, schema any) *rpcResponse {
+	if len(params) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(params, &value); err != nil {
+		return rpcFailure(nil, RPCInvalidParams, "Invalid params")
+	}
+	switch value.(type) {
+	case []any:
+		return nil
+	case map[string]any:
+	default:
+		return rpcFailure(nil, RPCInvalidParams, "Invalid params: must be an array or an object")
+	}
+	if schema == nil {
+		return nil
+	}
+	violations := validateSchema(jsonSchemaFor(schema), value, "$")
+	if len(violations) == 0 {
+		return nil
+	}
+	resp := rpcFailure(nil, RPCInvalidParams, "Invalid params")
+	resp.Error.Data = violations
+	return resp
+}
+
+// validRPCID reports whether id is absent, a string, a number or null.
+func validRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	var v any
+	if json.Unmarshal(id, &v) != nil {
+		return false
+	}
+	switch v.(type) {
+	case string, float64, nil:
+		return true
+	}
+	return false
+}
+
+func rpcFailure(id json.RawMessage, code int, message string) *rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", Error: &RPCError{Code: code, Message: message}, ID: id}
+}
@@ -0,0 +1,241 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testRPCMethods = map[string]RPCMethod{
+	"eth_blockNumber": {Result: "0x10d4f"},
+	"user.get": {
+		Result: map[string]any{"id": 1, "name": "Ada"},
+		Params: JSONSchema{
+			"type":       "object",
+			"properties": map[string]any{"id": map[string]any{"type": "integer"}},
+			"required":   []any{"id"},
+		},
+	},
+	"tx.send": {Error: &RPCError{Code: -32000, Message: "insufficient funds"}},
+}
+
+func rpcPost(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/rpc", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeRPC(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid JSON response %q", rec.Body.String())
+	}
+	return resp
+}
+
+func rpcErrorCode(resp map[string]any) float64 {
+	e, _ := resp["error"].(map[string]any)
+	code, _ := e["code"].(float64)
+	return code
+}
+
+func TestJSONRPC_Call(t *testing.T) {
+	h := New().JSONRPC(testRPCMethods)
+
+	resp := decodeRPC(t, rpcPost(t, h, `{"jsonrpc":"2.0","method":"eth_blockNumber","id":7}`))
+	if resp["jsonrpc"] != "2.0" || resp["id"] != float64(7) || resp["result"] != "0x10d4f" {
+		t.Errorf("Unexpected response %v", resp)
+	}
+
+	resp = decodeRPC(t, rpcPost(t, h, `{"jsonrpc":"2.0","method":"user.get","params":{"id":1},"id":"a"}`))
+	if resp["id"] != "a" || resp["result"].(map[string]any)["name"] != "Ada" {
+		t.Errorf("Unexpected response %v", resp)
+	}
+}
+
+func TestJSONRPC_Errors(t *testing.T) {
+	h := New().JSONRPC(testRPCMethods)
+
+	tests := []struct {
+		name string
+		body string
+		code float64
+	}{
+		{"parse error", `{"jsonrpc":`, RPCParseError},
+		{"missing version", `{"method":"eth_blockNumber","id":1}`, RPCInvalidRequest},
+		{"object id", `{"jsonrpc":"2.0","method":"eth_blockNumber","id":{}}`, RPCInvalidRequest},
+		{"empty batch", `[]`, RPCInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","method":"nope","id":1}`, RPCMethodNotFound},
+		{"scalar params", `{"jsonrpc":"2.0","method":"user.get","params":3,"id":1}`, RPCInvalidParams},
+		{"params schema", `{"jsonrpc":"2.0","method":"user.get","params":{"id":"x"},"id":1}`, RPCInvalidParams},
+		{"declared error", `{"jsonrpc":"2.0","method":"tx.send","id":1}`, -32000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := rpcPost(t, h, tt.body)
+			if rec.Code != http.StatusOK {
+				t.Errorf("Expected 200, got %d", rec.Code)
+			}
+			if code := rpcErrorCode(decodeRPC(t, rec)); code != tt.code {
+				t.Errorf("Expected error code %v, got %v: %s", tt.code, code, rec.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/rpc", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", rec.Code)
+	}
+}
+
+func TestJSONRPC_BatchAndNotifications(t *testing.T) {
+	h := New().JSONRPC(testRPCMethods)
+
+	rec := rpcPost(t, h, `[
+		{"jsonrpc":"2.0","method":"eth_blockNumber","id":1},
+		{"jsonrpc":"2.0","method":"eth_blockNumber"},
+		{"jsonrpc":"2.0","method":"nope","id":2},
+		{"foo":"bar"},
+		{"jsonrpc":"2.0","method":"user.get","params":{"id":1},"id":3}
+	]`)
+	var resps []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resps); err != nil {
+		t.Fatalf("Expected an array response, got %q", rec.Body.String())
+	}
+	if len(resps) != 4 {
+		t.Fatalf("Expected 4 responses without the notification, got %d", len(resps))
+	}
+	if resps[0]["id"] != float64(1) || resps[1]["id"] != float64(2) || resps[2]["id"] != nil || resps[3]["id"] != float64(3) {
+		t.Errorf("Expected responses in call order, got %v", resps)
+	}
+	if rpcErrorCode(resps[1]) != RPCMethodNotFound || rpcErrorCode(resps[2]) != RPCInvalidRequest {
+		t.Errorf("Unexpected errors %v", resps)
+	}
+
+	rec = rpcPost(t, h, `[{"jsonrpc":"2.0","method":"eth_blockNumber"},{"jsonrpc":"2.0","method":"nope"}]`)
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("Expected 204 for only notifications, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestJSONRPC_GeneratorEnvelope(t *testing.T) {
+	gen := &scriptedGenerator{}
+	h := New(WithGenerator(gen)).JSONRPC(testRPCMethods)
+
+	gen.out = `{"error":{"code":-32001,"message":"rate limited"}}`
+	resp := decodeRPC(t, rpcPost(t, h, `{"jsonrpc":"2.0","method":"eth_blockNumber","id":1}`))
+	if rpcErrorCode(resp) != -32001 || resp["result"] != nil {
+		t.Errorf("Expected the generated error object, got %v", resp)
+	}
+
+	gen.out = `{"result":{"error":"kept"}}`
+	resp = decodeRPC(t, rpcPost(t, h, `{"jsonrpc":"2.0","method":"eth_blockNumber","id":1}`))
+	if resp["result"].(map[string]any)["error"] != "kept" {
+		t.Errorf("Expected the unwrapped result, got %v", resp)
+	}
+
+	if gen.reqCtx.RPC == nil || gen.reqCtx.RPC.Method != "eth_blockNumber" || string(gen.reqCtx.RPC.ID) != "1" {
+		t.Errorf("Expected the call in the request context, got %+v", gen.reqCtx.RPC)
+	}
+
+	// A result with an "error" property is data, not an envelope
+	h = New(WithGenerator(gen)).JSONRPC(map[string]RPCMethod{
+		"job.status": {Result: map[string]any{"error": map[string]any{"code": 0, "message": ""}}},
+	})
+	gen.out = `{"error":{"code":7,"message":"disk full"}}`
+	resp = decodeRPC(t, rpcPost(t, h, `{"jsonrpc":"2.0","method":"job.status","id":1}`))
+	if resp["error"] != nil || resp["result"].(map[string]any)["error"] == nil {
+		t.Errorf("Expected the result schema's error field as data, got %v", resp)
+	}
+}
+
+func TestJSONRPC_BatchConcurrency(t *testing.T) {
+	gen := &concurrencyGenerator{}
+	h := New(WithGenerator(gen)).JSONRPC(testRPCMethods)
+
+	calls := make([]string, 200)
+	for i := range calls {
+		calls[i] = fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_blockNumber","id":%d}`, i)
+	}
+	baseline := runtime.NumGoroutine()
+	rec := rpcPost(t, h, "["+strings.Join(calls, ",")+"]")
+	var resps []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resps); err != nil || len(resps) != len(calls) {
+		t.Fatalf("Expected %d responses, got %q", len(calls), rec.Body.String())
+	}
+	if peak := gen.peak.Load(); peak > 4 {
+		t.Errorf("Expected at most 4 concurrent calls, got %d", peak)
+	}
+	if extra := gen.goroutines.Load() - int32(baseline); extra > 20 {
+		t.Errorf("Expected a fixed pool of workers, got %d extra goroutines", extra)
+	}
+}
+
+// concurrencyGenerator records the peak number of concurrent calls and of
+// running goroutines.
+type concurrencyGenerator struct {
+	active, peak, goroutines atomic.Int32
+}
+
+func (c *concurrencyGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	if n := int32(runtime.NumGoroutine()); n > c.goroutines.Load() {
+		c.goroutines.Store(n)
+	}
+	n := c.active.Add(1)
+	defer c.active.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return []byte(`"0x1"`), nil
+}
+
+func TestJSONRPC_BrokerOperation(t *testing.T) {
+	broker := NewAsyncBroker()
+	h := New(WithGenerator(broker)).JSONRPC(testRPCMethods)
+
+	done := make(chan map[string]any)
+	go func() {
+		done <- decodeRPC(t, rpcPost(t, h, `{"jsonrpc":"2.0","method":"user.get","params":{"id":1},"id":9}`))
+	}()
+
+	var pending []PendingRequest
+	for deadline := time.Now().Add(time.Second); len(pending) == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		pending = broker.GetPendingRequests()
+	}
+	if len(pending) != 1 || pending[0].Operation != "user.get" {
+		t.Fatalf("Expected the method as the operation, got %+v", pending)
+	}
+	_ = broker.SubmitResponse(pending[0].ID, []byte(`{"id":1,"name":"Grace"}`))
+	if resp := <-done; resp["id"] != float64(9) || resp["result"].(map[string]any)["name"] != "Grace" {
+		t.Errorf("Unexpected response %v", resp)
+	}
+}
+
+// scriptedGenerator answers with out and records the request context.
+type scriptedGenerator struct {
+	out    string
+	reqCtx RequestContext
+}
+
+func (s *scriptedGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	s.reqCtx = reqCtx
+	return []byte(s.out), nil
+}
@@ -0,0 +1,662 @@
+package gobo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONSchema is a JSON Schema document describing a response shape, for
+// routes whose shape is not available as a Go struct (configuration files,
+// third-party specs).
+//
+// Gobo treats every schema as a sample value, so a JSONSchema marshals to an
+// example instance built from its "example", "default", "const" and "enum"
+// keywords, falling back to zero values. Property descriptions are surfaced
+// to generators as field instructions, just like gobo struct tags.
+type JSONSchema map[string]any
+
+// MarshalJSON renders an example instance of the schema.
+func (s JSONSchema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exampleFromSchema(s))
+}
+
+// exampleFromSchema builds a sample value satisfying the schema node.
+func exampleFromSchema(node map[string]any) any {
+	if node == nil {
+		return nil
+	}
+	for _, key := range []string{"example", "default", "const"} {
+		if v, ok := node[key]; ok {
+			return v
+		}
+	}
+	if examples, ok := schemaList(node["examples"]); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	if enum, ok := schemaList(node["enum"]); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alts, ok := schemaList(node[key]); ok && len(alts) > 0 {
+			return exampleFromSchema(asSchemaNode(alts[0]))
+		}
+	}
+	if all, ok := schemaList(node["allOf"]); ok {
+		merged := map[string]any{}
+		for _, part := range all {
+			if obj, ok := exampleFromSchema(asSchemaNode(part)).(map[string]any); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+
+	switch schemaType(node) {
+	case "object":
+		obj := map[string]any{}
+		props := schemaMap(node["properties"])
+		for name, prop := range props {
+			obj[name] = exampleFromSchema(asSchemaNode(prop))
+		}
+		return obj
+	case "array":
+		if items := asSchemaNode(node["items"]); items != nil {
+			return []any{exampleFromSchema(items)}
+		}
+		return []any{}
+	case "string":
+		return ""
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	}
+	return nil
+}
+
+// schemaType returns the node's primary "type", inferring "object" when only
+// properties are given. Type arrays such as ["string", "null"] yield the
+// first non-null entry.
+func schemaType(node map[string]any) string {
+	if t, ok := node["type"].(string); ok {
+		return t
+	}
+	if types, ok := schemaList(node["type"]); ok {
+		for _, v := range types {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := node["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// asSchemaNode converts a decoded schema value into a node map.
+func asSchemaNode(v any) map[string]any {
+	switch n := v.(type) {
+	case map[string]any:
+		return n
+	case JSONSchema:
+		return n
+	}
+	return nil
+}
+
+// schemaFields walks a JSON Schema node and collects FieldInfo, using each
+// property's description as the field instruction.
+func schemaFields(node map[string]any, prefix string) []FieldInfo {
+	var fields []FieldInfo
+
+	switch schemaType(node) {
+	case "object":
+		props := schemaMap(node["properties"])
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			prop := asSchemaNode(props[name])
+			fullName := name
+			if prefix != "" {
+				fullName = prefix + "." + name
+			}
+			typ := schemaType(prop)
+			if format, ok := prop["format"].(string); ok {
+				typ += " (" + format + ")"
+			}
+			description, _ := prop["description"].(string)
+			fields = append(fields, FieldInfo{
+				Name:        name,
+				JSONName:    fullName,
+				Type:        typ,
+				Interpreter: description,
+			})
+			fields = append(fields, schemaFields(prop, fullName)...)
+		}
+	case "array":
+		if items := asSchemaNode(node["items"]); items != nil {
+			fields = append(fields, schemaFields(items, prefix+"[*]")...)
+		}
+	}
+
+	return fields
+}
+
+// validateSchema checks a decoded JSON value against a schema node and
+// returns one Violation per problem, identified by its JSON path ("$",
+// "$.user.email", "$.items[2]"). It covers the commonly used subset of
+// JSON Schema and OpenAPI: type, nullable, enum, const, properties, required,
+// additionalProperties, items, length and range bounds, pattern, allOf,
+// anyOf and oneOf. Keywords may hold decoded JSON or Go values such as
+// []string and map[string]JSONSchema.
+func validateSchema(node map[string]any, value any, path string) []Violation {
+	if node == nil {
+		return nil
+	}
+	var errs []Violation
+
+	if value == nil {
+		if node["nullable"] == true || typeAllows(node, "null") {
+			return nil
+		}
+	}
+
+	if all, ok := schemaList(node["allOf"]); ok {
+		for _, part := range all {
+			errs = append(errs, validateSchema(asSchemaNode(part), value, path)...)
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		alts, ok := schemaList(node[key])
+		if !ok || len(alts) == 0 {
+			continue
+		}
+		matched := 0
+		for _, alt := range alts {
+			if len(validateSchema(asSchemaNode(alt), value, path)) == 0 {
+				matched++
+				if key == "anyOf" || matched > 1 {
+					break
+				}
+			}
+		}
+		switch {
+		case matched == 0:
+			errs = append(errs, Violation{Field: path, Message: "does not match any allowed schema"})
+		case key == "oneOf" && matched > 1:
+			errs = append(errs, Violation{Field: path, Message: "matches more than one schema of oneOf"})
+		}
+	}
+
+	if enum, ok := schemaList(node["enum"]); ok && !containsJSON(enum, value) {
+		errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must be one of %s", compactJSON(enum))})
+	}
+	if c, ok := node["const"]; ok && !containsJSON([]any{c}, value) {
+		errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must equal %s", compactJSON(c))})
+	}
+
+	if typ := schemaType(node); typ != "" && !typeAllows(node, jsonType(value)) {
+		errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("expected %s, got %s", typ, jsonType(value))})
+		return errs
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		props := schemaMap(node["properties"])
+		if required, ok := schemaList(node["required"]); ok {
+			for _, name := range required {
+				if s, ok := name.(string); ok {
+					if _, present := v[s]; !present {
+						errs = append(errs, Violation{Field: path + "." + s, Message: "is required"})
+					}
+				}
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := props[k]; ok {
+				errs = append(errs, validateSchema(asSchemaNode(prop), v[k], path+"."+k)...)
+				continue
+			}
+			if extra, ok := node["additionalProperties"].(bool); ok && !extra {
+				errs = append(errs, Violation{Field: path + "." + k, Message: "unexpected property"})
+			} else if extra := asSchemaNode(node["additionalProperties"]); extra != nil {
+				errs = append(errs, validateSchema(extra, v[k], path+"."+k)...)
+			}
+		}
+	case []any:
+		if n, ok := schemaNumber(node, "minItems"); ok && float64(len(v)) < n {
+			errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must have at least %v items", n)})
+		}
+		if n, ok := schemaNumber(node, "maxItems"); ok && float64(len(v)) > n {
+			errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must have at most %v items", n)})
+		}
+		if items := asSchemaNode(node["items"]); items != nil {
+			for i, item := range v {
+				errs = append(errs, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if n, ok := schemaNumber(node, "minLength"); ok && length < n {
+			errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must be at least %v characters", n)})
+		}
+		if n, ok := schemaNumber(node, "maxLength"); ok && length > n {
+			errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must be at most %v characters", n)})
+		}
+		if pattern, ok := node["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must match pattern %s", pattern)})
+			}
+		}
+	case float64:
+		if n, ok := schemaNumber(node, "minimum"); ok && v < n {
+			errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must be >= %v", n)})
+		}
+		if n, ok := schemaNumber(node, "maximum"); ok && v > n {
+			errs = append(errs, Violation{Field: path, Message: fmt.Sprintf("must be <= %v", n)})
+		}
+	}
+
+	return errs
+}
+
+// typeAllows reports whether the node's "type" keyword admits the JSON type t.
+// A node without a type admits everything.
+func typeAllows(node map[string]any, t string) bool {
+	if typ, ok := node["type"].(string); ok {
+		return typ == t || (typ == "number" && t == "integer")
+	}
+	if types, ok := schemaList(node["type"]); ok {
+		for _, v := range types {
+			if v == t || (v == "number" && t == "integer") {
+				return true
+			}
+		}
+		return false
+	}
+	return schemaType(node) == "" || schemaType(node) == t
+}
+
+// jsonType names the JSON Schema type of a decoded JSON value.
+func jsonType(v any) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// schemaNumber reads a numeric keyword from a schema node. Schemas written
+// in Go may use any numeric type, such as "maxLength": 64.
+func schemaNumber(node map[string]any, key string) (float64, bool) {
+	v := reflect.ValueOf(node[key])
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// containsJSON reports whether value deep-equals one of the candidates,
+// comparing Go-typed candidates such as int by their JSON encoding.
+func containsJSON(candidates []any, value any) bool {
+	for _, c := range candidates {
+		if reflect.DeepEqual(c, value) || compactJSON(c) == compactJSON(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaList reads a list keyword such as "required" or "enum", which Go
+// schemas may write as any slice type, such as []string.
+func schemaList(v any) ([]any, bool) {
+	if list, ok := v.([]any); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// schemaMap reads a keyword holding a map, such as "properties", which Go
+// schemas may write as map[string]JSONSchema and the like.
+func schemaMap(v any) map[string]any {
+	switch m := v.(type) {
+	case map[string]any:
+		return m
+	case JSONSchema:
+		return m
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+	m := make(map[string]any, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m
+}
+
+// compactJSON renders v for use in error messages.
+func compactJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// jsonSchemaFor derives a JSON Schema from any schema Gobo accepts: Go
+// structs and their gobo tags, maps and decoded JSON examples, JSONSchema
+// documents and Annotated wrappers. Field instructions found by reflectSchema
+// become property descriptions.
+func jsonSchemaFor(schema any) map[string]any {
+	if s, ok := schema.(JSONSchema); ok {
+		return s
+	}
+
+	inner := schema
+	if a, ok := schema.(Annotated); ok {
+		inner = a.Schema
+	}
+	var node map[string]any
+	if s, ok := inner.(JSONSchema); ok {
+		node = deepCopySchema(s)
+	} else {
+		node = valueSchema(reflect.ValueOf(inner), map[reflect.Type]bool{})
+	}
+
+	for _, f := range reflectSchema(schema) {
+		if f.Interpreter != "" {
+			if prop := schemaAtPath(node, f.JSONName); prop != nil {
+				prop["description"] = f.Interpreter
+			}
+		}
+	}
+	return node
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// valueSchema describes a Go value. Struct shapes come from their types;
+// interface-typed values (decoded JSON) are described by their contents.
+// seen guards against recursive struct types.
+func valueSchema(val reflect.Value, seen map[reflect.Type]bool) map[string]any {
+	for val.Kind() == reflect.Interface || val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			if val.Kind() == reflect.Ptr {
+				return typeSchema(val.Type().Elem(), seen)
+			}
+			return map[string]any{}
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return map[string]any{}
+	}
+
+	switch val.Kind() {
+	case reflect.Map:
+		if val.Type().Elem().Kind() != reflect.Interface || val.Type().Key().Kind() != reflect.String {
+			return typeSchema(val.Type(), seen)
+		}
+		props := map[string]any{}
+		for _, k := range val.MapKeys() {
+			props[k.String()] = valueSchema(val.MapIndex(k), seen)
+		}
+		return map[string]any{"type": "object", "properties": props}
+	case reflect.Slice, reflect.Array:
+		if val.Type().Elem().Kind() != reflect.Interface {
+			return typeSchema(val.Type(), seen)
+		}
+		node := map[string]any{"type": "array"}
+		if val.Len() > 0 {
+			node["items"] = valueSchema(val.Index(0), seen)
+		}
+		return node
+	}
+	return typeSchema(val.Type(), seen)
+}
+
+// typeSchema describes a Go type using encoding/json conventions.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": fieldSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": fieldSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := map[string]any{}
+		var required []any
+		for _, field := range jsonFields(t) {
+			props[field.name] = fieldSchema(field.typ, seen)
+			if !field.omit {
+				required = append(required, field.name)
+			}
+		}
+		node := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			node["required"] = required
+		}
+		return node
+	}
+	return map[string]any{}
+}
+
+// fieldSchema describes a struct field or element type. Pointers, slices
+// and maps encode nil as null, so their schemas allow it.
+func fieldSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	node := typeSchema(t, seen)
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if typ, ok := node["type"].(string); ok {
+			node["type"] = []any{typ, "null"}
+		}
+	}
+	return node
+}
+
+// jsonField is a struct field as encoding/json encodes it.
+type jsonField struct {
+	name   string
+	typ    reflect.Type
+	omit   bool // omitempty or omitzero
+	tagged bool
+	depth  int
+}
+
+// jsonFields lists the encoded fields of a struct, promoting the fields of
+// embedded structs the way encoding/json does: shallower fields win, then
+// tagged ones, and remaining conflicts drop the name.
+func jsonFields(t reflect.Type) []jsonField {
+	var all []jsonField
+	var walk func(t reflect.Type, depth int, visited map[reflect.Type]bool)
+	walk = func(t reflect.Type, depth int, visited map[reflect.Type]bool) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			ft := field.Type
+			if field.Anonymous {
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				// encoding/json skips embedded unexported non-structs and
+				// pointers to unexported structs
+				if !field.IsExported() && (ft.Kind() != reflect.Struct || field.Type.Kind() == reflect.Ptr) {
+					continue
+				}
+				if name == "" && ft.Kind() == reflect.Struct {
+					walk(ft, depth+1, visited)
+					continue
+				}
+			} else if !field.IsExported() {
+				continue
+			}
+			tagged := name != ""
+			if !tagged {
+				name = field.Name
+			}
+			all = append(all, jsonField{
+				name:   name,
+				typ:    field.Type,
+				omit:   strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero"),
+				tagged: tagged,
+				depth:  depth,
+			})
+		}
+	}
+	walk(t, 0, map[reflect.Type]bool{})
+
+	byName := map[string][]jsonField{}
+	var order []string
+	for _, f := range all {
+		if _, ok := byName[f.name]; !ok {
+			order = append(order, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+	fields := make([]jsonField, 0, len(order))
+	for _, name := range order {
+		if f, ok := dominantField(byName[name]); ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// dominantField picks the field encoding/json keeps among fields sharing a
+// name, if any.
+func dominantField(fields []jsonField) (jsonField, bool) {
+	var best []jsonField
+	for _, f := range fields {
+		switch {
+		case len(best) == 0 || f.depth < best[0].depth:
+			best = []jsonField{f}
+		case f.depth == best[0].depth:
+			best = append(best, f)
+		}
+	}
+	var tagged []jsonField
+	for _, f := range best {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	switch {
+	case len(best) == 1:
+		return best[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+// schemaAtPath finds the property schema for a FieldInfo.JSONName path such
+// as "user.tags[*].name".
+func schemaAtPath(node map[string]any, path string) map[string]any {
+	for _, part := range strings.Split(path, ".") {
+		name, arrays := part, 0
+		for strings.HasSuffix(name, "[*]") {
+			name = strings.TrimSuffix(name, "[*]")
+			arrays++
+		}
+		if name != "" {
+			props, _ := node["properties"].(map[string]any)
+			if node = asSchemaNode(props[name]); node == nil {
+				return nil
+			}
+		}
+		for ; arrays > 0; arrays-- {
+			if node = asSchemaNode(node["items"]); node == nil {
+				return nil
+			}
+		}
+	}
+	return node
+}
+
+// deepCopySchema copies a schema node so it can be annotated without
+// mutating the caller's document.
+func deepCopySchema(node map[string]any) map[string]any {
+	b, err := json.Marshal(map[string]any(node))
+	if err != nil {
+		return map[string]any{}
+	}
+	var out map[string]any
+	_ = json.Unmarshal(b, &out)
+	return out
+}
@@ -0,0 +1,157 @@
+package gobo
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestJSONSchema_MarshalExample(t *testing.T) {
+	schema := JSONSchema{
+		"type": "object",
+		"properties": map[string]any{
+			"id":     map[string]any{"type": "string", "example": "usr_1"},
+			"role":   map[string]any{"type": "string", "enum": []any{"admin", "user"}},
+			"count":  map[string]any{"type": "integer"},
+			"tags":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"active": map[string]any{"type": "boolean", "default": true},
+		},
+	}
+
+	out, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `{"active":true,"count":0,"id":"usr_1","role":"admin","tags":[""]}`
+	if string(out) != expected {
+		t.Errorf("Expected %s, got %s", expected, out)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := map[string]any{
+		"type":                 "object",
+		"required":             []any{"id", "items"},
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"id":    map[string]any{"type": "string", "pattern": "^ord_"},
+			"note":  map[string]any{"type": "string", "nullable": true, "maxLength": 5.0},
+			"items": map[string]any{"type": "array", "minItems": 1.0, "items": map[string]any{"type": "integer"}},
+		},
+	}
+
+	var valid any
+	_ = json.Unmarshal([]byte(`{"id":"ord_1","note":null,"items":[1,2]}`), &valid)
+	if errs := validateSchema(schema, valid, "$"); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+
+	var invalid any
+	_ = json.Unmarshal([]byte(`{"id":"x","note":"too long","items":[1.5],"extra":1}`), &invalid)
+	expected := []string{
+		"$.extra: unexpected property",
+		"$.id: must match pattern ^ord_",
+		"$.items[0]: expected integer, got number",
+		"$.note: must be at most 5 characters",
+	}
+	var got []string
+	for _, v := range validateSchema(schema, invalid, "$") {
+		got = append(got, v.String())
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Errors mismatch.\nExpected: %v\nGot:      %v", expected, got)
+	}
+
+	if errs := validateSchema(schema, map[string]any{}, "$"); len(errs) != 2 {
+		t.Errorf("Expected 2 missing-field errors, got %v", errs)
+	}
+}
+
+func TestValidateSchema_OneOfAndGoNumbers(t *testing.T) {
+	oneOf := map[string]any{"oneOf": []any{
+		map[string]any{"type": "number"},
+		map[string]any{"type": "integer"},
+	}}
+	if errs := validateSchema(oneOf, 1.5, "$"); len(errs) != 0 {
+		t.Errorf("Expected a single match to pass, got %v", errs)
+	}
+	if errs := validateSchema(oneOf, 2.0, "$"); len(errs) != 1 || errs[0].Message != "matches more than one schema of oneOf" {
+		t.Errorf("Expected a value matching both branches to fail, got %v", errs)
+	}
+
+	bounds := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "maxLength": 3},
+			"count": map[string]any{"type": "integer", "minimum": int64(1), "maximum": uint8(9)},
+			"ratio": map[string]any{"type": "number", "maximum": float32(0.5)},
+		},
+	}
+	value := map[string]any{"name": "long", "count": 10.0, "ratio": 0.75}
+	if errs := validateSchema(bounds, value, "$"); len(errs) != 3 {
+		t.Errorf("Expected Go numeric keywords to be enforced, got %v", errs)
+	}
+}
+
+func TestJSONSchemaFor_EmbeddedFields(t *testing.T) {
+	type Audit struct {
+		ID      string `json:"id"`
+		Version int
+	}
+	type Named struct {
+		Name string
+	}
+	type Label struct {
+		Name string
+	}
+	type Doc struct {
+		Audit
+		*Named
+		Label
+		Version string  `json:"version"`
+		Parent  *string `json:"parent"`
+	}
+
+	node := jsonSchemaFor(Doc{})
+	props, _ := node["properties"].(map[string]any)
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Version is promoted from Audit, while the two Names conflict
+	if want := []string{"Version", "id", "parent", "version"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected fields %v, got %v", want, names)
+	}
+	if typ := asSchemaNode(props["parent"])["type"]; !reflect.DeepEqual(typ, []any{"string", "null"}) {
+		t.Errorf("Expected a nullable pointer field, got %v", typ)
+	}
+}
+
+func TestValidateSchema_GoTypedKeywords(t *testing.T) {
+	schema := JSONSchema{
+		"type":     "object",
+		"required": []string{"name", "role"},
+		"properties": map[string]JSONSchema{
+			"name": {"type": []string{"string", "null"}},
+			"role": {"enum": []string{"admin", "user"}},
+			"size": {"anyOf": []JSONSchema{{"enum": []int{1, 2}}, {"type": "string"}}},
+		},
+	}
+	if errs := validateSchema(schema, map[string]any{}, "$"); len(errs) != 2 {
+		t.Errorf("Expected both required fields to be enforced, got %v", errs)
+	}
+
+	var valid any
+	_ = json.Unmarshal([]byte(`{"name":null,"role":"admin","size":2}`), &valid)
+	if errs := validateSchema(schema, valid, "$"); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+
+	var invalid any
+	_ = json.Unmarshal([]byte(`{"name":1,"role":"root","size":3}`), &invalid)
+	if errs := validateSchema(schema, invalid, "$"); len(errs) != 3 {
+		t.Errorf("Expected type, enum and anyOf errors, got %v", errs)
+	}
+}
@@ -0,0 +1,175 @@
+package gobo
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrOverloaded is returned by generators that shed load, such as a full
+// LimitingGenerator queue or an AsyncBroker at its parking cap. Gobo answers
+// these requests with 503 Service Unavailable.
+var ErrOverloaded = errors.New("generator overloaded")
+
+// LimitConfig bounds a LimitingGenerator.
+type LimitConfig struct {
+	// Name identifies the limiter in stats, such as "ollama".
+	Name string
+	// MaxInFlight is how many generations may run at once (default 1).
+	MaxInFlight int
+	// MaxQueue is how many requests may wait for a free slot; requests
+	// beyond it are rejected. Zero rejects as soon as all slots are busy.
+	MaxQueue int
+}
+
+// LimitStats reports a limiter's load.
+type LimitStats struct {
+	Name     string `json:"name,omitempty"`
+	InFlight int    `json:"in_flight"`
+	Queued   int    `json:"queued"`
+	// PeakQueued is the deepest the queue has been.
+	PeakQueued int   `json:"peak_queued"`
+	Completed  int64 `json:"completed"`
+	Rejected   int64 `json:"rejected"`
+	// Abandoned counts queued requests whose client gave up waiting.
+	Abandoned int64 `json:"abandoned"`
+}
+
+// LimitingGenerator caps concurrent calls to a generator, so a burst of
+// requests does not start dozens of simultaneous LLM generations:
+//
+//	gen := gobo.NewLimitingGenerator(gobo.NewOllamaGenerator(url, "llama3"), gobo.LimitConfig{MaxInFlight: 2, MaxQueue: 20})
+//
+// Requests wait in a bounded queue for a free slot, served in arrival
+// order, giving up when their context ends. Requests beyond the queue fail
+// with ErrOverloaded.
+type LimitingGenerator struct {
+	gen Generator
+	cfg LimitConfig
+
+	mu      sync.Mutex
+	waiters list.List // of chan struct{}, closed when handed a slot
+	stats   LimitStats
+}
+
+// NewLimitingGenerator wraps gen with a concurrency limit.
+func NewLimitingGenerator(gen Generator, cfg LimitConfig) *LimitingGenerator {
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 1
+	}
+	return &LimitingGenerator{
+		gen:   gen,
+		cfg:   cfg,
+		stats: LimitStats{Name: cfg.Name},
+	}
+}
+
+// GenerateResponse implements the Generator interface.
+func (l *LimitingGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer l.release()
+	return l.gen.GenerateResponse(ctx, reqCtx, schema)
+}
+
+// acquire takes a slot, queueing for one when all are busy. Free slots go
+// to queued requests first, so none waits behind later arrivals.
+func (l *LimitingGenerator) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.stats.InFlight < l.cfg.MaxInFlight && l.waiters.Len() == 0 {
+		l.stats.InFlight++
+		l.mu.Unlock()
+		return nil
+	}
+	if l.stats.Queued >= l.cfg.MaxQueue {
+		l.stats.Rejected++
+		l.mu.Unlock()
+		return fmt.Errorf("%w: %d in flight, %d queued", ErrOverloaded, l.cfg.MaxInFlight, l.cfg.MaxQueue)
+	}
+	ready := make(chan struct{})
+	el := l.waiters.PushBack(ready)
+	l.stats.Queued++
+	l.stats.PeakQueued = max(l.stats.PeakQueued, l.stats.Queued)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.stats.Abandoned++
+		select {
+		case <-ready:
+			// Handed a slot while giving up; pass it on without counting
+			// a completion, as nothing ran
+			l.handoff()
+		default:
+			l.waiters.Remove(el)
+			l.stats.Queued--
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// release frees a slot after a generation.
+func (l *LimitingGenerator) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Completed++
+	l.handoff()
+}
+
+// handoff gives a slot to the longest queued request, or frees it. Callers
+// hold l.mu.
+func (l *LimitingGenerator) handoff() {
+	if front := l.waiters.Front(); front != nil {
+		l.waiters.Remove(front)
+		l.stats.Queued--
+		close(front.Value.(chan struct{}))
+		return
+	}
+	l.stats.InFlight--
+}
+
+// GenerateStream implements the StreamGenerator interface. A stream holds
+// its slot until it ends.
+func (l *LimitingGenerator) GenerateStream(ctx context.Context, reqCtx RequestContext, schema any, send func(Event) error) error {
+	if err := l.acquire(ctx); err != nil {
+		return err
+	}
+	defer l.release()
+	return streamFrom(ctx, l.gen, reqCtx, schema, send)
+}
+
+// Unwrap returns the limited generator, see GeneratorWrapper.
+func (l *LimitingGenerator) Unwrap() []Generator {
+	return []Generator{l.gen}
+}
+
+// HealthCheck checks the limited generator, see HealthChecker.
+func (l *LimitingGenerator) HealthCheck(ctx context.Context) error {
+	return checkWrapped(ctx, l.gen)
+}
+
+// Stats returns the limiter's current load and counters.
+func (l *LimitingGenerator) Stats() LimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// LimitStats returns the stats of every LimitingGenerator used by g, as the
+// instance generator or a route generator.
+func (g *Gobo) LimitStats() []LimitStats {
+	out := []LimitStats{}
+	for _, gen := range g.generators() {
+		if l, ok := gen.(*LimitingGenerator); ok {
+			out = append(out, l.Stats())
+		}
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package gobo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForLimit waits until the limiter reports the given load.
+func waitForLimit(t *testing.T, l *LimitingGenerator, inFlight, queued int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s := l.Stats()
+		if s.InFlight == inFlight && s.Queued == queued {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d in flight and %d queued, got %+v", inFlight, queued, s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimitingGenerator(t *testing.T) {
+	inner := &countingGenerator{release: make(chan struct{})}
+	l := NewLimitingGenerator(inner, LimitConfig{Name: "ollama", MaxInFlight: 2, MaxQueue: 1})
+	g := New(WithGenerator(l))
+	stub := g.Stub(map[string]any{})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 3)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			stub.ServeHTTP(rec, httptest.NewRequest("GET", "/slow", nil))
+			codes[i] = rec.Code
+		}()
+	}
+	waitForLimit(t, l, 2, 1)
+
+	rec := httptest.NewRecorder()
+	stub.ServeHTTP(rec, httptest.NewRequest("GET", "/slow", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 503 beyond the queue, got %d", rec.Code)
+	}
+
+	close(inner.release)
+	wg.Wait()
+	for _, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("Expected queued requests to succeed, got %v", codes)
+		}
+	}
+
+	stats := g.LimitStats()
+	if len(stats) != 1 || stats[0].Name != "ollama" || stats[0].Completed != 3 || stats[0].Rejected != 1 || stats[0].PeakQueued != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestLimitingGenerator_ContextAware(t *testing.T) {
+	inner := &countingGenerator{release: make(chan struct{})}
+	defer close(inner.release)
+	l := NewLimitingGenerator(inner, LimitConfig{MaxInFlight: 1, MaxQueue: 5})
+
+	go l.GenerateResponse(context.Background(), RequestContext{}, nil)
+	waitForLimit(t, l, 1, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.GenerateResponse(ctx, RequestContext{}, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the queued request to give up, got %v", err)
+	}
+	if s := l.Stats(); s.Queued != 0 || s.Abandoned != 1 || inner.calls.Load() != 1 {
+		t.Errorf("Unexpected stats %+v after %d calls", s, inner.calls.Load())
+	}
+}
+
+func TestLimitingGenerator_FIFO(t *testing.T) {
+	l := NewLimitingGenerator(&mockGenerator{}, LimitConfig{MaxInFlight: 1, MaxQueue: 5})
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan int, 2)
+	done := make(chan struct{})
+	for i := range 2 {
+		go func() {
+			if err := l.acquire(context.Background()); err == nil {
+				order <- i
+				<-done
+				l.release()
+			}
+		}()
+		waitForLimit(t, l, 1, i+1)
+	}
+
+	// A released slot goes to the queue, not to a newcomer
+	l.release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx); err == nil {
+		t.Error("Expected a newcomer to wait behind queued requests")
+		l.release()
+	}
+	close(done)
+	if first, second := <-order, <-order; first != 0 || second != 1 {
+		t.Errorf("Expected slots in arrival order, got %d then %d", first, second)
+	}
+	waitForLimit(t, l, 0, 0)
+}
+
+func TestLimitingGenerator_CancelAfterHandoff(t *testing.T) {
+	l := NewLimitingGenerator(&mockGenerator{}, LimitConfig{MaxInFlight: 1, MaxQueue: 5})
+
+	var ran int64
+	for range 200 {
+		if err := l.acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		ran++
+		ctx, cancel := context.WithCancel(context.Background())
+		result := make(chan error)
+		go func() { result <- l.acquire(ctx) }()
+		waitForLimit(t, l, 1, 1)
+
+		// The waiter gives up just as the slot is handed over, so either
+		// may win; a slot given up must not count as a completion
+		cancel()
+		l.release()
+		if err := <-result; err == nil {
+			ran++
+			l.release()
+		}
+	}
+	if s := l.Stats(); s.Completed != ran || s.InFlight != 0 || s.Queued != 0 {
+		t.Errorf("Expected %d completions and no load, got %+v", ran, s)
+	}
+}
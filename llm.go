@@ -16,36 +16,113 @@ type OllamaGenerator struct {
 	url        string
 	model      string
 	httpClient *http.Client
+
+	chat      bool           // use /api/chat with a JSON Schema format
+	options   map[string]any // model parameters sent as "options"
+	keepAlive string         // how long Ollama keeps the model loaded
+}
+
+// OllamaOption configures an OllamaGenerator.
+type OllamaOption func(*OllamaGenerator)
+
+// OllamaChat switches the generator to Ollama's /api/chat endpoint. The
+// prompt is split into a system and a user message, and the JSON Schema
+// derived from the response schema is sent as "format", which constrains
+// decoding to the exact shape. Requires a recent Ollama.
+func OllamaChat() OllamaOption {
+	return func(c *OllamaGenerator) {
+		c.chat = true
+	}
+}
+
+// OllamaTemperature sets the sampling temperature (default 0.2).
+func OllamaTemperature(t float64) OllamaOption {
+	return func(c *OllamaGenerator) {
+		c.options["temperature"] = t
+	}
+}
+
+// OllamaSeed fixes the random seed, making responses reproducible for the
+// same prompt.
+func OllamaSeed(seed int) OllamaOption {
+	return func(c *OllamaGenerator) {
+		c.options["seed"] = seed
+	}
+}
+
+// OllamaNumCtx sets the context window size in tokens.
+func OllamaNumCtx(n int) OllamaOption {
+	return func(c *OllamaGenerator) {
+		c.options["num_ctx"] = n
+	}
+}
+
+// OllamaKeepAlive sets how long Ollama keeps the model loaded after a
+// request, such as "10m" or "-1" for indefinitely.
+func OllamaKeepAlive(d string) OllamaOption {
+	return func(c *OllamaGenerator) {
+		c.keepAlive = d
+	}
 }
 
 // NewOllamaGenerator initializes a new client for a local Ollama instance.
-func NewOllamaGenerator(url string, model string) *OllamaGenerator {
-	return &OllamaGenerator{
+func NewOllamaGenerator(url string, model string, opts ...OllamaOption) *OllamaGenerator {
+	c := &OllamaGenerator{
 		url:   url,
 		model: model,
 		httpClient: &http.Client{
 			// Give the LLM enough time to generate a response
 			Timeout: 2 * time.Minute,
 		},
+		options: map[string]any{
+			"temperature": 0.2, // Keep it low for structural adherence
+		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // GenerateResponse queries the LLM and attempts to parse its output back as JSON matching the schema.
 func (c *OllamaGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
-	prompt, err := c.buildPrompt(reqCtx, schema)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	var ollamaReq map[string]any
+	endpoint := strings.TrimRight(c.url, "/")
+
+	if c.chat {
+		system, user, err := renderChatPrompt(reqCtx, schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build prompt: %w", err)
+		}
+		messages := []map[string]string{{"role": "user", "content": user}}
+		if system != "" {
+			messages = append([]map[string]string{{"role": "system", "content": system}}, messages...)
+		}
+		ollamaReq = map[string]any{
+			"model":    c.model,
+			"messages": messages,
+			// Constrain decoding to the exact response shape
+			"format": jsonSchemaFor(schema),
+			"stream": false,
+		}
+		endpoint += "/api/chat"
+	} else {
+		prompt, err := c.buildPrompt(reqCtx, schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build prompt: %w", err)
+		}
+		ollamaReq = map[string]any{
+			"model":  c.model,
+			"prompt": prompt,
+			// Force JSON mode in Ollama
+			"format": "json",
+			"stream": false,
+		}
+		endpoint += "/api/generate"
 	}
-
-	ollamaReq := map[string]any{
-		"model":  c.model,
-		"prompt": prompt,
-		// Force JSON mode in Ollama
-		"format": "json",
-		"stream": false,
-		"options": map[string]any{
-			"temperature": 0.2, // Keep it low for structural adherence
-		},
+	ollamaReq["options"] = c.options
+	if c.keepAlive != "" {
+		ollamaReq["keep_alive"] = c.keepAlive
 	}
 
 	reqBody, err := json.Marshal(ollamaReq)
@@ -53,7 +130,6 @@ func (c *OllamaGenerator) GenerateResponse(ctx context.Context, reqCtx RequestCo
 		return nil, fmt.Errorf("failed to marshal ollama request: %w", err)
 	}
 
-	endpoint := strings.TrimRight(c.url, "/") + "/api/generate"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -73,6 +149,9 @@ func (c *OllamaGenerator) GenerateResponse(ctx context.Context, reqCtx RequestCo
 
 	var ollamaResp struct {
 		Response string `json:"response"`
+		Message  struct {
+			Content string `json:"content"`
+		} `json:"message"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
 		return nil, fmt.Errorf("failed to decode ollama response: %w", err)
@@ -80,6 +159,9 @@ func (c *OllamaGenerator) GenerateResponse(ctx context.Context, reqCtx RequestCo
 
 	// Validate the returned bytes represent a valid JSON structure
 	outBytes := []byte(ollamaResp.Response)
+	if c.chat {
+		outBytes = []byte(ollamaResp.Message.Content)
+	}
 	if !json.Valid(outBytes) {
 		return nil, fmt.Errorf("llm returned invalid json")
 	}
@@ -87,43 +169,44 @@ func (c *OllamaGenerator) GenerateResponse(ctx context.Context, reqCtx RequestCo
 	return outBytes, nil
 }
 
-// buildPrompt constructs the prompt containing the HTTP request details and the required JSON schema.
-func (c *OllamaGenerator) buildPrompt(reqCtx RequestContext, schema any) (string, error) {
-	schemaBytes, err := json.MarshalIndent(schema, "", "  ")
+// HealthCheck verifies that Ollama is reachable and the model has been
+// pulled. Start runs it for the default instance's generator.
+func (c *OllamaGenerator) HealthCheck(ctx context.Context) error {
+	endpoint := strings.TrimRight(c.url, "/") + "/api/tags"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal schema: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	reqCtxBytes, err := json.MarshalIndent(reqCtx, "", "  ")
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request context: %w", err)
+		return fmt.Errorf("ollama unreachable at %s: %w", c.url, err)
 	}
+	defer resp.Body.Close()
 
-	// Dynamically parse the struct tags to provide explicit field guidance to the LLM
-	fields := reflectSchema(schema)
-	fieldInstructions := formatFieldInstructions(fields)
-
-	prompt := fmt.Sprintf(`You are a smart mock server named Gobo. 
-Your job is to intercept HTTP requests and generate realistic JSON responses based strictly on the provided Response Schema.
-You must use the Request Context to understand what the user is asking for, and generate a fitting response that perfectly matches the Response Schema.
-
-=== Request Context ===
-%s
-
-=== Expected Output JSON Structure ===
-%s
-
-=== Field Instructions ===
-Pay close attention to these explicit data-generation instructions for specific fields:
-%s
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama responded with status %d", resp.StatusCode)
+	}
 
-IMPORTANT: 
-- Return ONLY valid JSON.
-- The root of your output must match the Expected Output JSON Structure exactly.
-- Provide realistic and contextually appropriate fake data.
-- If the Request Context provides IDs or names, try to reuse them in the response if the schema permits.
-- Strictly adhere to any custom instructions provided in the Field Instructions section.
-`, string(reqCtxBytes), string(schemaBytes), fieldInstructions)
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("failed to decode ollama tags: %w", err)
+	}
+	for _, m := range tags.Models {
+		// Models pulled without a tag are listed as "name:latest"
+		if m.Name == c.model || m.Name == c.model+":latest" {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %q is not pulled, run: ollama pull %s", c.model, c.model)
+}
 
-	return prompt, nil
+// buildPrompt constructs the prompt containing the HTTP request details and the required JSON schema.
+// The prompt can be customized per instance or route, see PromptConfig.
+func (c *OllamaGenerator) buildPrompt(reqCtx RequestContext, schema any) (string, error) {
+	return renderPrompt(reqCtx, schema)
 }
@@ -0,0 +1,141 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newOllamaStub serves /api/generate, /api/chat and /api/tags, recording the
+// last generation request.
+func newOllamaStub(t *testing.T, got *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write([]byte(`{"models":[{"name":"llama3:latest"}]}`))
+			return
+		case "/api/generate", "/api/chat":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		*got = map[string]any{}
+		json.NewDecoder(r.Body).Decode(got)
+		(*got)["path"] = r.URL.Path
+		if r.URL.Path == "/api/chat" {
+			w.Write([]byte(`{"message":{"role":"assistant","content":"{\"id\":\"u_1\"}"}}`))
+			return
+		}
+		w.Write([]byte(`{"response":"{\"id\":\"u_1\"}"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOllamaGenerator_Generate(t *testing.T) {
+	var got map[string]any
+	srv := newOllamaStub(t, &got)
+
+	c := NewOllamaGenerator(srv.URL, "llama3")
+	out, err := c.GenerateResponse(context.Background(), RequestContext{Method: "GET", URL: "/users/1"}, map[string]any{"id": ""})
+	if err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+	if string(out) != `{"id":"u_1"}` {
+		t.Errorf("Unexpected response %s", out)
+	}
+	if got["path"] != "/api/generate" || got["format"] != "json" {
+		t.Errorf("Expected JSON mode generate request, got %v", got)
+	}
+	if _, ok := got["keep_alive"]; ok {
+		t.Error("Expected keep_alive to be omitted by default")
+	}
+}
+
+func TestOllamaGenerator_Chat(t *testing.T) {
+	var got map[string]any
+	srv := newOllamaStub(t, &got)
+
+	c := NewOllamaGenerator(srv.URL, "llama3", OllamaChat(), OllamaSeed(42), OllamaNumCtx(8192), OllamaTemperature(0), OllamaKeepAlive("10m"))
+	out, err := c.GenerateResponse(context.Background(), RequestContext{Method: "GET", URL: "/users/1"}, map[string]any{"id": ""})
+	if err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+	if string(out) != `{"id":"u_1"}` {
+		t.Errorf("Unexpected response %s", out)
+	}
+	if got["path"] != "/api/chat" || got["keep_alive"] != "10m" {
+		t.Errorf("Unexpected chat request %v", got)
+	}
+
+	format, _ := got["format"].(map[string]any)
+	if format["type"] != "object" || format["properties"] == nil {
+		t.Errorf("Expected JSON Schema format, got %v", got["format"])
+	}
+	options, _ := got["options"].(map[string]any)
+	if options["seed"] != 42.0 || options["num_ctx"] != 8192.0 || options["temperature"] != 0.0 {
+		t.Errorf("Unexpected options %v", options)
+	}
+
+	messages, _ := got["messages"].([]any)
+	if len(messages) != 2 {
+		t.Fatalf("Expected system and user messages, got %v", messages)
+	}
+	system, _ := messages[0].(map[string]any)
+	user, _ := messages[1].(map[string]any)
+	if system["role"] != "system" || !strings.Contains(system["content"].(string), "IMPORTANT") {
+		t.Errorf("Unexpected system message %v", system)
+	}
+	if user["role"] != "user" || !strings.Contains(user["content"].(string), "/users/1") {
+		t.Errorf("Unexpected user message %v", user)
+	}
+}
+
+func TestOllamaGenerator_ChatTemplatePrompt(t *testing.T) {
+	var got map[string]any
+	srv := newOllamaStub(t, &got)
+
+	builder, _ := NewTemplatePromptBuilder("mock {{.Request.URL}}")
+	c := NewOllamaGenerator(srv.URL, "llama3", OllamaChat())
+	reqCtx := RequestContext{Method: "GET", URL: "/users/1", Prompt: &PromptConfig{Builder: builder}}
+	if _, err := c.GenerateResponse(context.Background(), reqCtx, map[string]any{"id": ""}); err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+
+	messages, _ := got["messages"].([]any)
+	if len(messages) != 1 || messages[0].(map[string]any)["content"] != "mock /users/1" {
+		t.Errorf("Expected a single user message from the template, got %v", messages)
+	}
+}
+
+func TestOllamaGenerator_HealthCheck(t *testing.T) {
+	var got map[string]any
+	srv := newOllamaStub(t, &got)
+
+	if err := NewOllamaGenerator(srv.URL, "llama3").HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected llama3 to be found, got %v", err)
+	}
+	err := NewOllamaGenerator(srv.URL, "mistral").HealthCheck(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "ollama pull mistral") {
+		t.Errorf("Expected missing model error, got %v", err)
+	}
+}
+
+func TestDefaultPromptBuilder_ChatSplit(t *testing.T) {
+	data, err := NewPromptData(RequestContext{Method: "GET", URL: "/users/1"}, map[string]any{"id": ""})
+	if err != nil {
+		t.Fatalf("NewPromptData failed: %v", err)
+	}
+	system, user, _ := DefaultPromptBuilder{}.BuildChatPrompt(data)
+	full, _ := DefaultPromptBuilder{}.BuildPrompt(data)
+	if strings.Contains(system, "/users/1") || strings.Contains(user, "IMPORTANT") {
+		t.Error("Expected request details only in the user message and rules only in the system message")
+	}
+	if !strings.Contains(full, user) {
+		t.Error("Expected the single prompt to contain the user message")
+	}
+}
@@ -2,7 +2,10 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/gabriel-feang/gobo"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -13,6 +16,7 @@ func init() {
 		broker := gobo.NewAsyncBroker()
 		g.SetGenerator(broker)
 		srv := NewServer(broker)
+		srv.SetGobo(g)
 		go func() {
 			log.Println("[gobo-mcp] MCP server starting on stdio")
 			if err := srv.Start(context.Background()); err != nil {
@@ -25,6 +29,7 @@ func init() {
 // Server wraps a Gobo AsyncBroker and maps its API into MCP tools.
 type Server struct {
 	broker *gobo.AsyncBroker
+	gobo   *gobo.Gobo // enables instance tools such as invalidate_cache
 	mcp    *mcp.Server
 }
 
@@ -41,6 +46,12 @@ func NewServer(broker *gobo.AsyncBroker) *Server {
 	return srv
 }
 
+// SetGobo gives the server access to the Gobo instance, enabling tools that
+// act on it, such as invalidate_cache.
+func (s *Server) SetGobo(g *gobo.Gobo) {
+	s.gobo = g
+}
+
 // Start begins serving MCP requests over stdio. This method blocks indefinitely.
 func (s *Server) Start(ctx context.Context) error {
 	transport := &mcp.StdioTransport{}
@@ -58,6 +69,7 @@ func WithMCP() gobo.Option {
 		g.SetGenerator(broker)
 
 		srv := NewServer(broker)
+		srv.SetGobo(g)
 		go func() {
 			log.Println("[gobo-mcp] MCP server starting on stdio")
 			if err := srv.Start(context.Background()); err != nil {
@@ -82,6 +94,78 @@ type SubmitResponseOutput struct {
 	Message string `json:"message" jsonschema:"Status message indicating success or failure"`
 }
 
+type InvalidateCacheInput struct {
+	Method     string `json:"method,omitempty" jsonschema:"HTTP method of the cached requests to drop. Empty matches any method."`
+	PathPrefix string `json:"path_prefix,omitempty" jsonschema:"Only drop cached requests whose path starts with this prefix. Empty matches all paths."`
+}
+
+type InvalidateCacheOutput struct {
+	Invalidated int `json:"invalidated" jsonschema:"Number of cached responses removed"`
+}
+
+type PushEventInput struct {
+	RequestID string `json:"request_id" jsonschema:"The ID of the pending stream request."`
+	Event     string `json:"event,omitempty" jsonschema:"Event type. Empty sends the default message type."`
+	ID        string `json:"id,omitempty" jsonschema:"Event id. Empty numbers events in order."`
+	Data      string `json:"data" jsonschema:"Event data: JSON matching the request schema, or plain text."`
+	DelayMS   int    `json:"delay_ms,omitempty" jsonschema:"Milliseconds to wait before sending the event."`
+}
+
+type PushEventOutput struct {
+	Message string `json:"message" jsonschema:"Status message indicating success or failure"`
+}
+
+type CloseStreamInput struct {
+	RequestID string `json:"request_id" jsonschema:"The ID of the pending stream request to end."`
+}
+
+type CloseStreamOutput struct {
+	Message string `json:"message" jsonschema:"Status message indicating success or failure"`
+}
+
+type ListSocketsInput struct{}
+
+type ListSocketsOutput struct {
+	Sockets []gobo.SocketInfo `json:"sockets" jsonschema:"Open WebSocket connections served by Gobo"`
+}
+
+type SendSocketMessageInput struct {
+	SocketID string `json:"socket_id" jsonschema:"The ID of the open socket, from list_sockets or a pending request's context.socket."`
+	Message  string `json:"message" jsonschema:"The text message to send, usually JSON matching the route schema."`
+}
+
+type SendSocketMessageOutput struct {
+	Message string `json:"message" jsonschema:"Status message indicating success or failure"`
+}
+
+type CloseSocketInput struct {
+	SocketID string `json:"socket_id" jsonschema:"The ID of the open socket to close."`
+}
+
+type CloseSocketOutput struct {
+	Message string `json:"message" jsonschema:"Status message indicating success or failure"`
+}
+
+type SendWebhookInput struct {
+	Name        string            `json:"name,omitempty" jsonschema:"A webhook declared on a route (by its name); its URL, secret, schema and retry settings are used. Omit to send an ad-hoc webhook to url."`
+	URL         string            `json:"url,omitempty" jsonschema:"The callback URL; overrides the declared webhook's URL. Required when the declared URL has {placeholders}, which are only filled from requests."`
+	PayloadJSON string            `json:"payload_json,omitempty" jsonschema:"The JSON payload to POST. Omit to generate it from the declared webhook's schema."`
+	Secret      string            `json:"secret,omitempty" jsonschema:"Signs the payload with HMAC-SHA256; overrides the declared webhook's secret."`
+	Headers     map[string]string `json:"headers,omitempty" jsonschema:"Extra request headers."`
+	DelayMS     int               `json:"delay_ms,omitempty" jsonschema:"Milliseconds to wait before the first attempt."`
+}
+
+type SendWebhookOutput struct {
+	DeliveryID string `json:"delivery_id" jsonschema:"The ID of the delivery, to follow it with list_webhook_deliveries"`
+	Message    string `json:"message" jsonschema:"Status message indicating success or failure"`
+}
+
+type ListWebhookDeliveriesInput struct{}
+
+type ListWebhookDeliveriesOutput struct {
+	Deliveries []gobo.WebhookDelivery `json:"deliveries" jsonschema:"Recent webhook deliveries, newest first"`
+}
+
 func (s *Server) registerTools() {
 	// 1. Tool: get_pending_requests
 	getReqsTool := &mcp.Tool{
@@ -96,6 +180,62 @@ func (s *Server) registerTools() {
 		Description: "Submits a mocked JSON response to unblock a pending HTTP request intercepted by Gobo.",
 	}
 	mcp.AddTool(s.mcp, submitRespTool, s.handleSubmitResponse)
+
+	// 3. Tool: invalidate_cache
+	invalidateTool := &mcp.Tool{
+		Name:        "invalidate_cache",
+		Description: "Drops cached mock responses so the next matching requests are generated again, for example after the data they describe changed.",
+	}
+	mcp.AddTool(s.mcp, invalidateTool, s.handleInvalidateCache)
+
+	// 4. Tool: push_event
+	pushEventTool := &mcp.Tool{
+		Name:        "push_event",
+		Description: "Sends one server-sent event to a pending stream request (one with stream: true). The HTTP client receives it immediately; call close_stream when done.",
+	}
+	mcp.AddTool(s.mcp, pushEventTool, s.handlePushEvent)
+
+	// 5. Tool: close_stream
+	closeStreamTool := &mcp.Tool{
+		Name:        "close_stream",
+		Description: "Ends a pending stream request after the events pushed so far have been sent.",
+	}
+	mcp.AddTool(s.mcp, closeStreamTool, s.handleCloseStream)
+
+	// 6. Tool: list_sockets
+	listSocketsTool := &mcp.Tool{
+		Name:        "list_sockets",
+		Description: "Lists the WebSocket connections currently open on Gobo's WebSocket stubs.",
+	}
+	mcp.AddTool(s.mcp, listSocketsTool, s.handleListSockets)
+
+	// 7. Tool: send_socket_message
+	sendSocketTool := &mcp.Tool{
+		Name:        "send_socket_message",
+		Description: "Sends a text message to an open WebSocket, for example a server-pushed update the client is waiting for.",
+	}
+	mcp.AddTool(s.mcp, sendSocketTool, s.handleSendSocketMessage)
+
+	// 8. Tool: close_socket
+	closeSocketTool := &mcp.Tool{
+		Name:        "close_socket",
+		Description: "Closes an open WebSocket with a normal closure.",
+	}
+	mcp.AddTool(s.mcp, closeSocketTool, s.handleCloseSocket)
+
+	// 9. Tool: send_webhook
+	sendWebhookTool := &mcp.Tool{
+		Name:        "send_webhook",
+		Description: "Sends an outbound webhook (a signed JSON POST with retries) to the app under test, such as a payment confirmation callback. Use a webhook declared on a route by name, or give a url and payload.",
+	}
+	mcp.AddTool(s.mcp, sendWebhookTool, s.handleSendWebhook)
+
+	// 10. Tool: list_webhook_deliveries
+	listDeliveriesTool := &mcp.Tool{
+		Name:        "list_webhook_deliveries",
+		Description: "Lists recent webhook deliveries with their status, attempts and the receiver's last response status.",
+	}
+	mcp.AddTool(s.mcp, listDeliveriesTool, s.handleListWebhookDeliveries)
 }
 
 func (s *Server) handleGetPendingRequests(ctx context.Context, req *mcp.CallToolRequest, input GetPendingRequestsInput) (*mcp.CallToolResult, GetPendingRequestsOutput, error) {
@@ -113,3 +253,109 @@ func (s *Server) handleSubmitResponse(ctx context.Context, req *mcp.CallToolRequ
 
 	return nil, SubmitResponseOutput{Message: "Response successfully submitted to Gobo! The blocked HTTP request has been fulfilled."}, nil
 }
+
+func (s *Server) handleInvalidateCache(ctx context.Context, req *mcp.CallToolRequest, input InvalidateCacheInput) (*mcp.CallToolResult, InvalidateCacheOutput, error) {
+	if s.gobo == nil {
+		return nil, InvalidateCacheOutput{}, fmt.Errorf("server is not attached to a Gobo instance")
+	}
+	return nil, InvalidateCacheOutput{Invalidated: s.gobo.InvalidateCache(input.Method, input.PathPrefix)}, nil
+}
+
+func (s *Server) handlePushEvent(ctx context.Context, req *mcp.CallToolRequest, input PushEventInput) (*mcp.CallToolResult, PushEventOutput, error) {
+	var data any = input.Data
+	if json.Valid([]byte(input.Data)) {
+		data = json.RawMessage(input.Data)
+	}
+	event := gobo.Event{
+		ID:    input.ID,
+		Name:  input.Event,
+		Data:  data,
+		Delay: time.Duration(input.DelayMS) * time.Millisecond,
+	}
+	if err := s.broker.PushEvent(input.RequestID, event); err != nil {
+		return nil, PushEventOutput{}, err
+	}
+	return nil, PushEventOutput{Message: "Event queued for the stream."}, nil
+}
+
+func (s *Server) handleCloseStream(ctx context.Context, req *mcp.CallToolRequest, input CloseStreamInput) (*mcp.CallToolResult, CloseStreamOutput, error) {
+	if err := s.broker.CloseStream(input.RequestID); err != nil {
+		return nil, CloseStreamOutput{}, err
+	}
+	return nil, CloseStreamOutput{Message: "Stream closed. The HTTP client receives the remaining events and the end of the stream."}, nil
+}
+
+func (s *Server) handleListSockets(ctx context.Context, req *mcp.CallToolRequest, input ListSocketsInput) (*mcp.CallToolResult, ListSocketsOutput, error) {
+	if s.gobo == nil {
+		return nil, ListSocketsOutput{}, fmt.Errorf("server is not attached to a Gobo instance")
+	}
+	return nil, ListSocketsOutput{Sockets: s.gobo.Sockets()}, nil
+}
+
+func (s *Server) handleSendSocketMessage(ctx context.Context, req *mcp.CallToolRequest, input SendSocketMessageInput) (*mcp.CallToolResult, SendSocketMessageOutput, error) {
+	if s.gobo == nil {
+		return nil, SendSocketMessageOutput{}, fmt.Errorf("server is not attached to a Gobo instance")
+	}
+	if err := s.gobo.SendSocketMessage(input.SocketID, []byte(input.Message)); err != nil {
+		return nil, SendSocketMessageOutput{}, err
+	}
+	return nil, SendSocketMessageOutput{Message: "Message sent to the socket."}, nil
+}
+
+func (s *Server) handleCloseSocket(ctx context.Context, req *mcp.CallToolRequest, input CloseSocketInput) (*mcp.CallToolResult, CloseSocketOutput, error) {
+	if s.gobo == nil {
+		return nil, CloseSocketOutput{}, fmt.Errorf("server is not attached to a Gobo instance")
+	}
+	if err := s.gobo.CloseSocket(input.SocketID); err != nil {
+		return nil, CloseSocketOutput{}, err
+	}
+	return nil, CloseSocketOutput{Message: "Socket closed."}, nil
+}
+
+func (s *Server) handleSendWebhook(ctx context.Context, req *mcp.CallToolRequest, input SendWebhookInput) (*mcp.CallToolResult, SendWebhookOutput, error) {
+	if s.gobo == nil {
+		return nil, SendWebhookOutput{}, fmt.Errorf("server is not attached to a Gobo instance")
+	}
+	var hook gobo.Webhook
+	if input.Name != "" {
+		declared, ok := s.gobo.WebhookNamed(input.Name)
+		if !ok {
+			return nil, SendWebhookOutput{}, fmt.Errorf("no route declares a webhook named %q", input.Name)
+		}
+		hook = declared
+	}
+	if input.URL != "" {
+		hook.URL = input.URL
+	}
+	if input.Secret != "" {
+		hook.Secret = input.Secret
+	}
+	if len(input.Headers) > 0 {
+		headers := make(map[string]string, len(hook.Headers)+len(input.Headers))
+		for k, v := range hook.Headers {
+			headers[k] = v
+		}
+		for k, v := range input.Headers {
+			headers[k] = v
+		}
+		hook.Headers = headers
+	}
+	hook.Delay = time.Duration(input.DelayMS) * time.Millisecond
+
+	var payload []byte
+	if input.PayloadJSON != "" {
+		payload = []byte(input.PayloadJSON)
+	}
+	id, err := s.gobo.SendWebhook(hook, payload)
+	if err != nil {
+		return nil, SendWebhookOutput{}, err
+	}
+	return nil, SendWebhookOutput{DeliveryID: id, Message: "Webhook queued for delivery."}, nil
+}
+
+func (s *Server) handleListWebhookDeliveries(ctx context.Context, req *mcp.CallToolRequest, input ListWebhookDeliveriesInput) (*mcp.CallToolResult, ListWebhookDeliveriesOutput, error) {
+	if s.gobo == nil {
+		return nil, ListWebhookDeliveriesOutput{}, fmt.Errorf("server is not attached to a Gobo instance")
+	}
+	return nil, ListWebhookDeliveriesOutput{Deliveries: s.gobo.WebhookDeliveries()}, nil
+}
@@ -0,0 +1,250 @@
+package gobo
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// openAPIMethods lists the operation keys of an OpenAPI path item.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// RegisterOpenAPI registers a mock route on g for every operation in an
+// OpenAPI 3 document, given as JSON or YAML.
+//
+// Each route answers with the operation's first 2xx response (or "default"),
+// using its JSON Schema as the response schema. Property descriptions become
+// field instructions, and the spec's own example is served when no generator
+// is configured. JSON request bodies, query parameters and headers are
+// validated against the spec, see RequestSpec.
+func RegisterOpenAPI(g *Gobo, spec []byte) error {
+	var doc map[string]any
+	if err := decodeYAMLOrJSON(spec, &doc); err != nil {
+		return fmt.Errorf("failed to parse openapi document: %w", err)
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return fmt.Errorf("unsupported openapi version %q", doc["openapi"])
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for _, path := range sortedOpenAPIPaths(paths) {
+		item := asSchemaNode(resolveRefs(doc, paths[path], nil))
+		for _, method := range openAPIMethods {
+			op := asSchemaNode(item[method])
+			if op == nil {
+				continue
+			}
+			route := openAPIRoute(doc, strings.ToUpper(method), path, op, item["parameters"])
+			g.addRoute(route)
+		}
+	}
+	return nil
+}
+
+// sortedOpenAPIPaths orders paths so that literal segments are tried before
+// templated ones ("/users/me" before "/users/{id}"), since routes match in
+// registration order.
+func sortedOpenAPIPaths(paths map[string]any) []string {
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		wi, wj := strings.Count(keys[i], "{"), strings.Count(keys[j], "{")
+		if wi != wj {
+			return wi < wj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// openAPIRoute builds the mock route for a single operation. pathParams are
+// the parameters shared by every operation of the path item.
+func openAPIRoute(doc map[string]any, method, path string, op map[string]any, pathParams any) *routeSchema {
+	// Literal OpenAPI paths are exact; anchor them so "/users" does not
+	// prefix-match "/users/{id}".
+	pattern := path
+	if !strings.Contains(pattern, "{") {
+		pattern = strings.TrimRight(pattern, "/") + "/{$}"
+	}
+
+	route := &routeSchema{
+		Method:     method,
+		PathPrefix: pattern,
+		Status:     http.StatusOK,
+	}
+
+	responses, _ := op["responses"].(map[string]any)
+	code, response := pickOpenAPIResponse(responses)
+	if code != 0 {
+		route.Status = code
+	}
+	if media := jsonMediaType(asSchemaNode(resolveRefs(doc, response, nil))); media != nil {
+		schema := JSONSchema{}
+		if s := asSchemaNode(resolveRefs(doc, media["schema"], nil)); s != nil {
+			schema = JSONSchema(s)
+		}
+		if example, ok := mediaExample(media); ok {
+			schema["example"] = example
+		}
+		route.ResponseSchema = schema
+	}
+
+	spec := &RequestSpec{}
+	body := asSchemaNode(resolveRefs(doc, op["requestBody"], nil))
+	if media := jsonMediaType(body); media != nil {
+		if s := asSchemaNode(resolveRefs(doc, media["schema"], nil)); s != nil {
+			spec.Body = JSONSchema(s)
+			spec.BodyRequired = body["required"] == true
+		}
+	}
+	params, _ := resolveRefs(doc, pathParams, nil).([]any)
+	opParams, _ := resolveRefs(doc, op["parameters"], nil).([]any)
+	for _, raw := range append(params, opParams...) {
+		p := asSchemaNode(raw)
+		name, _ := p["name"].(string)
+		param := Param{Name: name, Required: p["required"] == true}
+		if s := asSchemaNode(p["schema"]); s != nil {
+			param.Schema = JSONSchema(s)
+		}
+		switch p["in"] {
+		case "query":
+			spec.Query = append(spec.Query, param)
+		case "header":
+			spec.Headers = append(spec.Headers, param)
+		}
+	}
+	if spec.Body != nil || spec.Query != nil || spec.Headers != nil {
+		route.Request = spec
+	}
+
+	return route
+}
+
+// pickOpenAPIResponse returns the lowest 2xx response, falling back to "default".
+func pickOpenAPIResponse(responses map[string]any) (int, any) {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if len(code) == 3 && code[0] == '2' {
+			if status, err := strconv.Atoi(code); err == nil {
+				return status, responses[code]
+			}
+			return http.StatusOK, responses[code] // "2XX" range
+		}
+	}
+	if resp, ok := responses["default"]; ok {
+		return 0, resp
+	}
+	return 0, nil
+}
+
+// jsonMediaType returns the JSON media type object from a response or
+// request body, preferring "application/json" over other "+json" types.
+func jsonMediaType(obj map[string]any) map[string]any {
+	content, _ := obj["content"].(map[string]any)
+	if media := asSchemaNode(content["application/json"]); media != nil {
+		return media
+	}
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if strings.Contains(t, "json") {
+			return asSchemaNode(content[t])
+		}
+	}
+	return nil
+}
+
+// mediaExample returns the media type's "example", or the value of the first
+// named entry in "examples".
+func mediaExample(media map[string]any) (any, bool) {
+	if example, ok := media["example"]; ok {
+		return example, true
+	}
+	examples, _ := media["examples"].(map[string]any)
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ex := asSchemaNode(examples[name]); ex != nil {
+			if value, ok := ex["value"]; ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// resolveRefs returns a copy of node with every local "$ref" replaced by the
+// referenced definition. Keys next to a $ref (such as a description) override
+// the referenced ones. chain holds the references being expanded; a reference
+// back into the chain resolves to an empty schema, which keeps recursive
+// definitions finite.
+func resolveRefs(doc map[string]any, node any, chain []string) any {
+	switch n := node.(type) {
+	case map[string]any:
+		if ref, ok := n["$ref"].(string); ok {
+			target, found := lookupPointer(doc, ref)
+			if !found || slices.Contains(chain, ref) {
+				return map[string]any{}
+			}
+			resolved, _ := resolveRefs(doc, target, append(slices.Clip(chain), ref)).(map[string]any)
+			merged := make(map[string]any, len(resolved)+len(n))
+			for k, v := range resolved {
+				merged[k] = v
+			}
+			for k, v := range n {
+				if k != "$ref" {
+					merged[k] = resolveRefs(doc, v, chain)
+				}
+			}
+			return merged
+		}
+		out := make(map[string]any, len(n))
+		for k, v := range n {
+			out[k] = resolveRefs(doc, v, chain)
+		}
+		return out
+	case []any:
+		out := make([]any, len(n))
+		for i, v := range n {
+			out[i] = resolveRefs(doc, v, chain)
+		}
+		return out
+	}
+	return node
+}
+
+// lookupPointer resolves a local JSON pointer reference such as
+// "#/components/schemas/User".
+func lookupPointer(doc map[string]any, ref string) (any, bool) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	var cur any = doc
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[token]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
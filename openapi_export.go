@@ -0,0 +1,274 @@
+package gobo
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// anyMethods are the operations emitted for routes registered with "ANY".
+var anyMethods = []string{"get", "put", "post", "delete", "patch"}
+
+// pathParamPattern finds wildcards in a route pattern.
+var pathParamPattern = regexp.MustCompile(`\{([^}$.]+)(\.\.\.)?\}`)
+
+// OpenAPI renders an OpenAPI 3.1 document describing every route known to g:
+// routes from the configuration file, routes added with Register or
+// RegisterOpenAPI, and Stub/Intercept handlers mounted with Handle. Handlers
+// mounted directly on a mux are only included once they have served their
+// first request, as their ServeMux pattern is not known before that.
+// Response and request shapes are derived from the schemas, with gobo tags
+// and other field instructions as property descriptions.
+func (g *Gobo) OpenAPI() ([]byte, error) {
+	paths := map[string]any{}
+	for _, route := range g.knownRoutes() {
+		if route.GRPC != nil {
+			continue // gRPC services are described by their protos
+		}
+		path, params := openAPIPath(route.PathPrefix)
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+
+		methods := []string{strings.ToLower(route.Method)}
+		if route.Method == "ANY" {
+			methods = anyMethods
+		}
+		for _, method := range methods {
+			if _, exists := item[method]; exists {
+				continue // earlier routes take precedence, as in match
+			}
+			item[method] = openAPIOperation(route, params)
+		}
+	}
+
+	doc := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Gobo mock API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// knownRoutes lists routes in match precedence order: configuration file,
+// code-registered, then observed handlers sorted by pattern.
+func (g *Gobo) knownRoutes() []*routeSchema {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	routes := slices.Concat(g.fileRoutes, g.routes)
+	patterns := make([]string, 0, len(g.handlerRoutes))
+	for p := range g.handlerRoutes {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+	for _, p := range patterns {
+		routes = append(routes, g.handlerRoutes[p])
+	}
+	return routes
+}
+
+// openAPIPath converts a route pattern into an OpenAPI path template and
+// returns the names of its path parameters.
+func openAPIPath(pattern string) (string, []string) {
+	pattern = strings.TrimSuffix(pattern, "{$}")
+	if pattern != "/" {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	if pattern == "" {
+		pattern = "/"
+	}
+
+	var params []string
+	path := pathParamPattern.ReplaceAllStringFunc(pattern, func(m string) string {
+		name := pathParamPattern.FindStringSubmatch(m)[1]
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	return path, params
+}
+
+// openAPIOperation describes a single route as an OpenAPI operation.
+func openAPIOperation(route *routeSchema, params []string) map[string]any {
+	status := route.Status
+	if status == 0 || route.GraphQL != nil || route.RPC != nil {
+		status = http.StatusOK // GraphQL and JSON-RPC report errors in the body
+	}
+	response := map[string]any{"description": http.StatusText(status)}
+	if content := openAPIContent(route); content != nil {
+		response["content"] = content
+	}
+	responses := map[string]any{strconv.Itoa(status): response}
+	if route.RPC != nil {
+		// Batches of notifications only are answered without a body
+		responses[strconv.Itoa(http.StatusNoContent)] = map[string]any{"description": http.StatusText(http.StatusNoContent)}
+	}
+
+	op := map[string]any{
+		"responses": responses,
+	}
+
+	var parameters []any
+	for _, name := range params {
+		parameters = append(parameters, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	if route.Collection != nil {
+		for _, name := range []string{collectionPage, collectionLimit, collectionOffset} {
+			parameters = append(parameters, map[string]any{"name": name, "in": "query", "schema": map[string]any{"type": "integer"}})
+		}
+		parameters = append(parameters, map[string]any{"name": collectionCursor, "in": "query", "schema": map[string]any{"type": "string"}})
+	}
+
+	if spec := route.Request; spec != nil {
+		for _, group := range []struct {
+			in     string
+			params []Param
+		}{{"query", spec.Query}, {"header", spec.Headers}} {
+			for _, p := range group.params {
+				param := map[string]any{"name": p.Name, "in": group.in, "required": p.Required}
+				if p.Schema != nil {
+					param["schema"] = map[string]any(p.Schema)
+				}
+				parameters = append(parameters, param)
+			}
+		}
+		if spec.Body != nil {
+			op["requestBody"] = map[string]any{
+				"required": spec.BodyRequired,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": jsonSchemaFor(spec.Body)},
+				},
+			}
+		}
+	}
+
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	return op
+}
+
+// openAPIContent describes a route's response body with one entry per
+// content type it serves, or returns nil when it has no body schema.
+func openAPIContent(route *routeSchema) map[string]any {
+	var schema map[string]any
+	switch {
+	case route.Artifact != nil:
+		return map[string]any{
+			"application/octet-stream": map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}},
+		}
+	case route.GraphQL != nil:
+		return map[string]any{"application/json": map[string]any{"schema": graphQLResponseSchema}}
+	case route.RPC != nil:
+		return map[string]any{"application/json": map[string]any{"schema": rpcResponseSchema(route.RPC)}}
+	case route.Stream:
+		return map[string]any{"text/event-stream": map[string]any{"schema": map[string]any{"type": "string"}}}
+	case route.Collection != nil:
+		schema = route.Collection.schema(route.ResponseSchema)
+	case route.ResponseSchema != nil:
+		schema = jsonSchemaFor(route.ResponseSchema)
+	default:
+		return nil
+	}
+
+	formats := route.Formats
+	if len(formats) == 0 {
+		formats = []Format{FormatJSON}
+	}
+	content := make(map[string]any, len(formats))
+	for _, format := range formats {
+		// JSON and XML bodies follow the schema; others, such as CSV, are
+		// only described as text
+		media, _, _ := mime.ParseMediaType(format.ContentType)
+		if structuredMedia(media) {
+			content[format.ContentType] = map[string]any{"schema": schema}
+		} else {
+			content[format.ContentType] = map[string]any{"schema": map[string]any{"type": "string"}}
+		}
+	}
+	return content
+}
+
+// structuredMedia reports whether a media type is JSON or XML, including
+// suffixed types such as application/problem+json.
+func structuredMedia(media string) bool {
+	for _, suffix := range []string{"/json", "+json", "/xml", "+xml"} {
+		if strings.HasSuffix(media, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// graphQLResponseSchema is the shape of every GraphQL response.
+var graphQLResponseSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"data": map[string]any{"type": []any{"object", "null"}},
+		"errors": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"message": map[string]any{"type": "string"}},
+				"required":   []any{"message"},
+			},
+		},
+	},
+}
+
+// rpcResponseSchema describes the responses of a JSON-RPC route: a single
+// response or a batch of them, whose result is one of the methods' results.
+func rpcResponseSchema(methods map[string]RPCMethod) map[string]any {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var results []any
+	for _, name := range names {
+		if result := methods[name].Result; result != nil {
+			results = append(results, jsonSchemaFor(result))
+		}
+	}
+	result := map[string]any{}
+	if len(results) == 1 {
+		result = results[0].(map[string]any)
+	} else if len(results) > 1 {
+		result = map[string]any{"anyOf": results}
+	}
+
+	response := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"jsonrpc": map[string]any{"type": "string", "const": "2.0"},
+			"id":      map[string]any{"type": []any{"string", "integer", "null"}},
+			"result":  result,
+			"error": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"code":    map[string]any{"type": "integer"},
+					"message": map[string]any{"type": "string"},
+					"data":    map[string]any{},
+				},
+				"required": []any{"code", "message"},
+			},
+		},
+		"required": []any{"jsonrpc", "id"},
+	}
+	return map[string]any{"oneOf": []any{response, map[string]any{"type": "array", "items": response}}}
+}
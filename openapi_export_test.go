@@ -0,0 +1,124 @@
+package gobo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type exportUser struct {
+	ID    string `json:"id" gobo:"A UUID v4"`
+	Email string `json:"email,omitempty"`
+}
+
+func TestOpenAPIExport(t *testing.T) {
+	g := New()
+	g.Register("GET", "/users/{id}", exportUser{})
+	if err := RegisterOpenAPI(g, []byte(testOpenAPISpec)); err != nil {
+		t.Fatalf("RegisterOpenAPI failed: %v", err)
+	}
+
+	// Handler routes are learned from the mux pattern on first use
+	mux := http.NewServeMux()
+	mux.Handle("POST /teams/{team}/members", g.Stub(exportUser{}))
+	mux.Handle(AdminPrefix, g.AdminHandler())
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/teams/t1/members", nil))
+	// Handlers mounted with Handle are known before any traffic
+	g.Handle(mux, "GET /teams/{team}", g.Validate(RequestSpec{}, g.Stub(exportUser{})))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/_gobo/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rr.Code)
+	}
+
+	var doc struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid document: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("Expected openapi 3.1.0, got %s", doc.OpenAPI)
+	}
+	for _, path := range []string{"/users/{id}", "/charges", "/charges/{id}", "/teams/{team}/members", "/teams/{team}"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("Expected path %s in document, got %v", path, doc.Paths)
+		}
+	}
+
+	params := doc.Paths["/teams/{team}/members"]["post"]["parameters"].([]any)
+	if params[0].(map[string]any)["name"] != "team" {
+		t.Errorf("Expected team path parameter, got %v", params)
+	}
+	if doc.Paths["/charges"]["post"]["requestBody"] == nil {
+		t.Error("Expected requestBody for POST /charges")
+	}
+}
+
+func TestOpenAPIExport_ContentTypes(t *testing.T) {
+	g := New()
+	g.Register("GET", "/report", []exportUser{}, RouteFormat(FormatJSON, FormatXML, FormatCSV))
+	g.Register("GET", "/events", exportUser{}, RouteStream(0))
+	mux := http.NewServeMux()
+	g.Handle(mux, "POST /rpc", g.JSONRPC(map[string]RPCMethod{"user": {Result: exportUser{}}}, RouteStatus(http.StatusCreated)))
+	gql, err := g.GraphQL("type Query { user: String }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Handle(mux, "POST /graphql", gql)
+
+	out, err := g.OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema map[string]any `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("Invalid document: %v", err)
+	}
+
+	report := doc.Paths["/report"]["get"].Responses["200"].Content
+	if len(report) != 3 || report["application/xml"].Schema["type"] != "array" || report["text/csv; charset=utf-8"].Schema["type"] != "string" {
+		t.Errorf("Expected JSON, XML and CSV content, got %v", report)
+	}
+	if events := doc.Paths["/events"]["get"].Responses["200"].Content; len(events) != 1 || events["text/event-stream"].Schema == nil {
+		t.Errorf("Expected event-stream content, got %v", events)
+	}
+	rpc := doc.Paths["/rpc"]["post"].Responses
+	if rpc["200"].Content["application/json"].Schema["oneOf"] == nil || rpc["201"].Content != nil {
+		t.Errorf("Expected JSON-RPC envelopes with status 200, got %v", rpc)
+	}
+	if _, ok := rpc["204"]; !ok {
+		t.Errorf("Expected a 204 response for notifications, got %v", rpc)
+	}
+	gqlSchema := doc.Paths["/graphql"]["post"].Responses["200"].Content["application/json"].Schema
+	if props, _ := gqlSchema["properties"].(map[string]any); props["data"] == nil || props["errors"] == nil {
+		t.Errorf("Expected the GraphQL response shape, got %v", gqlSchema)
+	}
+}
+
+func TestJSONSchemaFor_Struct(t *testing.T) {
+	got := jsonSchemaFor(exportUser{})
+	expected := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id":    map[string]any{"type": "string", "description": "A UUID v4"},
+			"email": map[string]any{"type": "string"},
+		},
+		"required": []any{"id"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Schema mismatch.\nExpected: %v\nGot:      %v", expected, got)
+	}
+}
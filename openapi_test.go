@@ -0,0 +1,107 @@
+package gobo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+info: {title: Payments, version: "1"}
+paths:
+  /charges:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [amount]
+              properties:
+                amount: {type: integer, minimum: 1}
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Charge"}
+              example: {id: ch_1, status: APPROVED}
+  /charges/{id}:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Charge"}
+components:
+  schemas:
+    Charge:
+      type: object
+      properties:
+        id: {type: string, description: A charge id like ch_123}
+        status: {type: string, enum: [APPROVED, DECLINED]}
+        parent: {$ref: "#/components/schemas/Charge"}
+`
+
+func TestRegisterOpenAPI(t *testing.T) {
+	g := New()
+	if err := RegisterOpenAPI(g, []byte(testOpenAPISpec)); err != nil {
+		t.Fatalf("RegisterOpenAPI failed: %v", err)
+	}
+	if len(g.routes) != 2 {
+		t.Fatalf("Expected 2 routes, got %d", len(g.routes))
+	}
+	handler := g.Middleware(http.NotFoundHandler())
+
+	// The spec example is served when no generator is configured
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/charges", strings.NewReader(`{"amount": 500}`)))
+	if rr.Code != http.StatusCreated || rr.Body.String() != "{\"id\":\"ch_1\",\"status\":\"APPROVED\"}\n" {
+		t.Errorf("Expected spec example with 201, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Invalid request bodies are rejected
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/charges", strings.NewReader(`{"amount": 0}`)))
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), `"field":"$.amount","message":"must be >= 1"`) {
+		t.Errorf("Expected 422 for invalid body, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/charges", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for missing required body, got %d", rr.Code)
+	}
+
+	// Templated paths match, literal paths are exact, recursive refs terminate
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/charges/ch_9", nil))
+	var charge map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &charge); err != nil || charge["status"] != "APPROVED" {
+		t.Errorf("Expected enum-derived example, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/charges/extra", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected literal path to match exactly, got %d", rr.Code)
+	}
+
+	// Descriptions become field instructions
+	route := g.match(httptest.NewRequest("GET", "/charges/ch_9", nil))
+	instructions := formatFieldInstructions(reflectSchema(route.ResponseSchema))
+	if !strings.Contains(instructions, "- **`id`** (string): A charge id like ch_123") {
+		t.Errorf("Expected description as instruction, got:\n%s", instructions)
+	}
+}
+
+func TestRegisterOpenAPI_RejectsSwagger2(t *testing.T) {
+	if err := RegisterOpenAPI(New(), []byte(`{"swagger": "2.0"}`)); err == nil {
+		t.Error("Expected error for a Swagger 2.0 document")
+	}
+}
@@ -0,0 +1,271 @@
+package gobo
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPoolSize        = 5
+	defaultPoolConcurrency = 2
+	prefetchTimeout        = 2 * time.Minute
+)
+
+// PrefetchConfig sizes the pools of pre-generated responses, see WithPrefetch.
+type PrefetchConfig struct {
+	// Size is how many responses are kept ready per route (default 5).
+	Size int
+	// Concurrency bounds background generations across all routes (default 2).
+	Concurrency int
+}
+
+// PoolStats describes the prefetch pool of one route.
+type PoolStats struct {
+	Route string `json:"route"`
+	// Ready is the number of responses waiting to be served.
+	Ready int `json:"ready"`
+	// Filling is the number of background generations in progress.
+	Filling int `json:"filling"`
+	// Hits counts requests served from the pool, Misses those that found
+	// it empty and were generated live.
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Errors counts failed background generations.
+	Errors    int64  `json:"errors"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// WithPrefetch keeps a pool of pre-generated responses for every route whose
+// generator is slow, such as an LLM, so requests are answered instantly while
+// the pool refills in the background. Pools are filled with a synthetic
+// request built from the route pattern, so pooled responses do not reflect
+// the actual request; requests that failed validation are always generated
+// live. Routes served by StaticGenerator, TemplateGenerator,
+// FixtureGenerator or the AsyncBroker are not pooled.
+//
+// Registered and config file routes are filled eagerly; Stub and Intercept
+// routes on their first request. Pool stats are served at /_gobo/pools.
+func WithPrefetch(cfg PrefetchConfig) Option {
+	return func(g *Gobo) {
+		if cfg.Size <= 0 {
+			cfg.Size = defaultPoolSize
+		}
+		if cfg.Concurrency <= 0 {
+			cfg.Concurrency = defaultPoolConcurrency
+		}
+		g.prefetch = &prefetcher{
+			g:     g,
+			cfg:   cfg,
+			sem:   make(chan struct{}, cfg.Concurrency),
+			pools: make(map[string]*responsePool),
+		}
+	}
+}
+
+// PoolStats returns the prefetch pool stats for every route, sorted by route.
+// It is empty unless WithPrefetch is used.
+func (g *Gobo) PoolStats() []PoolStats {
+	if g.prefetch == nil {
+		return []PoolStats{}
+	}
+	return g.prefetch.stats()
+}
+
+// prefetcher owns the pools of an instance.
+type prefetcher struct {
+	g   *Gobo
+	cfg PrefetchConfig
+	sem chan struct{} // bounds concurrent background generations
+
+	mu    sync.Mutex
+	pools map[string]*responsePool
+}
+
+// responsePool holds pre-generated responses for one route.
+type responsePool struct {
+	key   string
+	route *routeSchema
+	gen   Generator
+	ready []pooledResponse
+	stats PoolStats
+}
+
+type pooledResponse struct {
+	body    []byte
+	backend string
+}
+
+// prefetchable reports whether responses from gen are worth pooling: it
+// must reach a generator that is slow to answer, and no AsyncBroker, whose
+// pending requests would wait for an agent that never asked for them.
+func prefetchable(gen Generator) bool {
+	if gen == nil {
+		return false
+	}
+	worth := false
+	for _, leaf := range leafGenerators(gen) {
+		switch leaf.(type) {
+		case *AsyncBroker:
+			return false
+		case StaticGenerator, *StaticGenerator, *TemplateGenerator, FixtureGenerator, *FixtureGenerator:
+		default:
+			worth = true
+		}
+	}
+	return worth
+}
+
+// prefetched serves a request from its route's pool, when prefetching is
+// enabled and the request does not need a live generation. Handlers not
+// mounted on a ServeMux pattern are keyed by their literal path, so they
+// are not pooled: every new path would start its own pool.
+func (g *Gobo) prefetched(r *http.Request, key string, route *routeSchema, gen Generator, reqCtx RequestContext) (pooledResponse, bool) {
+	if reqCtx.Validation != nil && !reqCtx.Validation.Valid {
+		return pooledResponse{}, false
+	}
+	if route.PathPrefix == "" && r.Pattern == "" {
+		return pooledResponse{}, false
+	}
+	return g.prefetch.take(key, route, gen)
+}
+
+// warm creates and fills the pool of a registered route.
+func (p *prefetcher) warm(route *routeSchema) {
+	if p == nil || route.PathPrefix == "" || route.Artifact != nil || route.GraphQL != nil || route.RPC != nil || route.Collection != nil || route.GRPC != nil {
+		return
+	}
+	gen := p.g.generator(route)
+	if !prefetchable(gen) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fillLocked(p.poolLocked(route.Method+" "+route.PathPrefix, route, gen))
+}
+
+// take returns a pooled response for the route, creating its pool on first
+// use, and schedules a refill.
+func (p *prefetcher) take(key string, route *routeSchema, gen Generator) (pooledResponse, bool) {
+	if p == nil || !prefetchable(gen) {
+		return pooledResponse{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pool := p.poolLocked(key, route, gen)
+	defer p.fillLocked(pool)
+	if len(pool.ready) == 0 {
+		pool.stats.Misses++
+		return pooledResponse{}, false
+	}
+	resp := pool.ready[0]
+	pool.ready = pool.ready[1:]
+	pool.stats.Hits++
+	return resp, true
+}
+
+// poolLocked returns the pool for key, resetting it when the route or its
+// generator changed, such as after a config reload. Callers hold p.mu.
+func (p *prefetcher) poolLocked(key string, route *routeSchema, gen Generator) *responsePool {
+	pool, ok := p.pools[key]
+	if !ok {
+		pool = &responsePool{key: key, stats: PoolStats{Route: key}}
+		p.pools[key] = pool
+	}
+	if pool.route != route || !sameGenerator(pool.gen, gen) {
+		pool.route, pool.gen, pool.ready = route, gen, nil
+	}
+	return pool
+}
+
+// fillLocked starts background generations until the pool is full, counting
+// those already in flight. Callers hold p.mu.
+func (p *prefetcher) fillLocked(pool *responsePool) {
+	for n := p.cfg.Size - len(pool.ready) - pool.stats.Filling; n > 0; n-- {
+		pool.stats.Filling++
+		go p.generate(pool, pool.route, pool.gen)
+	}
+}
+
+// generate produces one response for the pool with a synthetic request.
+func (p *prefetcher) generate(pool *responsePool, route *routeSchema, gen Generator) {
+	p.sem <- struct{}{}
+	defer func() { <-p.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), prefetchTimeout)
+	defer cancel()
+	ctx, backend := withBackend(ctx)
+
+	method, path := syntheticRequest(pool.key)
+	reqCtx := RequestContext{
+		Method:   method,
+		URL:      path,
+		Headers:  http.Header{},
+		Examples: p.g.examplesFor(pool.key, route),
+		Prompt:   p.g.promptFor(pool.key, route),
+	}
+	body, err := gen.GenerateResponse(ctx, reqCtx, route.ResponseSchema)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pool.stats.Filling--
+	switch {
+	case err != nil:
+		// No retry here; the next request schedules another attempt
+		pool.stats.Errors++
+		pool.stats.LastError = err.Error()
+		p.g.logf("Prefetch for %s failed: %v", pool.key, err)
+	case pool.route == route && sameGenerator(pool.gen, gen):
+		pool.ready = append(pool.ready, pooledResponse{body: body, backend: *backend})
+	}
+}
+
+// sameGenerator compares generators without panicking on uncomparable
+// types, which are assumed unchanged.
+func sameGenerator(a, b Generator) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	return ta == nil || !ta.Comparable() || a == b
+}
+
+func (p *prefetcher) stats() []PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]PoolStats, 0, len(p.pools))
+	for _, pool := range p.pools {
+		s := pool.stats
+		s.Ready = len(pool.ready)
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Route < out[j].Route })
+	return out
+}
+
+// wildcardPattern matches ServeMux wildcards such as "{id}", "{path...}"
+// and "{$}".
+var wildcardPattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// syntheticRequest derives a plausible method and path from a route key such
+// as "GET /users/{id}", filling wildcards with sample values.
+func syntheticRequest(key string) (method, path string) {
+	method, path, ok := strings.Cut(key, " ")
+	if !ok || method == "ANY" {
+		method, path = http.MethodGet, strings.TrimPrefix(key, "ANY ")
+	}
+	path = wildcardPattern.ReplaceAllStringFunc(path, func(w string) string {
+		if w == "{$}" {
+			return ""
+		}
+		return "1"
+	})
+	return method, path
+}
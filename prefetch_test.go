@@ -0,0 +1,143 @@
+package gobo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// urlGenerator echoes the request URL with a call counter.
+type urlGenerator struct {
+	calls atomic.Int32
+	fail  atomic.Bool
+}
+
+func (u *urlGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	n := u.calls.Add(1)
+	if u.fail.Load() {
+		return nil, errors.New("model not loaded")
+	}
+	return fmt.Appendf(nil, `{"url":%q,"n":%d}`, reqCtx.URL, n), nil
+}
+
+// waitForPool waits until the route's pool has ready responses.
+func waitForPool(t *testing.T, g *Gobo, route string, ready int) PoolStats {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, s := range g.PoolStats() {
+			if s.Route == route && s.Ready >= ready && s.Filling == 0 {
+				return s
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Pool %s never reached %d ready responses: %+v", route, ready, g.PoolStats())
+	return PoolStats{}
+}
+
+func TestPrefetch_RegisteredRoute(t *testing.T) {
+	gen := &urlGenerator{}
+	g := New(WithGenerator(gen), WithPrefetch(PrefetchConfig{Size: 3, Concurrency: 1}))
+	g.Register("GET", "/users/{id}", map[string]any{"url": ""})
+
+	waitForPool(t, g, "GET /users/{id}", 3)
+
+	rec := httptest.NewRecorder()
+	g.Middleware(nil).ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+	if rec.Body.String() != `{"url":"/users/1","n":1}` {
+		t.Errorf("Expected a pooled response from the synthetic request, got %q", rec.Body.String())
+	}
+
+	stats := waitForPool(t, g, "GET /users/{id}", 3)
+	if stats.Hits != 1 || stats.Misses != 0 || gen.calls.Load() != 4 {
+		t.Errorf("Expected one hit and a refill, got %+v after %d calls", stats, gen.calls.Load())
+	}
+}
+
+func TestPrefetch_StubLazyAndErrors(t *testing.T) {
+	gen := &urlGenerator{}
+	gen.fail.Store(true)
+	g := New(WithGenerator(gen), WithPrefetch(PrefetchConfig{Size: 2}))
+	mux := http.NewServeMux()
+	mux.Handle("GET /orders", g.Stub(map[string]any{"url": ""}))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/orders", nil))
+	if rec.Code != 500 {
+		t.Errorf("Expected the live generation to fail, got %d", rec.Code)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats := g.PoolStats()
+		if len(stats) == 1 && stats[0].Errors == 2 && stats[0].Filling == 0 {
+			if stats[0].Misses != 1 || stats[0].LastError != "model not loaded" {
+				t.Errorf("Unexpected stats %+v", stats[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected failed prefetches to be counted, got %+v", stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPrefetch_SkipsUnmountedHandlers(t *testing.T) {
+	gen := &urlGenerator{}
+	g := New(WithGenerator(gen), WithPrefetch(PrefetchConfig{Size: 2}))
+	stub := g.Stub(map[string]any{"url": ""})
+	for i := range 3 {
+		stub.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", fmt.Sprintf("/orders/%d", i), nil))
+	}
+	if stats := g.PoolStats(); len(stats) != 0 || gen.calls.Load() != 3 {
+		t.Errorf("Expected no pools per literal path, got %+v after %d calls", stats, gen.calls.Load())
+	}
+}
+
+func TestPrefetch_SkipsLocalGenerators(t *testing.T) {
+	g := New(WithPrefetch(PrefetchConfig{}))
+	g.Register("GET", "/health", map[string]any{"ok": true})
+	g.Middleware(nil).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	if stats := g.PoolStats(); len(stats) != 0 {
+		t.Errorf("Expected no pools for static routes, got %+v", stats)
+	}
+}
+
+func TestPrefetch_SkipsWrappedBroker(t *testing.T) {
+	broker := NewAsyncBroker()
+	wrapped := NewFallbackGenerator([]FallbackBackend{
+		{Name: "agent", Generator: NewCachingGenerator(NewLimitingGenerator(broker, LimitConfig{}), CacheConfig{})},
+		{Name: "static", Generator: StaticGenerator{}},
+	})
+	if prefetchable(wrapped) {
+		t.Error("Expected a wrapped broker not to be prefetched")
+	}
+	if !prefetchable(NewCachingGenerator(&mockGenerator{}, CacheConfig{})) {
+		t.Error("Expected a wrapped remote generator to be prefetched")
+	}
+	if prefetchable(NewCachingGenerator(StaticGenerator{}, CacheConfig{})) {
+		t.Error("Expected a wrapped static generator not to be prefetched")
+	}
+}
+
+func TestSyntheticRequest(t *testing.T) {
+	for key, want := range map[string]string{
+		"GET /users/{id}":           "GET /users/1",
+		"POST /files/{path...}":     "POST /files/1",
+		"GET /users/{$}":            "GET /users/",
+		"ANY /v1/orders":            "GET /v1/orders",
+		"DELETE /a/{x}/b/{y}/items": "DELETE /a/1/b/1/items",
+	} {
+		method, path := syntheticRequest(key)
+		if got := method + " " + path; got != want {
+			t.Errorf("syntheticRequest(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
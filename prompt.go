@@ -0,0 +1,270 @@
+package gobo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+)
+
+// PromptBuilder turns a request and its schema into the prompt sent to an
+// LLM generator.
+type PromptBuilder interface {
+	BuildPrompt(data PromptData) (string, error)
+}
+
+// PromptData is everything a prompt can draw on. Template prompts receive it
+// as their root value.
+type PromptData struct {
+	Request     RequestContext // the intercepted request
+	RequestJSON string         // Request as indented JSON
+	Schema      any            // the route's response schema
+	SchemaJSON  string         // Schema as an indented sample value
+	JSONSchema  string         // JSON Schema derived from Schema, indented
+	Fields      []FieldInfo    // reflected fields with their gobo instructions
+	// FieldInstructions is Fields rendered as a markdown list.
+	FieldInstructions string
+	Examples          []Example // few-shot examples within the token budget
+	// ExamplesText is Examples rendered for a prompt.
+	ExamplesText string
+	// Context is free-form domain context, such as "This is a Brazilian
+	// payments API, amounts are in BRL".
+	Context string
+	// State holds arbitrary values configured with the prompt.
+	State map[string]any
+}
+
+// PromptConfig customizes the prompt for all routes (WithPrompt, or the
+// top-level "prompt" of the configuration file) or for a single one
+// (SetPrompt, or a route's "prompt"). Route
+// settings extend the instance settings: a route Builder replaces the
+// instance one, Context is appended and State keys are merged over.
+type PromptConfig struct {
+	// Builder renders the prompt; nil uses DefaultPromptBuilder.
+	Builder PromptBuilder
+	// Context is domain context included in the prompt.
+	Context string
+	// State is made available to template prompts as .State.
+	State map[string]any
+}
+
+// WithPrompt sets prompt customizations that apply to every route.
+func WithPrompt(p PromptConfig) Option {
+	return func(g *Gobo) {
+		g.prompt = p
+	}
+}
+
+// SetPrompt customizes the prompt for one route, identified by method and
+// pattern as in AddExamples.
+func (g *Gobo) SetPrompt(method, pattern string, p PromptConfig) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prompts[patternKey(method, pattern)] = p
+}
+
+// promptFor merges the instance, config file, route and SetPrompt settings
+// for a request.
+func (g *Gobo) promptFor(key string, route *routeSchema) *PromptConfig {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	merged := g.prompt.extend(g.filePrompt)
+	if route.Prompt != nil {
+		merged = merged.extend(*route.Prompt)
+	}
+	if p, ok := g.prompts[key]; ok {
+		merged = merged.extend(p)
+	}
+	if merged.Builder == nil && merged.Context == "" && len(merged.State) == 0 {
+		return nil
+	}
+	return &merged
+}
+
+// extend layers more specific settings over p.
+func (p PromptConfig) extend(over PromptConfig) PromptConfig {
+	out := PromptConfig{Builder: p.Builder, Context: p.Context}
+	if over.Builder != nil {
+		out.Builder = over.Builder
+	}
+	if over.Context != "" {
+		out.Context = strings.TrimSpace(out.Context + "\n" + over.Context)
+	}
+	if len(p.State) > 0 || len(over.State) > 0 {
+		out.State = make(map[string]any, len(p.State)+len(over.State))
+		maps.Copy(out.State, p.State)
+		maps.Copy(out.State, over.State)
+	}
+	return out
+}
+
+// NewPromptData gathers the prompt inputs for a request and schema.
+func NewPromptData(reqCtx RequestContext, schema any) (PromptData, error) {
+	schemaBytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return PromptData{}, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	reqCtxBytes, err := json.MarshalIndent(reqCtx, "", "  ")
+	if err != nil {
+		return PromptData{}, fmt.Errorf("failed to marshal request context: %w", err)
+	}
+
+	jsonSchemaBytes, err := json.MarshalIndent(jsonSchemaFor(schema), "", "  ")
+	if err != nil {
+		return PromptData{}, fmt.Errorf("failed to marshal json schema: %w", err)
+	}
+
+	// Dynamically parse the struct tags to provide explicit field guidance to the LLM
+	fields := reflectSchema(schema)
+
+	data := PromptData{
+		Request:           reqCtx,
+		RequestJSON:       string(reqCtxBytes),
+		Schema:            schema,
+		SchemaJSON:        string(schemaBytes),
+		JSONSchema:        string(jsonSchemaBytes),
+		Fields:            fields,
+		FieldInstructions: formatFieldInstructions(fields),
+		Examples:          reqCtx.Examples,
+	}
+	if len(reqCtx.Examples) > 0 {
+		data.ExamplesText = formatExamples(reqCtx.Examples)
+	}
+	if reqCtx.Prompt != nil {
+		data.Context = reqCtx.Prompt.Context
+		data.State = reqCtx.Prompt.State
+	}
+	return data, nil
+}
+
+// renderPrompt builds the prompt for a request with the builder configured
+// for its route, falling back to DefaultPromptBuilder. LLM generators call
+// this so that prompt customizations apply to all of them.
+func renderPrompt(reqCtx RequestContext, schema any) (string, error) {
+	data, err := NewPromptData(reqCtx, schema)
+	if err != nil {
+		return "", err
+	}
+	var builder PromptBuilder = DefaultPromptBuilder{}
+	if reqCtx.Prompt != nil && reqCtx.Prompt.Builder != nil {
+		builder = reqCtx.Prompt.Builder
+	}
+	return builder.BuildPrompt(data)
+}
+
+// ChatPromptBuilder is implemented by prompt builders that can split the
+// prompt into a system and a user message for chat APIs. Builders that do
+// not implement it are sent as a single user message.
+type ChatPromptBuilder interface {
+	PromptBuilder
+	BuildChatPrompt(data PromptData) (system, user string, err error)
+}
+
+// renderChatPrompt is renderPrompt for chat APIs. system is empty when the
+// configured builder cannot split its prompt.
+func renderChatPrompt(reqCtx RequestContext, schema any) (system, user string, err error) {
+	data, err := NewPromptData(reqCtx, schema)
+	if err != nil {
+		return "", "", err
+	}
+	var builder PromptBuilder = DefaultPromptBuilder{}
+	if reqCtx.Prompt != nil && reqCtx.Prompt.Builder != nil {
+		builder = reqCtx.Prompt.Builder
+	}
+	if chat, ok := builder.(ChatPromptBuilder); ok {
+		return chat.BuildChatPrompt(data)
+	}
+	user, err = builder.BuildPrompt(data)
+	return "", user, err
+}
+
+// DefaultPromptBuilder is Gobo's built-in prompt.
+type DefaultPromptBuilder struct{}
+
+const defaultSystemPrompt = `You are a smart mock server named Gobo. 
+Your job is to intercept HTTP requests and generate realistic JSON responses based strictly on the provided Response Schema.
+You must use the Request Context to understand what the user is asking for, and generate a fitting response that perfectly matches the Response Schema.
+`
+
+const defaultPromptRules = `IMPORTANT: 
+- Return ONLY valid JSON.
+- The root of your output must match the Expected Output JSON Structure exactly.
+- Provide realistic and contextually appropriate fake data.
+- If the Request Context provides IDs or names, try to reuse them in the response if the schema permits.
+- Strictly adhere to any custom instructions provided in the Field Instructions section.
+`
+
+// BuildPrompt implements PromptBuilder.
+func (b DefaultPromptBuilder) BuildPrompt(data PromptData) (string, error) {
+	return defaultSystemPrompt + b.userPrompt(data) + "\n" + defaultPromptRules, nil
+}
+
+// BuildChatPrompt implements ChatPromptBuilder. The role and rules go in the
+// system message, the request and schema in the user message.
+func (b DefaultPromptBuilder) BuildChatPrompt(data PromptData) (string, string, error) {
+	return defaultSystemPrompt + "\n" + defaultPromptRules, b.userPrompt(data), nil
+}
+
+// userPrompt renders the request-specific part of the default prompt.
+func (DefaultPromptBuilder) userPrompt(data PromptData) string {
+	contextSection := ""
+	if data.Context != "" {
+		contextSection = "\n=== Domain Context ===\n" + data.Context + "\n"
+	}
+
+	// Example request/response pairs keep the style of generated data close to production
+	examplesSection := ""
+	if data.ExamplesText != "" {
+		examplesSection = "\n=== Examples ===\nThese are example responses from this endpoint. Match their style, formats and value ranges, but do not copy them verbatim:\n" +
+			data.ExamplesText
+	}
+
+	return fmt.Sprintf(`%s
+=== Request Context ===
+%s
+
+=== Expected Output JSON Structure ===
+%s
+
+=== Field Instructions ===
+Pay close attention to these explicit data-generation instructions for specific fields:
+%s
+%s`, contextSection, data.RequestJSON, data.SchemaJSON, data.FieldInstructions, examplesSection)
+}
+
+// TemplatePromptBuilder renders prompts from a text/template. The template
+// receives PromptData and may use the "json" helper to marshal values.
+//
+//	You mock {{.Request.Method}} {{.Request.URL}} for a Brazilian payments API; use BRL.
+//	Return JSON matching this schema:
+//	{{.JSONSchema}}
+type TemplatePromptBuilder struct {
+	tmpl *template.Template
+}
+
+// NewTemplatePromptBuilder parses text as a prompt template.
+func NewTemplatePromptBuilder(text string) (*TemplatePromptBuilder, error) {
+	tmpl, err := template.New("prompt").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.MarshalIndent(v, "", "  ")
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+	return &TemplatePromptBuilder{tmpl: tmpl}, nil
+}
+
+// BuildPrompt implements PromptBuilder.
+func (t *TemplatePromptBuilder) BuildPrompt(data PromptData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return buf.String(), nil
+}
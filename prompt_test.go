@@ -0,0 +1,132 @@
+package gobo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// promptGenerator renders the prompt for each request and returns it.
+type promptGenerator struct {
+	captureGenerator
+	prompt string
+}
+
+func (p *promptGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	prompt, err := renderPrompt(reqCtx, schema)
+	if err != nil {
+		return nil, err
+	}
+	p.prompt = prompt
+	return p.captureGenerator.GenerateResponse(ctx, reqCtx, schema)
+}
+
+func TestDefaultPromptBuilder_Context(t *testing.T) {
+	prompt, err := renderPrompt(RequestContext{
+		Method: "GET",
+		URL:    "/payments/1",
+		Prompt: &PromptConfig{Context: "Amounts are in BRL."},
+	}, map[string]any{"amount": 0})
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	if !strings.Contains(prompt, "=== Domain Context ===\nAmounts are in BRL.") {
+		t.Errorf("Expected domain context in prompt, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, `"amount": 0`) {
+		t.Errorf("Expected schema in prompt, got:\n%s", prompt)
+	}
+}
+
+func TestTemplatePromptBuilder(t *testing.T) {
+	builder, err := NewTemplatePromptBuilder(
+		`{{.Request.Method}} {{.Request.URL}} in {{.State.currency}}: {{.Context}}` + "\n{{.JSONSchema}}")
+	if err != nil {
+		t.Fatalf("NewTemplatePromptBuilder failed: %v", err)
+	}
+	prompt, err := renderPrompt(RequestContext{
+		Method: "GET",
+		URL:    "/payments/1",
+		Prompt: &PromptConfig{Builder: builder, Context: "payments", State: map[string]any{"currency": "BRL"}},
+	}, map[string]any{"amount": 0})
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	if !strings.HasPrefix(prompt, "GET /payments/1 in BRL: payments\n") {
+		t.Errorf("Unexpected prompt:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, `"type": "integer"`) {
+		t.Errorf("Expected JSON Schema in prompt, got:\n%s", prompt)
+	}
+
+	if _, err := NewTemplatePromptBuilder("{{.Request"); err == nil {
+		t.Error("Expected parse error for invalid template")
+	}
+}
+
+func TestPromptFor_Layers(t *testing.T) {
+	builder, _ := NewTemplatePromptBuilder("route")
+	gen := &promptGenerator{}
+	g := New(WithGenerator(gen), WithPrompt(PromptConfig{
+		Context: "Brazilian payments API.",
+		State:   map[string]any{"currency": "BRL", "region": "sa"},
+	}))
+	g.SetPrompt("GET", "/refunds/{id}", PromptConfig{
+		Builder: builder,
+		Context: "Refunds take 7 days.",
+		State:   map[string]any{"currency": "USD"},
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /payments/{id}", g.Stub(map[string]any{"id": ""}))
+	mux.Handle("GET /refunds/{id}", g.Stub(map[string]any{"id": ""}))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/payments/1", nil))
+	if !strings.Contains(gen.prompt, "Brazilian payments API.") {
+		t.Errorf("Expected global context in prompt, got:\n%s", gen.prompt)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/refunds/1", nil))
+	if gen.prompt != "route" {
+		t.Errorf("Expected route template, got %q", gen.prompt)
+	}
+	p := gen.reqCtx.Prompt
+	if p.Context != "Brazilian payments API.\nRefunds take 7 days." {
+		t.Errorf("Unexpected merged context %q", p.Context)
+	}
+	if p.State["currency"] != "USD" || p.State["region"] != "sa" {
+		t.Errorf("Unexpected merged state %v", p.State)
+	}
+}
+
+func TestLoadConfigFile_Prompt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "payments.tmpl"), []byte("Payments: {{.Context}}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	path := writeConfig(t, dir, `
+prompt:
+  context: Amounts are in BRL.
+routes:
+  - pattern: GET /payments/{id}
+    example: {id: ""}
+    prompt:
+      context: Payment IDs start with pay_.
+      template_file: payments.tmpl
+`)
+
+	gen := &promptGenerator{}
+	g := New(WithGenerator(gen))
+	if err := g.LoadConfigFile(path); err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	g.Middleware(nil).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/payments/1", nil))
+
+	if gen.prompt != "Payments: Amounts are in BRL.\nPayment IDs start with pay_." {
+		t.Errorf("Unexpected prompt %q", gen.prompt)
+	}
+}
@@ -0,0 +1,372 @@
+package gobo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// defaultMaxExamples is how many recordings are kept per route by default.
+const defaultMaxExamples = 5
+
+// sensitiveHeaders are dropped from recorded requests so fixtures can be
+// committed safely.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie", "X-Api-Key"}
+
+// unsafeFileChars matches characters replaced when deriving fixture names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// RecordConfig configures record-from-upstream mode, see WithRecording.
+type RecordConfig struct {
+	// Dir is where recordings are stored, one JSON file per route.
+	Dir string
+	// Upstream is the base URL that Stub, Intercept and Middleware routes are
+	// forwarded to while recording. When empty, Intercept and Middleware
+	// record their real handler instead.
+	Upstream string
+	// Offline stops contacting upstreams. Routes are answered by the
+	// generator, grounded by the recorded responses as few-shot examples, or
+	// by replaying the latest recording when no generator is configured.
+	Offline bool
+	// MaxExamples caps the recordings kept per route, newest first.
+	// Defaults to 5.
+	MaxExamples int
+}
+
+// WithRecording enables record-from-upstream mode. While online, matched
+// requests are forwarded to the real upstream, the response is served as-is
+// and stored under cfg.Dir. Recorded responses are attached to their route
+// as examples, which generators receive in RequestContext.Examples. Set
+// cfg.Offline on later runs to generate variations grounded in the recorded
+// traffic without contacting the upstream.
+func WithRecording(cfg RecordConfig) Option {
+	return func(g *Gobo) {
+		if cfg.MaxExamples <= 0 {
+			cfg.MaxExamples = defaultMaxExamples
+		}
+		rec := &recorder{cfg: cfg, cache: make(map[string][]Example)}
+		if cfg.Upstream != "" {
+			if u, err := url.Parse(cfg.Upstream); err == nil {
+				rec.proxy = &httputil.ReverseProxy{Rewrite: func(pr *httputil.ProxyRequest) {
+					pr.SetURL(u)
+				}}
+			} else {
+				log.Printf("[gobo] invalid upstream %q: %v", cfg.Upstream, err)
+			}
+		}
+		g.recorder = rec
+	}
+}
+
+// recorder stores and serves recorded exchanges.
+type recorder struct {
+	cfg   RecordConfig
+	proxy *httputil.ReverseProxy
+
+	mu    sync.Mutex
+	cache map[string][]Example // per route key, loaded lazily from disk
+}
+
+// online reports whether requests should be forwarded and recorded.
+func (rec *recorder) online() bool {
+	return rec != nil && !rec.cfg.Offline
+}
+
+// source returns the handler that produces real responses: the upstream
+// proxy when configured, otherwise the route's real handler (may be nil).
+func (rec *recorder) source(real http.Handler) http.Handler {
+	if rec.proxy != nil {
+		return rec.proxy
+	}
+	return real
+}
+
+// capture serves the request from source and records the response.
+func (rec *recorder) capture(w http.ResponseWriter, r *http.Request, key string, reqCtx RequestContext, source http.Handler) {
+	cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+	source.ServeHTTP(cw, r)
+	if !successful(cw.status) || cw.truncated || !json.Valid(cw.body.Bytes()) {
+		return // only successful JSON bodies make useful fixtures
+	}
+	rec.add(key, Example{Request: reqCtx, Status: cw.status, Response: bytes.Clone(cw.body.Bytes())})
+}
+
+// add stores a new recording for the route, newest first.
+func (rec *recorder) add(key string, ex Example) {
+	ex.Request.Headers = redactHeaders(ex.Request.Headers)
+	ex.Request.Validation = nil
+	ex.Request.Examples = nil
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	examples := append([]Example{ex}, rec.loadLocked(key)...)
+	if len(examples) > rec.cfg.MaxExamples {
+		examples = examples[:rec.cfg.MaxExamples]
+	}
+	rec.cache[key] = examples
+
+	if err := rec.saveLocked(key, examples); err != nil {
+		log.Printf("[gobo] failed to store recording for %s: %v", key, err)
+	}
+}
+
+// examples returns the recordings for a route, newest first.
+func (rec *recorder) examples(key string) []Example {
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.loadLocked(key)
+}
+
+// replay returns the latest successful recording of a route, as recordings
+// made before only successes were kept may hold upstream errors.
+func (rec *recorder) replay(key string) (Example, bool) {
+	for _, ex := range rec.examples(key) {
+		if ex.Status == 0 || successful(ex.Status) {
+			return ex, true
+		}
+	}
+	return Example{}, false
+}
+
+// successful reports whether status is a 2xx status worth recording.
+func successful(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// loadLocked returns cached recordings, reading the fixture file on first use.
+func (rec *recorder) loadLocked(key string) []Example {
+	if examples, ok := rec.cache[key]; ok {
+		return examples
+	}
+
+	var file recordingFile
+	data, err := os.ReadFile(rec.path(key))
+	if err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			log.Printf("[gobo] ignoring corrupt recording %s: %v", rec.path(key), err)
+		}
+	}
+	// Fixtures are stored indented for review; serve them compact.
+	for i, ex := range file.Examples {
+		var buf bytes.Buffer
+		if json.Compact(&buf, ex.Response) == nil {
+			file.Examples[i].Response = buf.Bytes()
+		}
+	}
+	rec.cache[key] = file.Examples
+	return file.Examples
+}
+
+// recordingFile is the on-disk format of a route's recordings.
+type recordingFile struct {
+	Route    string    `json:"route"`
+	Examples []Example `json:"examples"`
+}
+
+func (rec *recorder) saveLocked(key string, examples []Example) error {
+	if err := os.MkdirAll(rec.cfg.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(recordingFile{Route: key, Examples: examples}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(rec.path(key), data, 0o644)
+}
+
+// path maps a route key such as "GET /users/{id}" to its fixture file.
+func (rec *recorder) path(key string) string {
+	return filepath.Join(rec.cfg.Dir, unsafeFileChars.ReplaceAllString(key, "_")+".json")
+}
+
+// redactHeaders returns a copy of headers without credentials.
+func redactHeaders(headers map[string][]string) map[string][]string {
+	out := http.Header(headers).Clone()
+	for _, h := range sensitiveHeaders {
+		out.Del(h)
+	}
+	return out
+}
+
+// routeKey identifies a route for recordings: its registered pattern, the
+// ServeMux pattern of a handler route, or the literal method and path.
+func routeKey(r *http.Request, route *routeSchema) string {
+	switch {
+	case route.PathPrefix != "":
+		return route.Method + " " + route.PathPrefix
+	case r.Pattern != "":
+		return r.Pattern
+	}
+	return r.Method + " " + r.URL.Path
+}
+
+// Transport returns an http.RoundTripper for outbound calls made by the
+// application, such as the client of a third-party API. Requests to hosts
+// (such as "api.stripe.com" or "https://api.stripe.com:8443") that match a
+// registered route are mocked like Middleware would mock them; in online
+// recording mode they are sent to their real destination through base and
+// recorded instead. Without hosts, the recording Upstream's host is used,
+// and without one calls to any host are matched. Other requests, and matched
+// ones with neither a generator nor a recording to replay, go through base
+// untouched. A nil base uses http.DefaultTransport.
+//
+//	client := &http.Client{Transport: g.Transport(nil, "api.stripe.com")}
+func (g *Gobo) Transport(base http.RoundTripper, hosts ...string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if len(hosts) == 0 && g.recorder != nil && g.recorder.cfg.Upstream != "" {
+		hosts = []string{g.recorder.cfg.Upstream}
+	}
+	return &transport{g: g, base: base, hosts: hosts}
+}
+
+// transport implements Gobo.Transport.
+type transport struct {
+	g     *Gobo
+	base  http.RoundTripper
+	hosts []string
+}
+
+// mocks reports whether calls to host are mocked.
+func (t *transport) mocks(host string) bool {
+	if len(t.hosts) == 0 {
+		return true
+	}
+	for _, h := range t.hosts {
+		if u, err := url.Parse(h); err == nil && u.Host != "" {
+			h = u.Host
+		}
+		if strings.EqualFold(h, host) || (!strings.Contains(h, ":") && strings.EqualFold(h, hostname(host))) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostname strips the port from a host.
+func hostname(host string) string {
+	return (&url.URL{Host: host}).Hostname()
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.mocks(req.URL.Host) {
+		return t.base.RoundTrip(req)
+	}
+	route := t.g.match(req)
+	if route == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	// The request must not be modified, so its body is read from a copy
+	if t.g.recorder.online() {
+		out, inspect, err := splitBody(req)
+		if err != nil {
+			return nil, err
+		}
+		reqCtx := extractRequestContext(inspect, t.g.maxBody)
+		resp, err := t.base.RoundTrip(out)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upstream response: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if successful(resp.StatusCode) && json.Valid(body) {
+			t.g.recorder.add(routeKey(req, route), Example{Request: reqCtx, Status: resp.StatusCode, Response: body})
+		}
+		return resp, nil
+	}
+
+	// Like Intercept, calls with nothing to generate or replay go through
+	if t.g.generator(route) == nil && !t.g.replays(req, route) {
+		return t.base.RoundTrip(req)
+	}
+
+	// Mock the call by running the regular serving pipeline in memory.
+	mocked := req.Clone(req.Context())
+	rb := &responseBuffer{header: make(http.Header)}
+	t.g.generateAndWrite(rb, mocked, route, nil)
+	if mocked.Body != nil {
+		// Closes req's body, and removes any spooled copy of it
+		mocked.Body.Close()
+	}
+	return rb.response(req), nil
+}
+
+// splitBody reads the body of req, which RoundTrip must consume and close,
+// into two copies of the request: one to send and one to inspect.
+func splitBody(req *http.Request) (*http.Request, *http.Request, error) {
+	out, inspect := req.Clone(req.Context()), req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return out, inspect, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	inspect.Body = io.NopCloser(bytes.NewReader(body))
+	return out, inspect, nil
+}
+
+// responseBuffer is an http.ResponseWriter kept in memory, for responses
+// handed to the client of a Transport.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// response returns the buffered response to req.
+func (b *responseBuffer) response(req *http.Request) *http.Response {
+	b.WriteHeader(http.StatusOK)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", b.status, http.StatusText(b.status)),
+		StatusCode:    b.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        b.header,
+		Body:          io.NopCloser(bytes.NewReader(b.body.Bytes())),
+		ContentLength: int64(b.body.Len()),
+		Request:       req,
+	}
+}
@@ -0,0 +1,198 @@
+package gobo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `","real":true}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRecording_ProxyThenOffline(t *testing.T) {
+	dir := t.TempDir()
+	upstream := newUpstream(t)
+
+	// Online: requests are forwarded and recorded
+	g := New(WithRecording(RecordConfig{Dir: dir, Upstream: upstream.URL}))
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", g.Stub(map[string]any{"path": ""}))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users/7", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	mux.ServeHTTP(rr, req)
+	if rr.Body.String() != `{"path":"/users/7","real":true}` {
+		t.Fatalf("Expected upstream response, got %s", rr.Body.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "GET_users_id_.json"))
+	if err != nil {
+		t.Fatalf("Expected fixture file: %v", err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Error("Expected credentials to be redacted from the fixture")
+	}
+
+	// Offline with a generator: recordings arrive as examples
+	gen := &captureGenerator{}
+	offline := New(WithGenerator(gen), WithRecording(RecordConfig{Dir: dir, Offline: true}))
+	mux = http.NewServeMux()
+	mux.Handle("GET /users/{id}", offline.Stub(map[string]any{"path": ""}))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/8", nil))
+	if len(gen.reqCtx.Examples) != 1 || string(gen.reqCtx.Examples[0].Response) != `{"path":"/users/7","real":true}` {
+		t.Errorf("Expected the recording as an example, got %+v", gen.reqCtx.Examples)
+	}
+
+	// Offline without a generator: the latest recording is replayed
+	replay := New(WithRecording(RecordConfig{Dir: dir, Offline: true}))
+	mux = http.NewServeMux()
+	mux.Handle("GET /users/{id}", replay.Stub(map[string]any{"path": ""}))
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/users/9", nil))
+	if rr.Body.String() != `{"path":"/users/7","real":true}` {
+		t.Errorf("Expected replayed recording, got %s", rr.Body.String())
+	}
+}
+
+func TestRecording_InterceptRecordsRealHandler(t *testing.T) {
+	dir := t.TempDir()
+	g := New(WithRecording(RecordConfig{Dir: dir, MaxExamples: 2}))
+	live := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"n":"` + r.URL.Query().Get("n") + `"}`))
+	})
+	handler := g.Intercept(live, struct{}{})
+
+	for _, n := range []string{"1", "2", "3"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items?n="+n, nil))
+	}
+
+	examples := g.recorder.examples("GET /items")
+	if len(examples) != 2 || string(examples[0].Response) != `{"n":"3"}` {
+		t.Errorf("Expected the two newest recordings, got %+v", examples)
+	}
+}
+
+func TestRecording_ErrorsAndPassThrough(t *testing.T) {
+	dir := t.TempDir()
+	g := New(WithRecording(RecordConfig{Dir: dir}))
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"down"}`))
+	})
+	g.Intercept(failing, struct{}{}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+	if examples := g.recorder.examples("GET /items"); len(examples) != 0 {
+		t.Errorf("Expected upstream errors not to be recorded, got %+v", examples)
+	}
+
+	// Offline with nothing to replay: the real handler answers
+	offline := New(WithRecording(RecordConfig{Dir: dir, Offline: true}))
+	live := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"real":true}`))
+	})
+	rr := httptest.NewRecorder()
+	offline.Intercept(live, struct{}{}).ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+	if rr.Body.String() != `{"real":true}` {
+		t.Errorf("Expected the real handler, got %s", rr.Body.String())
+	}
+
+	// Error recordings from older fixtures are not replayed
+	offline.recorder.add("GET /items", Example{Status: http.StatusOK, Response: []byte(`{"ok":true}`)})
+	offline.recorder.add("GET /items", Example{Status: http.StatusBadGateway, Response: []byte(`{"error":"down"}`)})
+	rr = httptest.NewRecorder()
+	offline.Intercept(live, struct{}{}).ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != `{"ok":true}` {
+		t.Errorf("Expected the latest successful recording, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestTransport(t *testing.T) {
+	upstream := newUpstream(t)
+
+	// Without recording, matched outbound calls are mocked
+	g := New(WithGenerator(&mockGenerator{Response: []byte(`{"mocked":true}`)}))
+	g.Register("POST", "/charge", map[string]any{})
+	client := &http.Client{Transport: g.Transport(nil)}
+
+	resp, err := client.Post(upstream.URL+"/charge", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"mocked":true}` {
+		t.Errorf("Expected mocked response, got %s", body)
+	}
+
+	resp, err = client.Get(upstream.URL + "/other")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"real":true`) {
+		t.Errorf("Expected unmatched call to reach the upstream, got %s", body)
+	}
+
+	// Online recording: calls reach the upstream and are recorded
+	rec := New(WithRecording(RecordConfig{Dir: t.TempDir()}))
+	rec.Register("POST", "/charge", map[string]any{})
+	client = &http.Client{Transport: rec.Transport(nil)}
+	req, _ := http.NewRequest("POST", upstream.URL+"/charge", strings.NewReader(`{"amount":1}`))
+	reqBody := req.Body
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if req.Body != reqBody {
+		t.Error("Expected the request to be left unmodified")
+	}
+	body, _ = io.ReadAll(resp.Body)
+	examples := rec.recorder.examples("POST /charge")
+	if !strings.Contains(string(body), `"real":true`) || len(examples) != 1 || examples[0].Request.Body != `{"amount":1}` {
+		t.Errorf("Expected recorded upstream response, got %s", body)
+	}
+}
+
+func TestTransport_HostsAndPassThrough(t *testing.T) {
+	upstream := newUpstream(t)
+	other := newUpstream(t)
+
+	g := New(WithGenerator(&mockGenerator{Response: []byte(`{"mocked":true}`)}))
+	g.Register("GET", "/users", map[string]any{})
+	client := &http.Client{Transport: g.Transport(nil, upstream.URL)}
+
+	get := func(url string) string {
+		t.Helper()
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	if body := get(upstream.URL + "/users"); body != `{"mocked":true}` {
+		t.Errorf("Expected the mocked host to be mocked, got %s", body)
+	}
+	if body := get(other.URL + "/users"); !strings.Contains(body, `"real":true`) {
+		t.Errorf("Expected other hosts to reach the network, got %s", body)
+	}
+
+	// Without a generator or recording there is nothing to mock
+	static := New()
+	static.Register("GET", "/users", map[string]any{})
+	client = &http.Client{Transport: static.Transport(nil)}
+	if body := get(upstream.URL + "/users"); !strings.Contains(body, `"real":true`) {
+		t.Errorf("Expected a pass-through without a generator, got %s", body)
+	}
+}
@@ -1,8 +1,10 @@
 package gobo
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -14,6 +16,20 @@ type FieldInfo struct {
 	Interpreter string // The custom "gobo" tag instruction
 }
 
+// Annotated attaches per-field instructions to a schema that cannot carry
+// gobo struct tags, such as a map or a decoded JSON example. Keys are JSON
+// paths in the same notation as FieldInfo.JSONName ("user.email",
+// "items[*].sku"). Annotated marshals exactly like the wrapped schema.
+type Annotated struct {
+	Schema       any
+	Instructions map[string]string
+}
+
+// MarshalJSON marshals the wrapped schema.
+func (a Annotated) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Schema)
+}
+
 // reflectSchema recursively visits a struct and extracts information about its fields, including custom "gobo" tags.
 func reflectSchema(v any) []FieldInfo {
 	return reflectValue(reflect.ValueOf(v), "")
@@ -23,6 +39,15 @@ func reflectSchema(v any) []FieldInfo {
 func reflectValue(val reflect.Value, prefix string) []FieldInfo {
 	var fields []FieldInfo
 
+	if val.IsValid() && val.CanInterface() {
+		switch s := val.Interface().(type) {
+		case JSONSchema:
+			return schemaFields(s, prefix)
+		case Annotated:
+			return annotateFields(reflectValue(reflect.ValueOf(s.Schema), prefix), s.Instructions)
+		}
+	}
+
 	// Unpack interfaces and pointers
 	if val.Kind() == reflect.Interface || val.Kind() == reflect.Ptr {
 		if val.IsNil() {
@@ -96,12 +121,91 @@ func reflectValue(val reflect.Value, prefix string) []FieldInfo {
 		if elemType.Kind() == reflect.Struct {
 			elemVal := reflect.Zero(elemType)
 			fields = append(fields, reflectValue(elemVal, prefix+"[*]")...)
+		} else if val.Len() > 0 && jsonTypeName(val.Index(0)) != "null" {
+			// Decoded JSON ([]any of maps): describe the first element
+			fields = append(fields, reflectValue(val.Index(0), prefix+"[*]")...)
+		}
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := make([]string, 0, val.Len())
+		for _, k := range val.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fullName := key
+			if prefix != "" {
+				fullName = prefix + "." + key
+			}
+			elem := val.MapIndex(reflect.ValueOf(key).Convert(val.Type().Key()))
+			typ := jsonTypeName(elem)
+			fields = append(fields, FieldInfo{
+				Name:     key,
+				JSONName: fullName,
+				Type:     typ,
+			})
+			if typ != "null" {
+				fields = append(fields, reflectValue(elem, fullName)...)
+			}
 		}
 	}
 
 	return fields
 }
 
+// jsonTypeName describes a map value by its JSON type, since decoded JSON
+// carries no Go type information worth showing.
+func jsonTypeName(val reflect.Value) string {
+	for val.Kind() == reflect.Interface || val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return "null"
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return val.Type().String()
+}
+
+// annotateFields applies explicit instructions to reflected fields. Paths
+// that were not discovered by reflection are appended in sorted order.
+func annotateFields(fields []FieldInfo, instructions map[string]string) []FieldInfo {
+	seen := make(map[string]bool, len(fields))
+	for i := range fields {
+		if instr, ok := instructions[fields[i].JSONName]; ok {
+			fields[i].Interpreter = instr
+		}
+		seen[fields[i].JSONName] = true
+	}
+
+	var extra []string
+	for path := range instructions {
+		if !seen[path] {
+			extra = append(extra, path)
+		}
+	}
+	sort.Strings(extra)
+	for _, path := range extra {
+		fields = append(fields, FieldInfo{Name: path, JSONName: path, Interpreter: instructions[path]})
+	}
+	return fields
+}
+
 // formatFieldInstructions formats the extracted field information into a markdown-like list for the LLM.
 func formatFieldInstructions(fields []FieldInfo) string {
 	if len(fields) == 0 {
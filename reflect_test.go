@@ -57,3 +57,44 @@ func TestFormatFieldInstructions(t *testing.T) {
 		t.Errorf("Format output mismatch.\nExpected:\n%s\nGot:\n%s", expected, out)
 	}
 }
+
+func TestReflectSchema_JSONSchema(t *testing.T) {
+	schema := JSONSchema{
+		"type": "object",
+		"properties": map[string]any{
+			"id": map[string]any{"type": "string", "format": "uuid", "description": "A UUID v4"},
+			"tags": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}},
+			},
+		},
+	}
+
+	fields := reflectSchema(schema)
+	expected := []FieldInfo{
+		{Name: "id", JSONName: "id", Type: "string (uuid)", Interpreter: "A UUID v4"},
+		{Name: "tags", JSONName: "tags", Type: "array"},
+		{Name: "name", JSONName: "tags[*].name", Type: "string"},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Fields mismatch.\nExpected: %+v\nGot:      %+v", expected, fields)
+	}
+}
+
+func TestReflectSchema_AnnotatedMap(t *testing.T) {
+	schema := Annotated{
+		Schema:       map[string]any{"user": map[string]any{"email": ""}, "count": 1.0},
+		Instructions: map[string]string{"user.email": "A work email", "extra": "Added by hand"},
+	}
+
+	fields := reflectSchema(schema)
+	expected := []FieldInfo{
+		{Name: "count", JSONName: "count", Type: "number"},
+		{Name: "user", JSONName: "user", Type: "object"},
+		{Name: "email", JSONName: "user.email", Type: "string", Interpreter: "A work email"},
+		{Name: "extra", JSONName: "extra", Interpreter: "Added by hand"},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Fields mismatch.\nExpected: %+v\nGot:      %+v", expected, fields)
+	}
+}
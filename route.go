@@ -0,0 +1,77 @@
+package gobo
+
+import (
+	"maps"
+	"time"
+)
+
+// RouteOption customizes a single route registered with Register, Stub or
+// Intercept.
+//
+//	mux.Handle("GET /health", g.Stub(Health{}, gobo.RouteGenerator(tmpl)))
+//	mux.Handle("POST /orders", g.Stub(Order{}, gobo.RouteGenerator(broker), gobo.RouteStatus(201)))
+type RouteOption func(*routeSchema)
+
+// RouteGenerator answers the route with gen instead of the instance
+// generator, for example the agent broker for some routes, Ollama for others
+// and templates for simple ones. An Intercept with its own generator mocks
+// even when the instance has none.
+func RouteGenerator(gen Generator) RouteOption {
+	return func(r *routeSchema) {
+		r.Generator = gen
+	}
+}
+
+// RouteStatus sets the response status code (default 200).
+func RouteStatus(status int) RouteOption {
+	return func(r *routeSchema) {
+		r.Status = status
+	}
+}
+
+// RouteLatency delays every response by d.
+func RouteLatency(d time.Duration) RouteOption {
+	return func(r *routeSchema) {
+		r.Latency = d
+	}
+}
+
+// RouteHeader adds a response header.
+func RouteHeader(key, value string) RouteOption {
+	return func(r *routeSchema) {
+		headers := make(map[string]string, len(r.Headers)+1)
+		maps.Copy(headers, r.Headers)
+		headers[key] = value
+		r.Headers = headers
+	}
+}
+
+// RouteExamples adds few-shot examples for the route, see AddExamples.
+func RouteExamples(examples ...Example) RouteOption {
+	return func(r *routeSchema) {
+		r.Examples = append(r.Examples, examples...)
+	}
+}
+
+// RouteRequest validates requests against spec, as Validate does.
+func RouteRequest(spec RequestSpec) RouteOption {
+	return func(r *routeSchema) {
+		r.Request = &spec
+	}
+}
+
+// RoutePrompt customizes the LLM prompt for the route, see PromptConfig.
+func RoutePrompt(p PromptConfig) RouteOption {
+	return func(r *routeSchema) {
+		r.Prompt = &p
+	}
+}
+
+// newRoute builds a route for schema with opts applied.
+func newRoute(schema any, opts []RouteOption) *routeSchema {
+	route := &routeSchema{ResponseSchema: schema}
+	for _, opt := range opts {
+		opt(route)
+	}
+	return route
+}
@@ -0,0 +1,79 @@
+package gobo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteOptions_Stub(t *testing.T) {
+	g := New(WithGenerator(&mockGenerator{Response: []byte(`{"from":"instance"}`)}))
+	routeGen := &mockGenerator{Response: []byte(`{"from":"route"}`)}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /orders", g.Stub(map[string]string{}, RouteGenerator(routeGen), RouteStatus(http.StatusCreated),
+		RouteHeader("Location", "/orders/1"), RouteLatency(20*time.Millisecond)))
+	mux.Handle("GET /orders", g.Stub(map[string]string{}))
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/orders", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != `{"from":"route"}` || rec.Header().Get("Location") != "/orders/1" {
+		t.Errorf("Unexpected route response %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("Expected route latency to apply")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/orders", nil))
+	if rec.Body.String() != `{"from":"instance"}` {
+		t.Errorf("Expected instance generator by default, got %q", rec.Body.String())
+	}
+}
+
+func TestRouteOptions_InterceptWithoutInstanceGenerator(t *testing.T) {
+	g := New()
+	real := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("real"))
+	})
+	tmpl, err := NewTemplateGenerator(`{"path":"{{.Request.URL}}"}`)
+	if err != nil {
+		t.Fatalf("NewTemplateGenerator failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	g.Intercept(real, struct{}{}, RouteGenerator(tmpl)).ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Body.String() != `{"path":"/health"}` {
+		t.Errorf("Expected route template, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	g.Intercept(real, struct{}{}).ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+	if rec.Body.String() != "real" {
+		t.Errorf("Expected pass-through without any generator, got %q", rec.Body.String())
+	}
+}
+
+func TestRouteOptions_Register(t *testing.T) {
+	gen := &captureGenerator{}
+	g := New(WithGenerator(gen))
+	g.Register("POST", "/users", map[string]string{},
+		RouteExamples(example(`{"id":"u_1"}`)),
+		RouteRequest(RequestSpec{BodyRequired: true}),
+		RoutePrompt(PromptConfig{Context: "users"}))
+
+	rec := httptest.NewRecorder()
+	g.Middleware(nil).ServeHTTP(rec, httptest.NewRequest("POST", "/users", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a missing body, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	g.Middleware(nil).ServeHTTP(rec, httptest.NewRequest("POST", "/users", strings.NewReader(`{}`)))
+	if len(gen.reqCtx.Examples) != 1 || gen.reqCtx.Prompt == nil || gen.reqCtx.Prompt.Context != "users" {
+		t.Errorf("Expected route examples and prompt, got %+v", gen.reqCtx)
+	}
+}
@@ -8,7 +8,9 @@ import (
 // Register configures the Gobo instance to intercept a specific method and path prefix.
 // The responseSchema argument should be a sample JSON-marshalable struct or map representing the expected output.
 // The LLM will use this parameter to infer the structure of the JSON it must return.
-func (g *Gobo) Register(method, pathPrefix string, responseSchema any) {
+// The path may also contain ServeMux-style wildcards such as "/users/{id}".
+// Route options set a route-specific generator, status, latency and more.
+func (g *Gobo) Register(method, pathPrefix string, responseSchema any, opts ...RouteOption) {
 	method = strings.ToUpper(method)
 
 	// Ensure the path prefix plays nicely with comparisons
@@ -16,22 +18,100 @@ func (g *Gobo) Register(method, pathPrefix string, responseSchema any) {
 		pathPrefix = "/" + pathPrefix
 	}
 
-	g.routes = append(g.routes, &routeSchema{
-		Method:         method,
-		PathPrefix:     pathPrefix,
-		ResponseSchema: responseSchema,
-	})
+	route := newRoute(responseSchema, opts)
+	route.Method, route.PathPrefix = method, pathPrefix
+	g.addRoute(route)
+}
 
-	g.logf("Registered mock schema for %s %s", method, pathPrefix)
+// addRoute appends a fully built route to the code-registered routes.
+func (g *Gobo) addRoute(route *routeSchema) {
+	g.routes = append(g.routes, route)
+	g.logf("Registered mock schema for %s %s", route.Method, route.PathPrefix)
+	g.prefetch.warm(route)
 }
 
 // match tries to find a registered schema for the incoming request's method and path.
-// Matches by exact method (or "ANY") and string prefix matching on the path.
+// Routes from the mock configuration file are consulted first, then routes
+// registered in code. Matches by exact method (or "ANY") and path, see matchPath.
 func (g *Gobo) match(r *http.Request) *routeSchema {
-	for _, route := range g.routes {
-		if (route.Method == "ANY" || route.Method == r.Method) && strings.HasPrefix(r.URL.Path, route.PathPrefix) {
+	if route := g.matchFile(r); route != nil {
+		return route
+	}
+	return matchRoutes(g.routes, r)
+}
+
+// matchFile finds a route declared in the mock configuration file.
+func (g *Gobo) matchFile(r *http.Request) *routeSchema {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return matchRoutes(g.fileRoutes, r)
+}
+
+// matchRoutes returns the first route in routes that accepts the request.
+func matchRoutes(routes []*routeSchema, r *http.Request) *routeSchema {
+	for _, route := range routes {
+		if (route.Method == "ANY" || route.Method == r.Method) && matchPath(route.PathPrefix, r.URL.Path) {
 			return route
 		}
 	}
 	return nil
 }
+
+// matchPath reports whether path satisfies pattern. Patterns without
+// wildcards use string prefix matching. Patterns containing ServeMux-style
+// wildcards ("/users/{id}", "/files/{path...}") are matched segment by
+// segment, where {name} matches exactly one non-empty segment,
+// {name...} matches the remainder of the path and a trailing {$} anchors
+// the end of the path ("/users/{$}" matches "/users" but not "/users/1").
+func matchPath(pattern, path string) bool {
+	if !strings.Contains(pattern, "{") {
+		return strings.HasPrefix(path, pattern)
+	}
+
+	patSegs := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegs := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range patSegs {
+		if seg == "{$}" {
+			return i == len(pathSegs) || (i == len(pathSegs)-1 && pathSegs[i] == "")
+		}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}") {
+			return true
+		}
+		if i >= len(pathSegs) {
+			return false
+		}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if pathSegs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != pathSegs[i] {
+			return false
+		}
+	}
+	return len(patSegs) == len(pathSegs)
+}
+
+// pathValues returns the wildcard values of path under pattern, for routes
+// matched by Gobo rather than ServeMux. It assumes matchPath accepted path.
+func pathValues(pattern, path string) map[string]string {
+	values := make(map[string]string)
+	if !strings.Contains(pattern, "{") {
+		return values
+	}
+	patSegs := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegs := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range patSegs {
+		if i >= len(pathSegs) || !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") || seg == "{$}" {
+			continue
+		}
+		name := seg[1 : len(seg)-1]
+		if rest, ok := strings.CutSuffix(name, "..."); ok {
+			values[rest] = strings.Join(pathSegs[i:], "/")
+			break
+		}
+		values[name] = pathSegs[i]
+	}
+	return values
+}
@@ -0,0 +1,240 @@
+package gobo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxShadowCapture bounds how much of a real response Shadow buffers.
+const maxShadowCapture = 4 << 20
+
+// maxDriftRoutes bounds the routes the drift report tracks, as requests
+// not routed by a ServeMux pattern are keyed by their concrete path.
+const maxDriftRoutes = 500
+
+// Drift kinds reported by Shadow.
+const (
+	DriftMissingField    = "missing_field"
+	DriftTypeMismatch    = "type_mismatch"
+	DriftUnexpectedField = "unexpected_field"
+	DriftConstraint      = "constraint"
+)
+
+// DriftIssue is a recurring difference between real responses and the schema.
+type DriftIssue struct {
+	Kind     string    `json:"kind"`
+	Field    string    `json:"field"`
+	Message  string    `json:"message"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// RouteDrift summarizes shadow checks for a single route.
+type RouteDrift struct {
+	Route       string       `json:"route"`
+	Checked     int          `json:"checked"`
+	Drifted     int          `json:"drifted"`
+	LastChecked time.Time    `json:"last_checked"`
+	Issues      []DriftIssue `json:"issues,omitempty"`
+}
+
+// driftTracker accumulates shadow results per route.
+type driftTracker struct {
+	mu     sync.Mutex
+	routes map[string]*routeDriftState
+}
+
+type routeDriftState struct {
+	RouteDrift
+	issues map[string]*DriftIssue // keyed by kind and field
+}
+
+func newDriftTracker() *driftTracker {
+	return &driftTracker{routes: make(map[string]*routeDriftState)}
+}
+
+// Shadow wraps a real handler and always serves its response. Successful
+// JSON responses are captured and compared against schema, and any drift —
+// missing fields, type mismatches, unexpected fields — is recorded in the
+// drift report served at /_gobo/drift, and logged in debug mode. Routes are
+// keyed by their ServeMux pattern; requests without one are keyed by path,
+// up to 500 routes. This catches mocks going stale against the real backend
+// during staging runs.
+func (g *Gobo) Shadow(handler http.Handler, schema any) http.Handler {
+	strict := strictSchema(jsonSchemaFor(schema))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(rec, r)
+
+		if rec.status < 200 || rec.status >= 300 || rec.truncated || rec.body.Len() == 0 {
+			return
+		}
+		var value any
+		if err := json.Unmarshal(rec.body.Bytes(), &value); err != nil {
+			return // not JSON; nothing to compare
+		}
+
+		key := r.Pattern
+		if key == "" {
+			key = r.Method + " " + r.URL.Path
+		}
+		violations := validateSchema(strict, value, "$")
+		if !g.drift.record(key, violations) {
+			g.logf("Not tracking drift on %s: %d routes already tracked", key, maxDriftRoutes)
+			return
+		}
+		for _, v := range violations {
+			g.logf("Drift on %s: %s %s", key, driftKind(v), v)
+		}
+	})
+}
+
+// DriftReport returns the shadow results for every route, sorted by route.
+func (g *Gobo) DriftReport() []RouteDrift {
+	return g.drift.report()
+}
+
+// record adds a shadow result for route. It reports false when the route
+// is new and maxDriftRoutes are already tracked.
+func (d *driftTracker) record(route string, violations []Violation) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, ok := d.routes[route]
+	if !ok {
+		if len(d.routes) >= maxDriftRoutes {
+			return false
+		}
+		state = &routeDriftState{RouteDrift: RouteDrift{Route: route}, issues: make(map[string]*DriftIssue)}
+		d.routes[route] = state
+	}
+
+	now := time.Now()
+	state.Checked++
+	state.LastChecked = now
+	if len(violations) > 0 {
+		state.Drifted++
+	}
+	for _, v := range violations {
+		kind := driftKind(v)
+		issue, ok := state.issues[kind+" "+v.Field]
+		if !ok {
+			issue = &DriftIssue{Kind: kind, Field: v.Field}
+			state.issues[kind+" "+v.Field] = issue
+		}
+		issue.Message = v.Message
+		issue.Count++
+		issue.LastSeen = now
+	}
+	return true
+}
+
+func (d *driftTracker) report() []RouteDrift {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make([]RouteDrift, 0, len(d.routes))
+	for _, state := range d.routes {
+		rd := state.RouteDrift
+		rd.Issues = make([]DriftIssue, 0, len(state.issues))
+		for _, issue := range state.issues {
+			rd.Issues = append(rd.Issues, *issue)
+		}
+		sort.Slice(rd.Issues, func(i, j int) bool {
+			if rd.Issues[i].Field != rd.Issues[j].Field {
+				return rd.Issues[i].Field < rd.Issues[j].Field
+			}
+			return rd.Issues[i].Kind < rd.Issues[j].Kind
+		})
+		out = append(out, rd)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Route < out[j].Route })
+	return out
+}
+
+// driftKind classifies a schema violation found in a real response.
+func driftKind(v Violation) string {
+	switch {
+	case v.Message == "is required":
+		return DriftMissingField
+	case v.Message == "unexpected property":
+		return DriftUnexpectedField
+	case strings.HasPrefix(v.Message, "expected "):
+		return DriftTypeMismatch
+	}
+	return DriftConstraint
+}
+
+// strictSchema returns a copy of node in which every object that lists its
+// properties rejects unknown ones, so extra fields in real responses are
+// reported as drift.
+func strictSchema(node map[string]any) map[string]any {
+	out := deepCopySchema(node)
+	var walk func(n map[string]any)
+	walk = func(n map[string]any) {
+		if n == nil {
+			return
+		}
+		if props, ok := n["properties"].(map[string]any); ok {
+			if _, set := n["additionalProperties"]; !set {
+				n["additionalProperties"] = false
+			}
+			for _, p := range props {
+				walk(asSchemaNode(p))
+			}
+		}
+		walk(asSchemaNode(n["items"]))
+		for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+			parts, _ := n[key].([]any)
+			for _, p := range parts {
+				walk(asSchemaNode(p))
+			}
+		}
+	}
+	walk(out)
+	return out
+}
+
+// captureWriter forwards a response to the client while keeping a copy.
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	truncated   bool
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.status = status
+		c.wroteHeader = true
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	c.wroteHeader = true
+	if c.body.Len()+len(p) > maxShadowCapture {
+		c.truncated = true
+	} else {
+		c.body.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Flush supports streaming handlers.
+func (c *captureWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
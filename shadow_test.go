@@ -0,0 +1,118 @@
+package gobo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type shadowUser struct {
+	ID    string `json:"id"`
+	Age   int    `json:"age"`
+	Email string `json:"email,omitempty"`
+}
+
+func TestShadow_ReportsDrift(t *testing.T) {
+	g := New()
+	live := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"age":"42","nickname":"zed"}`))
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", g.Shadow(live, shadowUser{}))
+	mux.Handle(AdminPrefix, g.AdminHandler())
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/users/1", nil))
+	if rr.Body.String() != `{"age":"42","nickname":"zed"}` {
+		t.Errorf("Expected the real response to be served, got %s", rr.Body.String())
+	}
+
+	report := g.DriftReport()
+	if len(report) != 1 || report[0].Route != "GET /users/{id}" || report[0].Drifted != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	kinds := map[string]string{}
+	for _, issue := range report[0].Issues {
+		kinds[issue.Field] = issue.Kind
+	}
+	expected := map[string]string{
+		"$.id":       DriftMissingField,
+		"$.age":      DriftTypeMismatch,
+		"$.nickname": DriftUnexpectedField,
+	}
+	for field, kind := range expected {
+		if kinds[field] != kind {
+			t.Errorf("Expected %s for %s, got %q", kind, field, kinds[field])
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/_gobo/drift", nil))
+	var body struct {
+		Routes []RouteDrift `json:"routes"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || len(body.Routes) != 1 {
+		t.Errorf("Expected drift report endpoint, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestShadow_NoDriftAndNonJSON(t *testing.T) {
+	g := New()
+	body := `{"id":"u1","age":3}`
+	live := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+	handler := g.Shadow(live, shadowUser{})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/u", nil))
+	body = "plain text"
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/u", nil))
+
+	report := g.DriftReport()
+	if len(report) != 1 || report[0].Checked != 1 || report[0].Drifted != 0 {
+		t.Errorf("Expected one clean check, got %+v", report)
+	}
+}
+
+type shadowBase struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+}
+
+type shadowOrder struct {
+	shadowBase
+	Customer *shadowUser      `json:"customer"`
+	Items    []string         `json:"items"`
+	Meta     map[string]int64 `json:"meta"`
+}
+
+func TestShadow_EncodedStructs(t *testing.T) {
+	g := New()
+	live := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(shadowOrder{shadowBase: shadowBase{ID: "o1", CreatedAt: "today"}})
+	})
+	g.Shadow(live, shadowOrder{}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/o1", nil))
+
+	report := g.DriftReport()
+	if len(report) != 1 || report[0].Drifted != 0 {
+		t.Errorf("Expected embedded fields and nil fields not to drift, got %+v", report)
+	}
+}
+
+func TestShadow_BoundsUnroutedPaths(t *testing.T) {
+	g := New()
+	live := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"u1"}`))
+	})
+	handler := g.Shadow(live, shadowUser{})
+	for i := range maxDriftRoutes + 10 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/"+strconv.Itoa(i), nil))
+	}
+	if n := len(g.DriftReport()); n != maxDriftRoutes {
+		t.Errorf("Expected at most %d tracked routes, got %d", maxDriftRoutes, n)
+	}
+}
@@ -0,0 +1,86 @@
+package gobo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StaticGenerator answers every request with the schema marshaled as-is.
+// It is what Stub falls back to when no generator is configured.
+type StaticGenerator struct{}
+
+// GenerateResponse implements the Generator interface.
+func (StaticGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	out, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return out, nil
+}
+
+// FixtureGenerator serves the contents of a JSON file, or of a file in the
+// route's format when that is not JSON (see RouteFormat). The file is read
+// on every request so edits show up without a restart.
+type FixtureGenerator struct {
+	Path string
+}
+
+// GenerateResponse implements the Generator interface.
+func (f FixtureGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	out, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	if wantsJSON(ctx) && !json.Valid(out) {
+		return nil, fmt.Errorf("fixture %s is not valid json", f.Path)
+	}
+	return out, nil
+}
+
+// TemplateGenerator renders a text/template into the response body.
+// The template receives a TemplateData value and may use the helper
+// functions "json", "uuid" and "now".
+type TemplateGenerator struct {
+	tmpl *template.Template
+}
+
+// TemplateData is the value passed to response templates.
+type TemplateData struct {
+	Request RequestContext
+	Schema  any
+}
+
+// NewTemplateGenerator parses text as a response template.
+func NewTemplateGenerator(text string) (*TemplateGenerator, error) {
+	tmpl, err := template.New("response").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"uuid": func() string { return uuid.New().String() },
+		"now":  func() string { return time.Now().UTC().Format(time.RFC3339) },
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return &TemplateGenerator{tmpl: tmpl}, nil
+}
+
+// GenerateResponse implements the Generator interface.
+func (t *TemplateGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, TemplateData{Request: reqCtx, Schema: schema}); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	if wantsJSON(ctx) && !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template rendered invalid json")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,279 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a single server-sent event of a streaming route.
+type Event struct {
+	// ID is sent as the event id; events without one are numbered from 1 in
+	// stream order, so clients can resume with Last-Event-ID.
+	ID string `json:"id,omitempty"`
+	// Name is the event type; empty means the default "message".
+	Name string `json:"event,omitempty"`
+	// Data is the payload: strings are sent as-is, anything else as JSON.
+	Data any `json:"data"`
+	// Delay is how long to wait before sending the event.
+	Delay time.Duration `json:"-"`
+}
+
+// eventJSON is the wire form of Event, with the delay in milliseconds.
+type eventJSON struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"event,omitempty"`
+	Data    any    `json:"data"`
+	DelayMS int64  `json:"delay_ms,omitempty"`
+}
+
+// MarshalJSON encodes the delay as "delay_ms".
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eventJSON{ID: e.ID, Name: e.Name, Data: e.Data, DelayMS: e.Delay.Milliseconds()})
+}
+
+// UnmarshalJSON decodes the delay from "delay_ms".
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw eventJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = Event{ID: raw.ID, Name: raw.Name, Data: raw.Data, Delay: time.Duration(raw.DelayMS) * time.Millisecond}
+	return nil
+}
+
+// StreamGenerator is implemented by generators that produce events
+// incrementally, such as the AsyncBroker, where an agent pushes events to a
+// parked stream. send writes one event to the client and fails once the
+// client is gone. Streaming routes served by other generators ask them for
+// the whole sequence as a JSON array of events, see RouteStream.
+type StreamGenerator interface {
+	GenerateStream(ctx context.Context, reqCtx RequestContext, schema any, send func(Event) error) error
+}
+
+// RouteStream makes the route answer with a text/event-stream instead of a
+// single JSON body. The route schema then describes the data of each event.
+// Generators that do not implement StreamGenerator are asked for a JSON
+// array of events ({"event", "id", "data", "delay_ms"}); delay is used
+// between events that do not set their own.
+func RouteStream(delay time.Duration) RouteOption {
+	return func(r *routeSchema) {
+		r.Stream = true
+		r.StreamDelay = delay
+	}
+}
+
+// streamSchema describes a sequence of events whose data matches schema.
+// Sample values are kept as the data example, so static stream routes send
+// the sample itself.
+func streamSchema(schema any) JSONSchema {
+	data := jsonSchemaFor(schema)
+	if _, isSchema := schema.(JSONSchema); !isSchema && schema != nil {
+		data["example"] = schema
+	}
+	return JSONSchema{
+		"type":        "array",
+		"description": "A sequence of server-sent events, in the order they are sent",
+		"items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"event":    map[string]any{"type": "string", "description": "Event type, empty for the default message type"},
+				"id":       map[string]any{"type": "string", "description": "Event id, such as a sequence number"},
+				"data":     data,
+				"delay_ms": map[string]any{"type": "integer", "description": "Milliseconds to wait before sending this event"},
+			},
+			"required": []any{"data"},
+		},
+	}
+}
+
+// writeStream answers a streaming route, flushing events one by one. With a
+// Last-Event-ID header, events up to and including that id are skipped when
+// the generator replays a sequence containing it.
+func (g *Gobo) writeStream(w http.ResponseWriter, r *http.Request, route *routeSchema, gen Generator, reqCtx RequestContext) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Gobo Mock Generation Failed: streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ctx := r.Context()
+	lastID := r.Header.Get("Last-Event-ID")
+
+	var events []Event
+	incremental := streams(gen)
+	if !incremental {
+		body, err := gen.GenerateResponse(ctx, reqCtx, streamSchema(route.ResponseSchema))
+		if err == nil {
+			err = json.Unmarshal(body, &events)
+		}
+		if err != nil {
+			g.logf("Error generating stream: %v", err)
+			http.Error(w, "Gobo Mock Generation Failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		events = numberEvents(events, 0)
+		events = resumeAfter(events, lastID)
+	}
+
+	if route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	for k, v := range route.Headers {
+		w.Header().Set(k, v)
+	}
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	flusher.Flush()
+
+	// Live streams continue numbering after a numeric Last-Event-ID
+	base, _ := strconv.Atoi(lastID)
+	sent := 0
+	send := func(e Event) error {
+		delay := e.Delay
+		if delay == 0 && sent > 0 {
+			delay = route.StreamDelay
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		sent++
+		if e.ID == "" && incremental {
+			e.ID = strconv.Itoa(base + sent)
+		}
+		if err := writeEvent(w, e); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if incremental {
+		if err := gen.(StreamGenerator).GenerateStream(ctx, reqCtx, route.ResponseSchema, send); err != nil {
+			g.logf("Stream for %s %s ended: %v", r.Method, r.URL.Path, err)
+		}
+		return
+	}
+	for _, e := range events {
+		if err := send(e); err != nil {
+			return
+		}
+	}
+}
+
+// streams reports whether gen produces events incrementally. Caching and
+// limiting generators stream when the generator they wrap does, and a
+// FallbackGenerator when its first backend does.
+func streams(gen Generator) bool {
+	switch w := gen.(type) {
+	case *CachingGenerator:
+		return streams(w.gen)
+	case *LimitingGenerator:
+		return streams(w.gen)
+	case *FallbackGenerator:
+		return len(w.backends) > 0 && streams(w.backends[0].Generator)
+	}
+	_, ok := gen.(StreamGenerator)
+	return ok
+}
+
+// streamFrom streams events from gen for a wrapper's GenerateStream. A gen
+// that does not stream is asked for the whole sequence, sent event by event.
+func streamFrom(ctx context.Context, gen Generator, reqCtx RequestContext, schema any, send func(Event) error) error {
+	if streams(gen) {
+		return gen.(StreamGenerator).GenerateStream(ctx, reqCtx, schema, send)
+	}
+	body, err := gen.GenerateResponse(ctx, reqCtx, streamSchema(schema))
+	if err != nil {
+		return err
+	}
+	var events []Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return fmt.Errorf("failed to decode events: %w", err)
+	}
+	for _, e := range events {
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// numberEvents assigns sequential ids to events without one, continuing
+// after offset.
+func numberEvents(events []Event, offset int) []Event {
+	for i := range events {
+		if events[i].ID == "" {
+			events[i].ID = strconv.Itoa(offset + i + 1)
+		}
+	}
+	return events
+}
+
+// resumeAfter drops the events up to and including the one with id lastID,
+// if the sequence contains it.
+func resumeAfter(events []Event, lastID string) []Event {
+	if lastID == "" {
+		return events
+	}
+	for i, e := range events {
+		if e.ID == lastID {
+			return events[i+1:]
+		}
+	}
+	return events
+}
+
+// sseLineBreaks strips the line breaks of the text/event-stream format.
+var sseLineBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+// writeEvent writes e in the text/event-stream format.
+func writeEvent(w http.ResponseWriter, e Event) error {
+	var data string
+	switch d := e.Data.(type) {
+	case string:
+		data = d
+	case json.RawMessage:
+		data = string(d)
+	default:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event data: %w", err)
+		}
+		data = string(b)
+	}
+
+	// A line break would end the field and start another, so generated ids
+	// and names lose theirs and data is split into lines at any of them
+	var b strings.Builder
+	if id := sseLineBreaks.Replace(e.ID); id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	if name := sseLineBreaks.Replace(e.Name); name != "" {
+		b.WriteString("event: " + name + "\n")
+	}
+	data = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(data)
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	_, err := w.Write([]byte(b.String()))
+	return err
+}
@@ -0,0 +1,193 @@
+package gobo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStream_StaticGenerator(t *testing.T) {
+	g := New()
+	rec := httptest.NewRecorder()
+	g.Stub(map[string]any{"token": "hi"}, RouteStream(0)).ServeHTTP(rec, httptest.NewRequest("GET", "/chat", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", ct)
+	}
+	if want := "id: 1\ndata: {\"token\":\"hi\"}\n\n"; rec.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, rec.Body.String())
+	}
+}
+
+func TestStream_EventsAndResume(t *testing.T) {
+	gen := &mockGenerator{Response: []byte(`[
+		{"event": "start", "data": {"n": 1}},
+		{"data": "line one\nline two", "delay_ms": 5},
+		{"id": "done", "event": "end", "data": {"n": 3}}
+	]`)}
+	g := New(WithGenerator(gen))
+	stub := g.Stub(map[string]any{"n": 0}, RouteStream(time.Millisecond))
+
+	rec := httptest.NewRecorder()
+	stub.ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+	want := "id: 1\nevent: start\ndata: {\"n\":1}\n\n" +
+		"id: 2\ndata: line one\ndata: line two\n\n" +
+		"id: done\nevent: end\ndata: {\"n\":3}\n\n"
+	if rec.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, rec.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	rec = httptest.NewRecorder()
+	stub.ServeHTTP(rec, req)
+	if rec.Body.String() != "id: done\nevent: end\ndata: {\"n\":3}\n\n" {
+		t.Errorf("Expected to resume after event 2, got %q", rec.Body.String())
+	}
+}
+
+func TestStream_LineBreaksInFields(t *testing.T) {
+	gen := &mockGenerator{Response: []byte(`[{"id": "1\ndata: injected", "event": "a\r\nevent: b", "data": "x\ry"}]`)}
+	rec := httptest.NewRecorder()
+	New(WithGenerator(gen)).Stub(map[string]any{}, RouteStream(0)).ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+	if want := "id: 1data: injected\nevent: aevent: b\ndata: x\ndata: y\n\n"; rec.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, rec.Body.String())
+	}
+}
+
+func TestStream_StreamSchema(t *testing.T) {
+	gen := &captureSchemaGenerator{}
+	g := New(WithGenerator(gen))
+	g.Stub(map[string]any{"n": 0}, RouteStream(0)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/events", nil))
+
+	schema, ok := gen.schema.(JSONSchema)
+	if !ok || schema["type"] != "array" {
+		t.Fatalf("Expected an array of events schema, got %#v", gen.schema)
+	}
+	data := asSchemaNode(asSchemaNode(asSchemaNode(schema["items"])["properties"])["data"])
+	if data["type"] != "object" {
+		t.Errorf("Expected event data to follow the route schema, got %v", data)
+	}
+}
+
+// captureSchemaGenerator records the schema it was asked for.
+type captureSchemaGenerator struct {
+	schema any
+}
+
+func (c *captureSchemaGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	c.schema = schema
+	return []byte(`[]`), nil
+}
+
+func TestStream_Broker(t *testing.T) {
+	broker := NewAsyncBroker()
+	g := New(WithGenerator(broker))
+	srv := httptest.NewServer(g.Stub(map[string]any{"token": ""}, RouteStream(0)))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/chat", nil)
+	req.Header.Set("Last-Event-ID", "4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var pending []PendingRequest
+	for len(pending) == 0 {
+		pending = broker.GetPendingRequests()
+		time.Sleep(time.Millisecond)
+	}
+	pr := pending[0]
+	if !pr.Stream || pr.LastEventID != "4" {
+		t.Errorf("Expected a stream resuming after 4, got %+v", pr)
+	}
+
+	// The first event is flushed before the stream is closed
+	if err := broker.PushEvent(pr.ID, Event{Data: map[string]string{"token": "Hel"}}); err != nil {
+		t.Fatalf("PushEvent failed: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, _ := resp.Body.Read(buf)
+	if got := string(buf[:n]); got != "id: 5\ndata: {\"token\":\"Hel\"}\n\n" {
+		t.Errorf("Unexpected first event %q", got)
+	}
+
+	if err := broker.SubmitResponse(pr.ID, []byte(`[{"event":"done","data":"lo"}]`)); err != nil {
+		t.Fatalf("SubmitResponse failed: %v", err)
+	}
+	rest := new(strings.Builder)
+	for {
+		n, err := resp.Body.Read(buf)
+		rest.Write(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+	if rest.String() != "id: 6\nevent: done\ndata: lo\n\n" {
+		t.Errorf("Unexpected remaining events %q", rest.String())
+	}
+	if err := broker.PushEvent(pr.ID, Event{Data: "late"}); err == nil {
+		t.Error("Expected PushEvent to fail after the stream ended")
+	}
+}
+
+func TestStream_WrappedBroker(t *testing.T) {
+	broker := NewAsyncBroker()
+	limit := NewLimitingGenerator(broker, LimitConfig{MaxInFlight: 1})
+	gen := NewFallbackGenerator([]FallbackBackend{
+		{Name: "agent", Generator: NewCachingGenerator(limit, CacheConfig{}), Timeout: 100 * time.Millisecond},
+		{Name: "static", Generator: StaticGenerator{}},
+	})
+	g := New(WithGenerator(gen))
+	srv := httptest.NewServer(g.Stub(map[string]any{"token": "static"}, RouteStream(0)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/chat")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var pending []PendingRequest
+	for len(pending) == 0 {
+		pending = broker.GetPendingRequests()
+		time.Sleep(time.Millisecond)
+	}
+	if !pending[0].Stream || limit.Stats().InFlight != 1 {
+		t.Fatalf("Expected a parked stream holding a slot, got %+v and %+v", pending[0], limit.Stats())
+	}
+	if err := broker.PushEvent(pending[0].ID, Event{Data: "Hel"}); err != nil {
+		t.Fatalf("PushEvent failed: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, _ := resp.Body.Read(buf)
+	if got := string(buf[:n]); got != "id: 1\ndata: Hel\n\n" {
+		t.Errorf("Expected the event before the stream ends, got %q", got)
+	}
+
+	// Once an event was sent the timeout no longer applies, and the
+	// stream stays with the agent
+	time.Sleep(150 * time.Millisecond)
+	_ = broker.CloseStream(pending[0].ID)
+	rest, _ := io.ReadAll(resp.Body)
+	if len(rest) != 0 {
+		t.Errorf("Expected no fallback events after the agent streamed, got %q", rest)
+	}
+
+	// Without an agent, the stream falls back once the timeout passes
+	resp, err = http.Get(srv.URL + "/chat")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `data: {"token":"static"}`) {
+		t.Errorf("Expected the static backend's events, got %q", body)
+	}
+}
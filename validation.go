@@ -0,0 +1,237 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RequestSpec describes what a route expects from its clients. Requests that
+// do not satisfy it are answered with a 400 or 422 error instead of a mock,
+// so client bugs surface while mocking.
+type RequestSpec struct {
+	// Body is the expected JSON body: a Go struct (fields without omitempty
+	// are required), a sample map, or a JSONSchema. Nil skips body checks.
+	Body any
+	// BodyRequired rejects requests that arrive without a body.
+	BodyRequired bool
+	// Query lists the expected query parameters.
+	Query []Param
+	// Headers lists the expected request headers.
+	Headers []Param
+	// PassThrough hands invalid requests to the generator instead of
+	// rejecting them, so it can craft the error itself from
+	// RequestContext.Validation.
+	PassThrough bool
+}
+
+// Param describes a query parameter or header. Values are coerced according
+// to the schema type (integer, number, boolean, comma-separated array)
+// before validation.
+type Param struct {
+	Name     string     `json:"name"`
+	Required bool       `json:"required,omitempty"`
+	Schema   JSONSchema `json:"schema,omitempty"`
+}
+
+// ValidationResult is the outcome of checking a request against its
+// RequestSpec. It is exposed to generators and agents as
+// RequestContext.Validation.
+type ValidationResult struct {
+	Valid bool `json:"valid"`
+	// Status is the error status a real API would answer with: 400 for
+	// malformed bodies and bad parameters, 422 for well-formed bodies that
+	// violate the schema. Zero when valid.
+	Status     int         `json:"status,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Violation is a single validation failure.
+type Violation struct {
+	// In is where the problem was found: "body", "query" or "header".
+	In      string `json:"in,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// String renders the violation as "field: message".
+func (v Violation) String() string {
+	return v.Field + ": " + v.Message
+}
+
+// ErrorEnvelope shapes the JSON body of a validation error response.
+type ErrorEnvelope func(result ValidationResult) any
+
+// DefaultErrorEnvelope renders
+//
+//	{"error": {"status": 422, "message": "Unprocessable Entity", "violations": [...]}}
+func DefaultErrorEnvelope(result ValidationResult) any {
+	return map[string]any{
+		"error": map[string]any{
+			"status":     result.Status,
+			"message":    http.StatusText(result.Status),
+			"violations": result.Violations,
+		},
+	}
+}
+
+// WithErrorEnvelope sets how validation errors are rendered, to match the
+// error format of the API being mocked.
+func WithErrorEnvelope(envelope ErrorEnvelope) Option {
+	return func(g *Gobo) {
+		g.errorEnvelope = envelope
+	}
+}
+
+// validationKey carries a ValidationResult from Validate to the generator.
+type validationKey struct{}
+
+// Validate wraps a handler, typically a Stub or Intercept, and checks every
+// request against spec first. Invalid requests are answered with an error
+// unless spec.PassThrough is set; the result reaches generators through
+// RequestContext.Validation either way.
+//
+//	mux.Handle("POST /users", g.Validate(gobo.RequestSpec{Body: CreateUser{}}, g.Stub(User{})))
+func (g *Gobo) Validate(spec RequestSpec, next http.Handler) http.Handler {
+	validate := func(w http.ResponseWriter, r *http.Request) {
+		result := spec.validate(r, extractRequestContext(r, g.maxBody))
+		if !result.Valid && !spec.PassThrough {
+			g.writeValidationError(w, r, result)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), validationKey{}, &result)))
+	}
+	if rh, ok := next.(*routeHandler); ok {
+		// Still a Gobo route for Handle
+		return &routeHandler{route: rh.route, serve: validate}
+	}
+	return http.HandlerFunc(validate)
+}
+
+// validationFromContext returns the result stored by Validate, if any.
+func validationFromContext(ctx context.Context) *ValidationResult {
+	result, _ := ctx.Value(validationKey{}).(*ValidationResult)
+	return result
+}
+
+// writeValidationError answers a request that failed validation.
+func (g *Gobo) writeValidationError(w http.ResponseWriter, r *http.Request, result ValidationResult) {
+	g.logf("Rejected %s %s with %d: %v", r.Method, r.URL.Path, result.Status, result.Violations)
+	envelope := g.errorEnvelope
+	if envelope == nil {
+		envelope = DefaultErrorEnvelope
+	}
+	writeJSON(w, result.Status, envelope(result))
+}
+
+// validate checks the request's parameters and body against the spec.
+func (spec *RequestSpec) validate(r *http.Request, reqCtx RequestContext) ValidationResult {
+	var violations []Violation
+	badRequest := false
+	body := reqCtx.Body
+
+	query := r.URL.Query()
+	for _, p := range spec.Query {
+		values, present := query[p.Name]
+		var raw string
+		if present && len(values) > 0 {
+			raw = values[0]
+		}
+		if v := p.check(raw, present, "query"); len(v) > 0 {
+			violations = append(violations, v...)
+			badRequest = true
+		}
+	}
+	for _, p := range spec.Headers {
+		raw := r.Header.Get(p.Name)
+		if v := p.check(raw, raw != "", "header"); len(v) > 0 {
+			violations = append(violations, v...)
+			badRequest = true
+		}
+	}
+
+	if spec.Body != nil || spec.BodyRequired {
+		truncated := reqCtx.BodyTruncated
+		if truncated && reqCtx.Files == nil && reqCtx.Form == nil {
+			// Only the capture is cut; validate the whole body
+			if full, err := readFullBody(r); err == nil {
+				body, truncated = string(full), false
+			}
+		}
+		switch {
+		case truncated:
+			violations = append(violations, Violation{In: "body", Field: "$", Message: "request body exceeds the capture limit and cannot be validated"})
+			badRequest = true
+		case body == "":
+			if spec.BodyRequired {
+				violations = append(violations, Violation{In: "body", Field: "$", Message: "request body is required"})
+				badRequest = true
+			}
+		default:
+			var value any
+			if err := json.Unmarshal([]byte(body), &value); err != nil {
+				violations = append(violations, Violation{In: "body", Field: "$", Message: "invalid json: " + err.Error()})
+				badRequest = true
+			} else if spec.Body != nil {
+				for _, v := range validateSchema(jsonSchemaFor(spec.Body), value, "$") {
+					v.In = "body"
+					violations = append(violations, v)
+				}
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return ValidationResult{Valid: true}
+	}
+	status := http.StatusUnprocessableEntity
+	if badRequest {
+		status = http.StatusBadRequest
+	}
+	return ValidationResult{Status: status, Violations: violations}
+}
+
+// check validates a single raw parameter value.
+func (p Param) check(raw string, present bool, in string) []Violation {
+	if !present {
+		if p.Required {
+			return []Violation{{In: in, Field: p.Name, Message: "is required"}}
+		}
+		return nil
+	}
+	if p.Schema == nil {
+		return nil
+	}
+
+	violations := validateSchema(p.Schema, coerceParam(p.Schema, raw), p.Name)
+	for i := range violations {
+		violations[i].In = in
+	}
+	return violations
+}
+
+// coerceParam converts a raw query or header value into the JSON type its
+// schema expects. Values that do not parse are left as strings so the
+// schema check reports the type mismatch.
+func coerceParam(schema map[string]any, raw string) any {
+	switch schemaType(schema) {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	case "array":
+		items := asSchemaNode(schema["items"])
+		var out []any
+		for _, part := range strings.Split(raw, ",") {
+			out = append(out, coerceParam(items, part))
+		}
+		return out
+	}
+	return raw
+}
@@ -0,0 +1,96 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Bio   string `json:"bio,omitempty"`
+}
+
+// captureGenerator records the request context it was called with.
+type captureGenerator struct {
+	reqCtx RequestContext
+}
+
+func (c *captureGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	c.reqCtx = reqCtx
+	return []byte(`{"ok":true}`), nil
+}
+
+func TestValidate(t *testing.T) {
+	gen := &captureGenerator{}
+	g := New(WithGenerator(gen))
+	spec := RequestSpec{
+		Body:    createUserRequest{},
+		Query:   []Param{{Name: "limit", Schema: JSONSchema{"type": "integer", "maximum": 100.0}}},
+		Headers: []Param{{Name: "X-Tenant", Required: true}},
+	}
+	handler := g.Validate(spec, g.Stub(map[string]any{"ok": true}))
+
+	cases := []struct {
+		name   string
+		target string
+		tenant string
+		body   string
+		status int
+		field  string
+	}{
+		{"valid", "/users?limit=10", "acme", `{"name":"a","email":"b"}`, http.StatusOK, ""},
+		{"missing header", "/users", "", `{"name":"a","email":"b"}`, http.StatusBadRequest, "X-Tenant"},
+		{"bad query", "/users?limit=500", "acme", `{"name":"a","email":"b"}`, http.StatusBadRequest, "limit"},
+		{"malformed body", "/users", "acme", `{"name":`, http.StatusBadRequest, "$"},
+		{"schema violation", "/users", "acme", `{"name":"a"}`, http.StatusUnprocessableEntity, "$.email"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("POST", c.target, strings.NewReader(c.body))
+		if c.tenant != "" {
+			req.Header.Set("X-Tenant", c.tenant)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != c.status {
+			t.Errorf("%s: expected %d, got %d %s", c.name, c.status, rr.Code, rr.Body.String())
+			continue
+		}
+		if c.field != "" && !strings.Contains(rr.Body.String(), `"field":"`+c.field+`"`) {
+			t.Errorf("%s: expected violation for %s, got %s", c.name, c.field, rr.Body.String())
+		}
+	}
+
+	if gen.reqCtx.Validation == nil || !gen.reqCtx.Validation.Valid {
+		t.Errorf("Expected valid result in RequestContext, got %+v", gen.reqCtx.Validation)
+	}
+}
+
+func TestValidate_PassThroughAndEnvelope(t *testing.T) {
+	gen := &captureGenerator{}
+	g := New(WithGenerator(gen), WithErrorEnvelope(func(result ValidationResult) any {
+		return map[string]any{"code": "INVALID", "count": len(result.Violations)}
+	}))
+
+	rr := httptest.NewRecorder()
+	g.Validate(RequestSpec{BodyRequired: true}, g.Stub(nil)).ServeHTTP(rr, httptest.NewRequest("POST", "/x", nil))
+	var envelope map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil || envelope["code"] != "INVALID" {
+		t.Errorf("Expected custom envelope, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	g.Validate(RequestSpec{BodyRequired: true, PassThrough: true}, g.Stub(nil)).ServeHTTP(rr, httptest.NewRequest("POST", "/x", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected pass-through to reach the generator, got %d", rr.Code)
+	}
+	if v := gen.reqCtx.Validation; v == nil || v.Valid || v.Status != http.StatusBadRequest {
+		t.Errorf("Expected failed validation in RequestContext, got %+v", v)
+	}
+}
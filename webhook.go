@@ -0,0 +1,523 @@
+package gobo
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSignatureHeader carries the HMAC signature of signed webhooks.
+const DefaultSignatureHeader = "X-Gobo-Signature"
+
+// maxWebhookDeliveries is how many deliveries WebhookDeliveries remembers.
+const maxWebhookDeliveries = 100
+
+// Webhook describes a callback Gobo sends after a route responds, the way a
+// payment gateway notifies the merchant once a charge settles. Deliveries
+// are POSTed with a JSON payload after Delay and retried with exponential
+// backoff until the receiver answers 2xx.
+type Webhook struct {
+	// Name identifies the webhook for TriggerWebhook and SendWebhook.
+	Name string
+	// URL is the callback URL. {name} placeholders are filled from the
+	// request's path values, then top-level fields of the request and
+	// response bodies, so "{callback_url}" uses a URL the client sent.
+	// Values are path-escaped unless the placeholder starts the URL or its
+	// path, and webhooks with placeholders left unfilled are not sent.
+	URL string
+	// Schema describes the payload, which the generator produces with the
+	// route's response in RequestContext.Webhook. Nil sends the response.
+	Schema any
+	// Delay is how long to wait before the first attempt.
+	Delay time.Duration
+	// Secret signs payloads with HMAC-SHA256, see VerifyWebhookSignature.
+	Secret string
+	// SignatureHeader carries the signature, default DefaultSignatureHeader.
+	SignatureHeader string
+	// Headers are extra request headers.
+	Headers map[string]string
+	// MaxAttempts bounds deliveries including retries, default 3.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for each
+	// following one, default 1s.
+	Backoff time.Duration
+	// When is a rule: the webhook is sent only when these response fields,
+	// by dotted path, have these values ({"status": "succeeded"}).
+	When map[string]string
+	// Manual webhooks are only sent when triggered by a generator
+	// (TriggerWebhook) or an agent (SendWebhook, the send_webhook tool).
+	Manual bool
+}
+
+// WebhookEvent describes the webhook whose payload is being generated, see
+// RequestContext.Webhook.
+type WebhookEvent struct {
+	Name     string          `json:"name,omitempty"`
+	URL      string          `json:"url"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// WebhookDelivery reports the progress of a webhook delivery.
+type WebhookDelivery struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name,omitempty"`
+	URL        string    `json:"url"`
+	Status     string    `json:"status"` // "pending", "delivered" or "failed"
+	Attempts   int       `json:"attempts"`
+	LastStatus int       `json:"last_status,omitempty"` // HTTP status of the last attempt
+	LastError  string    `json:"last_error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RouteWebhook declares webhooks sent after the route responds. Unless
+// Manual, each is sent after every response matching its When rule.
+//
+//	mux.Handle("POST /charge", g.Stub(Charge{}, gobo.RouteWebhook(gobo.Webhook{
+//	    Name:   "charge.succeeded",
+//	    URL:    "http://localhost:8080/webhooks/payments",
+//	    Schema: ChargeEvent{},
+//	    Delay:  2 * time.Second,
+//	    Secret: "whsec_test",
+//	    When:   map[string]string{"status": "succeeded"},
+//	})))
+func RouteWebhook(hooks ...Webhook) RouteOption {
+	return func(r *routeSchema) {
+		r.Webhooks = append(r.Webhooks, hooks...)
+	}
+}
+
+// webhookTriggersKey carries the webhooks a generator triggered back to the
+// handler.
+type webhookTriggersKey struct{}
+
+type webhookTrigger struct {
+	name    string
+	payload []byte
+}
+
+type webhookTriggers struct {
+	mu   sync.Mutex
+	list []webhookTrigger
+}
+
+// withWebhookTriggers returns a context in which TriggerWebhook records
+// triggers.
+func withWebhookTriggers(ctx context.Context) (context.Context, *webhookTriggers) {
+	t := &webhookTriggers{}
+	return context.WithValue(ctx, webhookTriggersKey{}, t), t
+}
+
+// TriggerWebhook lets a generator send one of the route's webhooks by name
+// once the response is written, even a Manual one or one whose When rule
+// does not match. A nil payload is generated as usual. It does nothing
+// outside of a route's generation.
+func TriggerWebhook(ctx context.Context, name string, payload []byte) {
+	if t, ok := ctx.Value(webhookTriggersKey{}).(*webhookTriggers); ok {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.list = append(t.list, webhookTrigger{name: name, payload: payload})
+	}
+}
+
+// fireWebhooks sends the route's webhooks after a response: those whose
+// rule matches, and those the generator triggered.
+func (g *Gobo) fireWebhooks(r *http.Request, route *routeSchema, reqCtx RequestContext, response []byte, triggers *webhookTriggers) {
+	var triggered []webhookTrigger
+	if triggers != nil {
+		triggers.mu.Lock()
+		triggered = triggers.list
+		triggers.mu.Unlock()
+	}
+	if len(route.Webhooks) == 0 {
+		for _, t := range triggered {
+			g.logf("Ignoring webhook %q triggered on %s without webhooks", t.name, r.URL.Path)
+		}
+		return
+	}
+
+	var resp any
+	_ = json.Unmarshal(response, &resp)
+	for _, hook := range route.Webhooks {
+		if !hook.Manual && matchesRule(resp, hook.When) {
+			g.queueWebhook(r, route, reqCtx, hook, response, nil)
+		}
+	}
+	for _, t := range triggered {
+		hook, ok := findWebhook(route.Webhooks, t.name)
+		if !ok {
+			g.logf("Ignoring unknown webhook %q triggered on %s", t.name, r.URL.Path)
+			continue
+		}
+		g.queueWebhook(r, route, reqCtx, hook, response, t.payload)
+	}
+}
+
+// queueWebhook resolves a route webhook's URL and starts its delivery.
+func (g *Gobo) queueWebhook(r *http.Request, route *routeSchema, reqCtx RequestContext, hook Webhook, response, payload []byte) {
+	values := pathValues(route.PathPrefix, r.URL.Path)
+	for _, m := range placeholderPattern.FindAllStringSubmatch(hook.URL, -1) {
+		// Handlers routed by a ServeMux know their own path values
+		if v := r.PathValue(m[1]); v != "" {
+			values[m[1]] = v
+		}
+	}
+	for _, body := range []string{reqCtx.Body, string(response)} {
+		var fields map[string]any
+		if json.Unmarshal([]byte(body), &fields) == nil {
+			for k, v := range fields {
+				if _, ok := values[k]; !ok {
+					values[k] = scalarText(v)
+				}
+			}
+		}
+	}
+	hook.URL = fillPlaceholders(hook.URL, values)
+	if err := checkPlaceholders(hook.URL); err != nil {
+		g.logf("Skipping webhook %s: %v", hook.Name, err)
+		return
+	}
+	reqCtx.Webhook = &WebhookEvent{Name: hook.Name, URL: hook.URL}
+	if json.Valid(response) {
+		reqCtx.Webhook.Response = response
+	}
+	g.deliverWebhook(context.WithoutCancel(r.Context()), g.generator(route), hook, reqCtx, payload, response)
+}
+
+// SendWebhook sends a webhook apart from any request, after its Delay, and
+// returns the delivery id. A nil payload is generated from the webhook's
+// Schema. Agents use it through the send_webhook MCP tool.
+func (g *Gobo) SendWebhook(hook Webhook, payload []byte) (string, error) {
+	if hook.URL == "" {
+		return "", errors.New("webhook has no URL")
+	}
+	if err := checkPlaceholders(hook.URL); err != nil {
+		return "", err
+	}
+	if payload == nil && hook.Schema == nil {
+		return "", errors.New("webhook has neither a payload nor a schema")
+	}
+	if payload != nil && !json.Valid(payload) {
+		return "", errors.New("webhook payload is not valid JSON")
+	}
+	reqCtx := RequestContext{
+		Method:  http.MethodPost,
+		URL:     hook.URL,
+		Webhook: &WebhookEvent{Name: hook.Name, URL: hook.URL},
+	}
+	return g.deliverWebhook(context.Background(), g.generator(nil), hook, reqCtx, payload, nil), nil
+}
+
+// WebhookNamed returns a webhook declared on a route. Webhooks of handler
+// routes are known once mounted with Handle, or else once the handler has
+// served a request.
+func (g *Gobo) WebhookNamed(name string) (Webhook, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, routes := range [][]*routeSchema{g.fileRoutes, g.routes} {
+		for _, route := range routes {
+			if hook, ok := findWebhook(route.Webhooks, name); ok {
+				return hook, true
+			}
+		}
+	}
+	for _, route := range g.handlerRoutes {
+		if hook, ok := findWebhook(route.Webhooks, name); ok {
+			return hook, true
+		}
+	}
+	return Webhook{}, false
+}
+
+// WebhookDeliveries lists recent webhook deliveries, newest first.
+func (g *Gobo) WebhookDeliveries() []WebhookDelivery {
+	return g.webhooks.list()
+}
+
+// deliverWebhook records a delivery and sends it in the background.
+func (g *Gobo) deliverWebhook(ctx context.Context, gen Generator, hook Webhook, reqCtx RequestContext, payload, response []byte) string {
+	d := g.webhooks.add(hook)
+	go func() {
+		if hook.Delay > 0 {
+			time.Sleep(hook.Delay)
+		}
+		if payload == nil {
+			var err error
+			if payload, err = webhookPayload(ctx, gen, hook, reqCtx, response); err != nil {
+				g.logf("Webhook %s: %v", d.ID, err)
+				g.webhooks.update(d.ID, func(d *WebhookDelivery) {
+					d.Status, d.LastError = "failed", err.Error()
+				})
+				return
+			}
+		}
+		g.sendWebhook(hook, d.ID, payload)
+	}()
+	return d.ID
+}
+
+// webhookPayload generates a payload from the webhook's schema, or falls
+// back to the route's response.
+func webhookPayload(ctx context.Context, gen Generator, hook Webhook, reqCtx RequestContext, response []byte) ([]byte, error) {
+	if hook.Schema == nil {
+		if !json.Valid(response) {
+			return nil, errors.New("response is not JSON and the webhook has no schema")
+		}
+		return response, nil
+	}
+	if gen == nil {
+		gen = StaticGenerator{}
+	}
+	reqCtx.Examples, reqCtx.Prompt = nil, nil
+	payload, err := gen.GenerateResponse(ctx, reqCtx, hook.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate payload: %w", err)
+	}
+	if !json.Valid(payload) {
+		return nil, errors.New("generated payload is not valid JSON")
+	}
+	return payload, nil
+}
+
+// sendWebhook POSTs the payload, retrying with exponential backoff.
+func (g *Gobo) sendWebhook(hook Webhook, id string, payload []byte) {
+	attempts := hook.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	backoff := hook.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		status, err := postWebhook(client, hook, id, attempt, payload)
+		delivered := err == nil && status >= 200 && status < 300
+		g.webhooks.update(id, func(d *WebhookDelivery) {
+			d.Attempts, d.LastStatus, d.LastError = attempt, status, ""
+			if err != nil {
+				d.LastError = err.Error()
+			}
+			switch {
+			case delivered:
+				d.Status = "delivered"
+			case attempt == attempts:
+				d.Status = "failed"
+			}
+		})
+		if delivered {
+			g.logf("Webhook %s delivered to %s", id, hook.URL)
+			return
+		}
+		g.logf("Webhook %s attempt %d to %s failed: status %d, %v", id, attempt, hook.URL, status, err)
+	}
+}
+
+// postWebhook makes a single delivery attempt.
+func postWebhook(client *http.Client, hook Webhook, id string, attempt int, payload []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Gobo-Webhooks/1.0")
+	req.Header.Set("X-Gobo-Webhook-Id", id)
+	req.Header.Set("X-Gobo-Webhook-Attempt", strconv.Itoa(attempt))
+	if hook.Name != "" {
+		req.Header.Set("X-Gobo-Webhook", hook.Name)
+	}
+	if hook.Secret != "" {
+		header := hook.SignatureHeader
+		if header == "" {
+			header = DefaultSignatureHeader
+		}
+		req.Header.Set(header, SignWebhook(hook.Secret, time.Now(), payload))
+	}
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the signature header value of a payload:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<payload>">".
+func SignWebhook(secret string, t time.Time, payload []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + webhookMAC(secret, ts, payload)
+}
+
+// VerifyWebhookSignature checks a signature header made by SignWebhook,
+// for webhook handlers under test. Signatures older than tolerance are
+// rejected; zero skips the check.
+func VerifyWebhookSignature(secret, header string, payload []byte, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	if ts == "" || sig == "" {
+		return errors.New("malformed webhook signature")
+	}
+	if !hmac.Equal([]byte(sig), []byte(webhookMAC(secret, ts, payload))) {
+		return errors.New("webhook signature mismatch")
+	}
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return errors.New("malformed webhook timestamp")
+		}
+		if time.Since(time.Unix(unix, 0)) > tolerance {
+			return errors.New("webhook signature expired")
+		}
+	}
+	return nil
+}
+
+func webhookMAC(secret, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func findWebhook(hooks []Webhook, name string) (Webhook, bool) {
+	for _, hook := range hooks {
+		if hook.Name == name && name != "" {
+			return hook, true
+		}
+	}
+	return Webhook{}, false
+}
+
+// matchesRule reports whether a decoded response has the values of a When
+// rule; an empty rule always matches.
+func matchesRule(resp any, when map[string]string) bool {
+	for path, want := range when {
+		value := resp
+		for _, key := range strings.Split(path, ".") {
+			fields, ok := value.(map[string]any)
+			if !ok {
+				return false
+			}
+			value = fields[key]
+		}
+		if value == nil || scalarText(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z0-9_.-]+)\}`)
+
+// fillPlaceholders replaces {name} placeholders in a URL with values;
+// unknown ones are kept. Values are path-escaped, except where a placeholder
+// starts the URL or its path, so "{callback_url}" can hold a whole URL and
+// "https://host{path}" a whole path.
+func fillPlaceholders(s string, values map[string]string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(s[last:loc[0]])
+		last = loc[1]
+		v, ok := values[s[loc[2]:loc[3]]]
+		switch {
+		case !ok:
+			b.WriteString(s[loc[0]:loc[1]])
+		case urlPrefix.MatchString(b.String()):
+			b.WriteString(v)
+		default:
+			b.WriteString(url.PathEscape(v))
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// urlPrefix matches the part of a URL before its path.
+var urlPrefix = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*://[^/?#]*)?$`)
+
+// checkPlaceholders reports placeholders left in a webhook URL.
+func checkPlaceholders(s string) error {
+	if left := placeholderPattern.FindAllString(s, -1); len(left) > 0 {
+		return fmt.Errorf("webhook URL %s has unfilled placeholders %s", s, strings.Join(left, ", "))
+	}
+	return nil
+}
+
+// webhookLog keeps the most recent deliveries.
+type webhookLog struct {
+	mu         sync.Mutex
+	deliveries []*WebhookDelivery // oldest first
+}
+
+func newWebhookLog() *webhookLog {
+	return &webhookLog{}
+}
+
+func (l *webhookLog) add(hook Webhook) *WebhookDelivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d := &WebhookDelivery{
+		ID:        uuid.New().String(),
+		Name:      hook.Name,
+		URL:       hook.URL,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}
+	l.deliveries = append(l.deliveries, d)
+	if len(l.deliveries) > maxWebhookDeliveries {
+		l.deliveries = l.deliveries[len(l.deliveries)-maxWebhookDeliveries:]
+	}
+	return d
+}
+
+func (l *webhookLog) update(id string, fn func(*WebhookDelivery)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, d := range l.deliveries {
+		if d.ID == id {
+			fn(d)
+			return
+		}
+	}
+}
+
+func (l *webhookLog) list() []WebhookDelivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]WebhookDelivery, 0, len(l.deliveries))
+	for i := len(l.deliveries) - 1; i >= 0; i-- {
+		out = append(out, *l.deliveries[i])
+	}
+	return out
+}
@@ -0,0 +1,215 @@
+package gobo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type receivedWebhook struct {
+	path    string
+	header  http.Header
+	payload []byte
+}
+
+// webhookReceiver records webhooks, failing the first failures attempts.
+func webhookReceiver(t *testing.T, failures int32) (*httptest.Server, chan receivedWebhook) {
+	t.Helper()
+	received := make(chan receivedWebhook, 10)
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedWebhook{path: r.URL.Path, header: r.Header, payload: body}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+func waitWebhook(t *testing.T, received chan receivedWebhook) receivedWebhook {
+	t.Helper()
+	select {
+	case wh := <-received:
+		return wh
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the webhook")
+		return receivedWebhook{}
+	}
+}
+
+// webhookGenerator answers responses with a charge and webhook payloads
+// with an event built from the charge.
+type webhookGenerator struct {
+	trigger string
+}
+
+func (g *webhookGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	if wh := reqCtx.Webhook; wh != nil {
+		var charge map[string]any
+		_ = json.Unmarshal(wh.Response, &charge)
+		return json.Marshal(map[string]any{"type": wh.Name, "transaction_id": charge["transaction_id"]})
+	}
+	if g.trigger != "" {
+		TriggerWebhook(ctx, g.trigger, nil)
+	}
+	return []byte(`{"transaction_id":"tx_1","status":"succeeded","callback_url":"/hooks/cb"}`), nil
+}
+
+func TestWebhook_RuleAndSignature(t *testing.T) {
+	srv, received := webhookReceiver(t, 0)
+	g := New(WithGenerator(&webhookGenerator{}))
+	h := g.Stub(nil, RouteWebhook(
+		Webhook{
+			Name:   "charge.succeeded",
+			URL:    srv.URL + "/hooks/{order}",
+			Schema: map[string]any{"type": "", "transaction_id": ""},
+			Secret: "whsec_test",
+			When:   map[string]string{"status": "succeeded"},
+		},
+		Webhook{Name: "charge.failed", URL: srv.URL + "/failed", When: map[string]string{"status": "failed"}},
+	))
+	mux := http.NewServeMux()
+	mux.Handle("POST /orders/{order}/charge", h)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/orders/o42/charge", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	wh := waitWebhook(t, received)
+	if wh.path != "/hooks/o42" {
+		t.Errorf("Expected the path value in the URL, got %s", wh.path)
+	}
+	if string(wh.payload) != `{"transaction_id":"tx_1","type":"charge.succeeded"}` {
+		t.Errorf("Expected a payload generated from the response, got %s", wh.payload)
+	}
+	if err := VerifyWebhookSignature("whsec_test", wh.header.Get(DefaultSignatureHeader), wh.payload, time.Minute); err != nil {
+		t.Errorf("Expected a valid signature: %v", err)
+	}
+	if err := VerifyWebhookSignature("other", wh.header.Get(DefaultSignatureHeader), wh.payload, 0); err == nil {
+		t.Error("Expected a signature mismatch with another secret")
+	}
+	if wh.header.Get("X-Gobo-Webhook") != "charge.succeeded" || wh.header.Get("X-Gobo-Webhook-Id") == "" {
+		t.Errorf("Unexpected headers %v", wh.header)
+	}
+
+	select {
+	case extra := <-received:
+		t.Errorf("Expected the failed rule not to fire, got %s", extra.path)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhook_Retries(t *testing.T) {
+	srv, received := webhookReceiver(t, 2)
+	g := New()
+	id, err := g.SendWebhook(Webhook{URL: srv.URL, Backoff: 5 * time.Millisecond}, []byte(`{"ok":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wh := waitWebhook(t, received)
+	if wh.header.Get("X-Gobo-Webhook-Attempt") != "3" || wh.header.Get("X-Gobo-Webhook-Id") != id {
+		t.Errorf("Expected the third attempt of %s, got %v", id, wh.header)
+	}
+
+	var d WebhookDelivery
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if d = g.WebhookDeliveries()[0]; d.Status == "delivered" {
+			break
+		}
+	}
+	if d.ID != id || d.Status != "delivered" || d.Attempts != 3 || d.LastStatus != http.StatusOK {
+		t.Errorf("Unexpected delivery %+v", d)
+	}
+
+	failing, _ := webhookReceiver(t, 100)
+	id, _ = g.SendWebhook(Webhook{URL: failing.URL, MaxAttempts: 2, Backoff: time.Millisecond}, []byte(`{}`))
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if d = g.WebhookDeliveries()[0]; d.Status == "failed" {
+			break
+		}
+	}
+	if d.ID != id || d.Status != "failed" || d.Attempts != 2 || d.LastStatus != http.StatusBadGateway {
+		t.Errorf("Expected a failed delivery after 2 attempts, got %+v", d)
+	}
+
+	if _, err := g.SendWebhook(Webhook{URL: srv.URL}, nil); err == nil {
+		t.Error("Expected an error without payload or schema")
+	}
+}
+
+func TestWebhook_GeneratorTrigger(t *testing.T) {
+	srv, received := webhookReceiver(t, 0)
+	g := New(WithGenerator(&webhookGenerator{trigger: "refund"}))
+	h := g.Stub(nil, RouteWebhook(Webhook{Name: "refund", URL: srv.URL + "{callback_url}", Manual: true}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/refunds", nil))
+	wh := waitWebhook(t, received)
+	if wh.path != "/hooks/cb" {
+		t.Errorf("Expected the response field in the URL, got %s", wh.path)
+	}
+	if !strings.Contains(string(wh.payload), `"transaction_id":"tx_1"`) {
+		t.Errorf("Expected the response as payload, got %s", wh.payload)
+	}
+}
+
+func TestWebhook_Placeholders(t *testing.T) {
+	values := map[string]string{"order": "a/b c", "callback_url": "https://merchant.test/cb?x=1", "path": "/hooks/cb"}
+	tests := []struct{ url, want string }{
+		{"https://api.test/orders/{order}", "https://api.test/orders/a%2Fb%20c"},
+		{"{callback_url}", "https://merchant.test/cb?x=1"},
+		{"https://api.test{path}", "https://api.test/hooks/cb"},
+		{"https://api.test/{missing}", "https://api.test/{missing}"},
+	}
+	for _, tt := range tests {
+		if got := fillPlaceholders(tt.url, values); got != tt.want {
+			t.Errorf("fillPlaceholders(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	g := New()
+	_, err := g.SendWebhook(Webhook{Name: "refund", URL: "{callback_url}"}, []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "{callback_url}") {
+		t.Errorf("Expected an unfilled placeholder error, got %v", err)
+	}
+	if deliveries := g.WebhookDeliveries(); len(deliveries) != 0 {
+		t.Errorf("Expected nothing to be sent, got %+v", deliveries)
+	}
+}
+
+func TestWebhook_CachingRouteGenerator(t *testing.T) {
+	srv, received := webhookReceiver(t, 0)
+	g := New()
+	h := g.Stub(nil,
+		RouteGenerator(NewCachingGenerator(&webhookGenerator{}, CacheConfig{})),
+		RouteWebhook(Webhook{Name: "charge.succeeded", URL: srv.URL + "/hooks", Schema: map[string]any{"type": ""}}),
+	)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/charge", strings.NewReader(`{}`)))
+	wh := waitWebhook(t, received)
+	if string(wh.payload) != `{"transaction_id":"tx_1","type":"charge.succeeded"}` {
+		t.Errorf("Expected a generated webhook payload, not the cached response, got %s", wh.payload)
+	}
+}
+
+func TestWebhook_Named(t *testing.T) {
+	g := New()
+	g.Register("POST", "/charge", nil, RouteWebhook(Webhook{Name: "charge.succeeded", URL: "http://localhost/hook"}))
+	if hook, ok := g.WebhookNamed("charge.succeeded"); !ok || hook.URL != "http://localhost/hook" {
+		t.Errorf("Expected the declared webhook, got %+v", hook)
+	}
+	if _, ok := g.WebhookNamed("missing"); ok {
+		t.Error("Expected no webhook")
+	}
+}
@@ -0,0 +1,544 @@
+package gobo
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// WebSocket opcodes and close codes from RFC 6455.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+
+	wsCloseNormal        = 1000
+	wsCloseProtocolError = 1002
+	wsCloseInvalidData   = 1007
+	wsCloseTooBig        = 1009
+
+	// wsGUID is appended to the client key to compute Sec-WebSocket-Accept.
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	maxWebSocketMessage = 1 << 20
+)
+
+// ScriptedMessage is a message a WebSocket stub sends on its own, see
+// RouteScript.
+type ScriptedMessage struct {
+	// After is the delay from the connection being opened.
+	After time.Duration
+	// Every repeats the message at this interval; zero sends it once.
+	Every time.Duration
+	// Data is sent as-is when it is a string or []byte, as JSON otherwise.
+	// Nil generates a message from the route schema.
+	Data any
+}
+
+// RouteScript adds server-initiated messages to a WebSocket route, such as
+// a price tick every second.
+func RouteScript(messages ...ScriptedMessage) RouteOption {
+	return func(r *routeSchema) {
+		r.Script = append(r.Script, messages...)
+	}
+}
+
+// SocketInfo describes an open WebSocket connection.
+type SocketInfo struct {
+	ID       string    `json:"id"`
+	URL      string    `json:"url"`
+	OpenedAt time.Time `json:"opened_at"`
+	Received int       `json:"received"`
+	Sent     int       `json:"sent"`
+}
+
+// WebSocket returns an http.Handler that accepts WebSocket connections, for
+// feeds that cannot run locally. Every message from the client is passed to
+// the generator as a request with method "WEBSOCKET", the message as its
+// body (base64-encoded for binary messages) and RequestContext.Socket set;
+// the generated response, shaped by schema, is sent back as a text message.
+// Messages are answered one at a time, in order. Scripted messages
+// (RouteScript) are pushed on timers, and agents can send messages to open
+// sockets with SendSocketMessage.
+//
+//	mux.Handle("GET /prices", g.WebSocket(PriceTick{},
+//	    gobo.RouteScript(gobo.ScriptedMessage{Every: time.Second})))
+func (g *Gobo) WebSocket(schema any, opts ...RouteOption) http.Handler {
+	route := newRoute(schema, opts)
+	return &routeHandler{route: route, serve: func(w http.ResponseWriter, r *http.Request) {
+		g.observe(r, route)
+		conn, err := upgradeWebSocket(w, r)
+		if err != nil {
+			g.logf("WebSocket upgrade failed for %s: %v", r.URL.Path, err)
+			return
+		}
+		g.serveSocket(conn, r, route)
+	}}
+}
+
+// SendSocketMessage sends a text message to an open WebSocket.
+func (g *Gobo) SendSocketMessage(id string, data []byte) error {
+	conn := g.sockets.get(id)
+	if conn == nil {
+		return fmt.Errorf("no open socket with id %s", id)
+	}
+	return conn.writeMessage(wsText, data)
+}
+
+// CloseSocket closes an open WebSocket with a normal closure.
+func (g *Gobo) CloseSocket(id string) error {
+	conn := g.sockets.get(id)
+	if conn == nil {
+		return fmt.Errorf("no open socket with id %s", id)
+	}
+	return conn.close(wsCloseNormal, "closed by gobo")
+}
+
+// Sockets lists the open WebSocket connections, oldest first.
+func (g *Gobo) Sockets() []SocketInfo {
+	return g.sockets.list()
+}
+
+// serveSocket runs a connection until the client leaves.
+func (g *Gobo) serveSocket(conn *wsConn, r *http.Request, route *routeSchema) {
+	g.sockets.add(conn)
+	defer g.sockets.remove(conn.info.ID)
+	defer conn.netConn.Close()
+	g.logf("WebSocket %s opened on %s", conn.info.ID, r.URL.Path)
+
+	done := make(chan struct{})
+	defer close(done)
+	for _, msg := range route.Script {
+		go g.runScript(conn, r, route, msg, done)
+	}
+
+	// A single worker answers messages, so replies keep the client's order
+	messages := make(chan string, 16)
+	defer close(messages)
+	go func() {
+		for message := range messages {
+			g.answerSocket(conn, r, route, message, done)
+		}
+	}()
+
+	for {
+		op, data, err := conn.readMessage()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				g.logf("WebSocket %s closed: %v", conn.info.ID, err)
+			}
+			return
+		}
+		message := string(data)
+		if op == wsBinary {
+			message = base64.StdEncoding.EncodeToString(data)
+		}
+		messages <- message
+	}
+}
+
+// answerSocket generates a reply to a client message.
+func (g *Gobo) answerSocket(conn *wsConn, r *http.Request, route *routeSchema, message string, done <-chan struct{}) {
+	out, err := g.socketMessage(conn, r, route, message, done)
+	if err != nil {
+		g.logf("WebSocket %s generation failed: %v", conn.info.ID, err)
+		return
+	}
+	_ = conn.writeMessage(wsText, out)
+}
+
+// runScript sends a scripted message after its delay, repeating it if asked.
+func (g *Gobo) runScript(conn *wsConn, r *http.Request, route *routeSchema, msg ScriptedMessage, done <-chan struct{}) {
+	timer := time.NewTimer(msg.After)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		}
+
+		out, err := scriptPayload(msg.Data)
+		if msg.Data == nil {
+			out, err = g.socketMessage(conn, r, route, "", done)
+		}
+		if err != nil {
+			g.logf("WebSocket %s script failed: %v", conn.info.ID, err)
+		} else if conn.writeMessage(wsText, out) != nil {
+			return
+		}
+
+		if msg.Every <= 0 {
+			return
+		}
+		timer.Reset(msg.Every)
+	}
+}
+
+// socketMessage asks the route's generator for a message. message is the
+// client message being answered, empty for scripted ones.
+func (g *Gobo) socketMessage(conn *wsConn, r *http.Request, route *routeSchema, message string, done <-chan struct{}) ([]byte, error) {
+	reqCtx := RequestContext{
+		Method:  "WEBSOCKET",
+		URL:     r.URL.String(),
+		Headers: r.Header,
+		Body:    message,
+		Socket:  conn.info.ID,
+	}
+	key := routeKey(r, route)
+	reqCtx.Examples = g.examplesFor(key, route)
+	reqCtx.Prompt = g.promptFor(key, route)
+
+	gen := g.generator(route)
+	if gen == nil {
+		gen = StaticGenerator{}
+	}
+	ctx, cancel := contextUntil(done)
+	defer cancel()
+	return gen.GenerateResponse(ctx, reqCtx, route.ResponseSchema)
+}
+
+// contextUntil returns a context cancelled when done is closed.
+func contextUntil(done <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// scriptPayload encodes scripted message data.
+func scriptPayload(data any) ([]byte, error) {
+	switch d := data.(type) {
+	case string:
+		return []byte(d), nil
+	case []byte:
+		return d, nil
+	}
+	return json.Marshal(data)
+}
+
+// upgradeWebSocket performs the RFC 6455 opening handshake and hijacks the
+// connection.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		http.Error(w, "Gobo WebSocket: upgrade required", http.StatusUpgradeRequired)
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Gobo WebSocket: unsupported version", http.StatusBadRequest)
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Gobo WebSocket: missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "Gobo WebSocket: "+err.Error(), http.StatusInternalServerError)
+		return nil, fmt.Errorf("failed to hijack connection: %w", err)
+	}
+	_ = netConn.SetDeadline(time.Time{})
+
+	_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("failed to write handshake: %w", err)
+	}
+
+	return &wsConn{
+		netConn: netConn,
+		reader:  rw.Reader,
+		info:    SocketInfo{ID: uuid.New().String(), URL: r.URL.String(), OpenedAt: time.Now()},
+	}, nil
+}
+
+// acceptKey computes Sec-WebSocket-Accept for a client key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains reports whether a comma-separated header lists token.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn is the server side of a WebSocket connection.
+type wsConn struct {
+	netConn net.Conn
+	reader  *bufio.Reader
+
+	writeMu sync.Mutex // serializes frames from replies, scripts and agents
+	closed  bool
+
+	statsMu sync.Mutex
+	info    SocketInfo
+}
+
+// readMessage returns the next data message, answering pings and
+// reassembling fragments along the way. A close frame ends with io.EOF.
+func (c *wsConn) readMessage() (int, []byte, error) {
+	var op int
+	var msg []byte
+	for {
+		fin, frameOp, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch frameOp {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			code := wsCloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			if len(payload) == 1 || !validCloseCode(code) || !utf8.Valid(payload[min(len(payload), 2):]) {
+				_ = c.close(wsCloseProtocolError, "invalid close frame")
+				return 0, nil, errors.New("invalid close frame")
+			}
+			_ = c.close(code, "")
+			return 0, nil, io.EOF
+		case wsContinuation:
+			if op == 0 {
+				_ = c.close(wsCloseProtocolError, "unexpected continuation")
+				return 0, nil, errors.New("unexpected continuation frame")
+			}
+		case wsText, wsBinary:
+			if op != 0 {
+				_ = c.close(wsCloseProtocolError, "expected continuation")
+				return 0, nil, errors.New("expected continuation frame")
+			}
+			op = frameOp
+		default:
+			_ = c.close(wsCloseProtocolError, "unknown opcode")
+			return 0, nil, fmt.Errorf("unknown opcode %d", frameOp)
+		}
+
+		if len(msg)+len(payload) > maxWebSocketMessage {
+			_ = c.close(wsCloseTooBig, "message too big")
+			return 0, nil, errors.New("message too big")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			if op == wsText && !utf8.Valid(msg) {
+				_ = c.close(wsCloseInvalidData, "invalid UTF-8")
+				return 0, nil, errors.New("invalid UTF-8 in text message")
+			}
+			c.statsMu.Lock()
+			c.info.Received++
+			c.statsMu.Unlock()
+			return op, msg, nil
+		}
+	}
+}
+
+// validCloseCode reports whether a client may send code in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	}
+	return code >= 3000 && code <= 4999
+}
+
+// readFrame reads a single frame and unmasks its payload.
+func (c *wsConn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.reader, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = head[0]&0x80 != 0
+	op = int(head[0] & 0x0F)
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	if head[0]&0x70 != 0 {
+		_ = c.close(wsCloseProtocolError, "reserved bits set")
+		return false, 0, nil, errors.New("reserved bits set without an extension")
+	}
+	if !masked {
+		_ = c.close(wsCloseProtocolError, "client frames must be masked")
+		return false, 0, nil, errors.New("unmasked client frame")
+	}
+	if op >= wsClose && (!fin || length > 125) {
+		_ = c.close(wsCloseProtocolError, "invalid control frame")
+		return false, 0, nil, errors.New("invalid control frame")
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxWebSocketMessage {
+		_ = c.close(wsCloseTooBig, "message too big")
+		return false, 0, nil, errors.New("frame too big")
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeMessage sends a complete data message.
+func (c *wsConn) writeMessage(op int, data []byte) error {
+	if err := c.writeFrame(op, data); err != nil {
+		return err
+	}
+	c.statsMu.Lock()
+	c.info.Sent++
+	c.statsMu.Unlock()
+	return nil
+}
+
+// writeFrame sends a single unmasked frame, as servers must.
+func (c *wsConn) writeFrame(op int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	return c.writeFrameLocked(op, payload)
+}
+
+func (c *wsConn) writeFrameLocked(op int, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|byte(op))
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+	_, err := c.netConn.Write(frame)
+	return err
+}
+
+// close sends a close frame and shuts the connection down. Later writes
+// fail with net.ErrClosed.
+func (c *wsConn) close(code int, reason string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	err := c.writeFrameLocked(wsClose, payload)
+	c.netConn.Close()
+	return err
+}
+
+// socketHub tracks the open sockets of an instance.
+type socketHub struct {
+	mu    sync.Mutex
+	conns map[string]*wsConn
+}
+
+func newSocketHub() *socketHub {
+	return &socketHub{conns: make(map[string]*wsConn)}
+}
+
+func (h *socketHub) add(c *wsConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conns[c.info.ID] = c
+}
+
+func (h *socketHub) remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, id)
+}
+
+func (h *socketHub) get(id string) *wsConn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.conns[id]
+}
+
+func (h *socketHub) list() []SocketInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]SocketInfo, 0, len(h.conns))
+	for _, c := range h.conns {
+		c.statsMu.Lock()
+		out = append(out, c.info)
+		c.statsMu.Unlock()
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OpenedAt.Before(out[j].OpenedAt) })
+	return out
+}
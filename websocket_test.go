@@ -0,0 +1,254 @@
+package gobo
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsClient is a minimal WebSocket client for tests.
+type wsClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, srv *httptest.Server, path string) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	_, _ = io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: test\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: "+key+"\r\nSec-WebSocket-Version: 13\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected Sec-WebSocket-Accept %q", got)
+	}
+	return &wsClient{conn: conn, reader: reader}
+}
+
+// send writes a masked frame.
+func (c *wsClient) send(fin bool, op byte, payload string) {
+	head := op
+	if fin {
+		head |= 0x80
+	}
+	frame := []byte{head, 0x80 | byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i := range len(payload) {
+		frame = append(frame, payload[i]^mask[i%4])
+	}
+	_, _ = c.conn.Write(frame)
+}
+
+// read returns the next frame from the server.
+func (c *wsClient) read(t *testing.T) (byte, string) {
+	t.Helper()
+	_ = c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var head [2]byte
+	if _, err := io.ReadFull(c.reader, head[:]); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	length := int(head[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		_, _ = io.ReadFull(c.reader, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		t.Fatalf("read payload: %v", err)
+	}
+	return head[0] & 0x0F, string(payload)
+}
+
+func TestWebSocket_EchoesGenerated(t *testing.T) {
+	g := New(WithGenerator(&mockGenerator{Response: []byte(`{"price":42}`)}))
+	mux := http.NewServeMux()
+	mux.Handle("GET /prices", g.WebSocket(map[string]any{"price": 0}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := dialWebSocket(t, srv, "/prices")
+
+	// A fragmented message, interleaved with a ping
+	c.send(false, wsText, `{"sub`)
+	c.send(true, wsPing, "hb")
+	c.send(true, wsContinuation, `":"BTC"}`)
+	if op, payload := c.read(t); op != wsPong || payload != "hb" {
+		t.Fatalf("Expected pong, got %d %q", op, payload)
+	}
+	if op, payload := c.read(t); op != wsText || payload != `{"price":42}` {
+		t.Fatalf("Expected generated message, got %d %q", op, payload)
+	}
+
+	sockets := g.Sockets()
+	if len(sockets) != 1 || sockets[0].Received != 1 || sockets[0].Sent != 1 {
+		t.Fatalf("Unexpected sockets %+v", sockets)
+	}
+
+	c.send(true, wsClose, "\x03\xe8")
+	if op, _ := c.read(t); op != wsClose {
+		t.Fatalf("Expected close frame, got %d", op)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(g.Sockets()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := len(g.Sockets()); n != 0 {
+		t.Errorf("Expected the socket to be removed, got %d open", n)
+	}
+}
+
+func TestWebSocket_ScriptAndAgentMessages(t *testing.T) {
+	g := New()
+	mux := http.NewServeMux()
+	mux.Handle("GET /feed", g.WebSocket(map[string]any{"tick": 1},
+		RouteScript(
+			ScriptedMessage{After: 10 * time.Millisecond, Data: map[string]any{"hello": true}},
+			ScriptedMessage{After: 20 * time.Millisecond, Every: 20 * time.Millisecond},
+		)))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := dialWebSocket(t, srv, "/feed")
+	if _, payload := c.read(t); payload != `{"hello":true}` {
+		t.Fatalf("Expected the scripted greeting, got %q", payload)
+	}
+	for range 2 {
+		if _, payload := c.read(t); payload != `{"tick":1}` {
+			t.Fatalf("Expected a repeated tick, got %q", payload)
+		}
+	}
+
+	id := g.Sockets()[0].ID
+	if err := g.SendSocketMessage(id, []byte(`{"agent":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, payload := c.read(t); payload == `{"agent":true}` {
+			break
+		}
+	}
+	if err := g.SendSocketMessage("missing", nil); err == nil {
+		t.Error("Expected an error for an unknown socket")
+	}
+}
+
+func TestWebSocket_Broker(t *testing.T) {
+	broker := NewAsyncBroker()
+	g := New(WithGenerator(broker))
+	srv := httptest.NewServer(g.WebSocket(map[string]any{"reply": ""}))
+	defer srv.Close()
+
+	c := dialWebSocket(t, srv, "/chat")
+	c.send(true, wsText, "hello")
+
+	var pending []PendingRequest
+	deadline := time.Now().Add(time.Second)
+	for len(pending) == 0 && time.Now().Before(deadline) {
+		pending = broker.GetPendingRequests()
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(pending) != 1 {
+		t.Fatal("Expected the message to be parked")
+	}
+	pr := pending[0]
+	if pr.Method != "WEBSOCKET" || pr.Context.Body != "hello" || pr.Context.Socket == "" {
+		t.Fatalf("Unexpected pending request %+v", pr)
+	}
+	if err := broker.SubmitResponse(pr.ID, []byte(`{"reply":"hi"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, payload := c.read(t); payload != `{"reply":"hi"}` {
+		t.Fatalf("Expected the agent's reply, got %q", payload)
+	}
+}
+
+func TestWebSocket_RejectsPlainRequests(t *testing.T) {
+	g := New()
+	rec := httptest.NewRecorder()
+	g.WebSocket(map[string]any{}).ServeHTTP(rec, httptest.NewRequest("GET", "/ws", nil))
+	if rec.Code != http.StatusUpgradeRequired {
+		t.Errorf("Expected 426, got %d", rec.Code)
+	}
+}
+
+// echoGenerator replies with the message body, slowly for "slow".
+type echoGenerator struct{}
+
+func (echoGenerator) GenerateResponse(ctx context.Context, reqCtx RequestContext, schema any) ([]byte, error) {
+	if reqCtx.Body == "slow" {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return json.Marshal(reqCtx.Body)
+}
+
+func TestWebSocket_AnswersInOrder(t *testing.T) {
+	g := New(WithGenerator(echoGenerator{}))
+	srv := httptest.NewServer(g.WebSocket(map[string]any{}))
+	defer srv.Close()
+
+	c := dialWebSocket(t, srv, "/chat")
+	c.send(true, wsText, "slow")
+	c.send(true, wsText, "fast")
+	c.send(true, wsBinary, "\x00\x01")
+	for _, want := range []string{`"slow"`, `"fast"`, `"AAE="`} {
+		if _, payload := c.read(t); payload != want {
+			t.Fatalf("Expected %s, got %s", want, payload)
+		}
+	}
+}
+
+func TestWebSocket_ProtocolErrors(t *testing.T) {
+	g := New()
+	srv := httptest.NewServer(g.WebSocket(map[string]any{}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		op      byte
+		payload string
+		code    uint16
+	}{
+		{"reserved bits", 0x40 | wsText, "hi", wsCloseProtocolError},
+		{"invalid UTF-8", wsText, "\xff\xfe", wsCloseInvalidData},
+		{"one byte close", wsClose, "\x03", wsCloseProtocolError},
+		{"reserved close code", wsClose, "\x03\xed", wsCloseProtocolError},
+		{"application close code", wsClose, "\x0f\xa0", 4000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dialWebSocket(t, srv, "/ws")
+			c.send(true, tt.op, tt.payload)
+			op, payload := c.read(t)
+			if op != wsClose || len(payload) < 2 {
+				t.Fatalf("Expected a close frame, got %d %q", op, payload)
+			}
+			if code := binary.BigEndian.Uint16([]byte(payload)); code != tt.code {
+				t.Errorf("Expected close code %d, got %d", tt.code, code)
+			}
+		})
+	}
+}